@@ -0,0 +1,61 @@
+// Команда fake-info-api запускает подделку внешнего API сведений о песнях для локальной разработки:
+//
+//	go run ./cmd/fake-info-api -addr :8081 -fixtures fixtures.yaml -latency 200ms -error-rate 0.1
+//
+// После запуска song-library настраивается на нее через API_URL=http://localhost:8081.
+package main
+
+import (
+	"flag"
+	"github.com/TakuroBreath/song-library/internal/fakeinfo"
+	"github.com/TakuroBreath/song-library/pkg/sl"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "address to listen on")
+	fixturesPath := flag.String("fixtures", "", "fixtures file (.json, .yaml or .yml); the built-in set is used if empty")
+
+	var cfg fakeinfo.Config
+	flag.DurationVar(&cfg.Latency, "latency", 0, "delay before every response")
+	flag.DurationVar(&cfg.Jitter, "jitter", 0, "maximum random delay added to latency")
+	flag.Float64Var(&cfg.ErrorRate, "error-rate", 0, "fraction of requests answered with 500, from 0 to 1")
+	flag.Float64Var(&cfg.NotFoundRate, "not-found-rate", 0, "fraction of requests answered with 404, from 0 to 1")
+	flag.Uint64Var(&cfg.Seed, "seed", 0, "seed for reproducible failures, 0 for random")
+	verbose := flag.Bool("v", false, "log every request")
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+
+	fixtures, err := fakeinfo.LoadFixtures(*fixturesPath)
+	if err != nil {
+		log.Error("failed to load fixtures", sl.Err(err))
+		os.Exit(1)
+	}
+
+	server, err := fakeinfo.NewServer(fixtures, cfg, log)
+	if err != nil {
+		log.Error("invalid configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("starting fake info API",
+		slog.String("addr", *addr),
+		slog.Int("fixtures", len(fixtures)),
+		slog.Duration("latency", cfg.Latency),
+		slog.Float64("error_rate", cfg.ErrorRate),
+		slog.Float64("not_found_rate", cfg.NotFoundRate))
+
+	srv := &http.Server{Addr: *addr, Handler: server, ReadHeaderTimeout: 5 * time.Second}
+	if err := srv.ListenAndServe(); err != nil {
+		log.Error("failed to start server", sl.Err(err))
+		os.Exit(1)
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	_ "github.com/TakuroBreath/song-library/docs"
 	"github.com/TakuroBreath/song-library/internal/api/handlers"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
 	"github.com/TakuroBreath/song-library/internal/api/routes"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/fakeinfo"
 	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
 	"github.com/TakuroBreath/song-library/internal/storage/postgresql"
+	"github.com/TakuroBreath/song-library/internal/storage/sqlite"
 	"github.com/TakuroBreath/song-library/pkg/migrator"
 	"github.com/TakuroBreath/song-library/pkg/sl"
 	"github.com/gin-gonic/gin"
@@ -15,6 +23,8 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"log/slog"
 	"os"
+	"strconv"
+	"time"
 )
 
 const (
@@ -23,6 +33,43 @@ const (
 	envProd  = "production"
 )
 
+const (
+	storagePostgres = "postgres"
+	storageSQLite   = "sqlite"
+	storageMemory   = "memory"
+)
+
+const defaultSQLitePath = "song-library.db"
+
+const (
+	infoCacheMemory  = "memory"
+	infoCacheStorage = "storage"
+	infoCacheOff     = "off"
+)
+
+const (
+	defaultDBTimeout  = 5 * time.Second
+	defaultAPITimeout = 10 * time.Second
+
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+
+	defaultImportWorkers = 4
+
+	defaultEnrichInterval     = 2 * time.Second
+	defaultEnrichBatchSize    = 10
+	defaultEnrichMaxAttempts  = 5
+	defaultEnrichRetryBackoff = 30 * time.Second
+
+	defaultRefreshInterval  = time.Minute
+	defaultRefreshMaxAge    = 30 * 24 * time.Hour
+	defaultRefreshBatchSize = 10
+
+	defaultInfoCacheTTL         = 24 * time.Hour
+	defaultInfoCacheNotFoundTTL = time.Hour
+	defaultInfoCacheSize        = 1000
+)
+
 // @title           Song Library API
 // @version         1.0
 // @description     API Server for Song Library Application
@@ -45,39 +92,174 @@ func main() {
 		panic("Error loading .env file")
 	}
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
 	env := os.Getenv("ENV")
 	log := setupLogger(env)
 
 	log.Info("starting song-library", slog.String("env", env))
 	log.Debug("debug messages are enabled")
 
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	songStorage, err := setupStorage(os.Getenv("STORAGE"), log)
+	if err != nil {
+		log.Error("failed to create storage", sl.Err(err))
+		os.Exit(1)
+	}
 
-	if err := migrator.Migrate(user, password, host, port, dbname, log); err != nil {
-		log.Error("failed to apply migrations", sl.Err(err))
+	timeouts, err := setupTimeouts()
+	if err != nil {
+		log.Error("invalid timeout configuration", sl.Err(err))
 		os.Exit(1)
 	}
 
-	storage, err := postgresql.NewStorage(psqlInfo, log)
+	songInfoConfig, err := setupSongInfo()
 	if err != nil {
-		log.Error("failed to create storage", sl.Err(err))
+		log.Error("invalid external API configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	apiURL := os.Getenv("API_URL")
+
+	// В режиме разработки внешний API заменяется подделкой в том же процессе
+	if os.Getenv("FAKE_INFO_API") == "true" {
+		if env == envProd {
+			log.Error("FAKE_INFO_API is not allowed in production")
+			os.Exit(1)
+		}
+		// Источники из файла настроек не знают адреса подделки, и она осталась бы без запросов
+		if os.Getenv("METADATA_CONFIG") != "" {
+			log.Error("FAKE_INFO_API cannot be combined with METADATA_CONFIG")
+			os.Exit(1)
+		}
+		if apiURL != "" {
+			log.Warn("API_URL is ignored because FAKE_INFO_API is enabled", slog.String("api_url", apiURL))
+		}
+
+		apiURL, err = startFakeInfoAPI(log)
+		if err != nil {
+			log.Error("failed to start fake info API", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	providers, err := setupMetadataProviders(songInfoConfig, apiURL, log)
+	if err != nil {
+		log.Error("invalid metadata providers configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	var songInfo songinfo.MetadataProvider = songinfo.NewChain(providers, log)
+
+	infoCache, err := setupInfoCache(songInfo, songStorage, log)
+	if err != nil {
+		log.Error("invalid song info cache configuration", sl.Err(err))
+		os.Exit(1)
+	}
+	if infoCache != nil {
+		songInfo = infoCache
+	}
+
+	songService := service.NewSongService(songStorage, songInfo, timeouts, log)
+	songHandler := handlers.NewSongHandler(songService, os.Getenv("REQUIRE_IF_MATCH") == "true")
+
+	// Песни, сохраненные до разбора текстов на части или разобранные прежней версией разборщика,
+	// разбираются в фоне; до этого их части пусты
+	go songService.ReparseVerses(context.Background(), false)
+
+	infoCacheService := service.NewInfoCacheService(infoCache, timeouts, log)
+	adminHandler := handlers.NewAdminHandler(infoCacheService, songService)
+
+	artistService := service.NewArtistService(songStorage, timeouts, log)
+	artistHandler := handlers.NewArtistHandler(artistService)
+
+	albumService := service.NewAlbumService(songStorage, timeouts, log)
+	albumHandler := handlers.NewAlbumHandler(albumService)
+
+	trashRetention, err := durationEnv("TRASH_RETENTION", defaultTrashRetention)
+	if err == nil && trashRetention <= 0 {
+		// Иначе очистка сразу удалила бы навсегда все песни из корзины
+		err = fmt.Errorf("TRASH_RETENTION must be positive")
+	}
+	if err != nil {
+		log.Error("invalid trash configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	trashPurgeInterval, err := durationEnv("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+	if err != nil {
+		log.Error("invalid trash configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	importWorkers, err := intEnv("IMPORT_WORKERS", defaultImportWorkers)
+	if err != nil || importWorkers < 1 {
+		log.Error("invalid import configuration", slog.String("IMPORT_WORKERS", os.Getenv("IMPORT_WORKERS")))
 		os.Exit(1)
 	}
 
-	songService := service.NewSongService(storage, os.Getenv("API_URL"), log)
-	songHandler := handlers.NewSongHandler(songService)
+	importService := service.NewImportService(songService, importWorkers, log)
+	importHandler := handlers.NewImportHandler(importService)
+
+	enrichInterval, err := durationEnv("ENRICH_INTERVAL", defaultEnrichInterval)
+	if err != nil {
+		log.Error("invalid enrichment configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	enrichConfig, err := setupEnrichment()
+	if err != nil {
+		log.Error("invalid enrichment configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	enrichmentService := service.NewEnrichmentService(songStorage, songService, enrichConfig, timeouts, log)
+
+	// Нулевой интервал отключает обогащение в этом экземпляре: песни ждут в очереди другой экземпляр
+	if enrichInterval > 0 {
+		go enrichmentService.RunWorker(context.Background(), enrichInterval)
+	}
+
+	refreshInterval, err := durationEnv("REFRESH_INTERVAL", defaultRefreshInterval)
+	if err != nil {
+		log.Error("invalid refresh configuration", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router := gin.Default()
+	refreshConfig, err := setupRefresh()
+	if err != nil {
+		log.Error("invalid refresh configuration", sl.Err(err))
+		os.Exit(1)
+	}
+
+	refreshService := service.NewRefreshService(songStorage, songService, refreshConfig, timeouts, log)
+	refreshHandler := handlers.NewRefreshHandler(refreshService)
+
+	// Нулевой интервал отключает фоновое обновление, остается только обновление по запросу
+	if refreshInterval > 0 {
+		go refreshService.RunWorker(context.Background(), refreshInterval)
+	}
+
+	trashService := service.NewTrashService(songStorage, trashRetention, timeouts, log)
+	trashHandler := handlers.NewTrashHandler(trashService)
+
+	// Нулевой интервал отключает фоновую очистку корзины, остается только ручная
+	if trashPurgeInterval > 0 {
+		go trashService.RunPurger(context.Background(), trashPurgeInterval)
+	}
+
+	router := gin.New()
 	gin.SetMode(gin.DebugMode)
 
+	// Вместо gin.Recovery: обрыв выгрузки паникой http.ErrAbortHandler должен дойти до сервера
+	router.Use(gin.Logger(), middleware.Recovery(log))
+	router.Use(middleware.ErrorHandler(log))
+	router.Use(middleware.Actor())
+
 	routes.SetupSongRoutes(router, songHandler)
+	routes.SetupImportRoutes(router, importHandler)
+	routes.SetupTrashRoutes(router, trashHandler)
+	routes.SetupRefreshRoutes(router, refreshHandler)
+	routes.SetupArtistRoutes(router, artistHandler)
+	routes.SetupAlbumRoutes(router, albumHandler)
+	routes.SetupAdminRoutes(router, adminHandler)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -107,3 +289,267 @@ func setupLogger(env string) *slog.Logger {
 
 	return log
 }
+
+// setupStorage создает хранилище в зависимости от значения переменной STORAGE.
+func setupStorage(kind string, log *slog.Logger) (storage.Repository, error) {
+	switch kind {
+	case storageMemory:
+		log.Info("using in-memory storage")
+
+		return memory.NewStorage(log), nil
+	case storageSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = defaultSQLitePath
+		}
+
+		if err := migrator.MigrateSQLite(path, log); err != nil {
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		return sqlite.NewStorage(path, log)
+	case storagePostgres, "":
+		host := os.Getenv("DB_HOST")
+		port := os.Getenv("DB_PORT")
+		user := os.Getenv("DB_USER")
+		password := os.Getenv("DB_PASSWORD")
+		dbname := os.Getenv("DB_NAME")
+
+		psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			host, port, user, password, dbname)
+
+		if err := migrator.Migrate(user, password, host, port, dbname, log); err != nil {
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		return postgresql.NewStorage(psqlInfo, log)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", kind)
+	}
+}
+
+// setupTimeouts читает ограничения времени операций из DB_TIMEOUT и API_TIMEOUT.
+func setupTimeouts() (service.Timeouts, error) {
+	dbTimeout, err := durationEnv("DB_TIMEOUT", defaultDBTimeout)
+	if err != nil {
+		return service.Timeouts{}, err
+	}
+
+	apiTimeout, err := durationEnv("API_TIMEOUT", defaultAPITimeout)
+	if err != nil {
+		return service.Timeouts{}, err
+	}
+
+	return service.Timeouts{DB: dbTimeout, API: apiTimeout}, nil
+}
+
+// setupSongInfo читает настройки клиента внешнего API: API_ATTEMPT_TIMEOUT, API_RETRIES,
+// API_BREAKER_THRESHOLD и API_BREAKER_COOLDOWN. Незаданные переменные берутся из songinfo.DefaultConfig.
+func setupSongInfo() (songinfo.Config, error) {
+	cfg := songinfo.DefaultConfig()
+
+	var err error
+	if cfg.AttemptTimeout, err = durationEnv("API_ATTEMPT_TIMEOUT", cfg.AttemptTimeout); err != nil {
+		return songinfo.Config{}, err
+	}
+	if cfg.MaxRetries, err = intEnv("API_RETRIES", cfg.MaxRetries); err != nil {
+		return songinfo.Config{}, err
+	}
+	if cfg.BreakerThreshold, err = intEnv("API_BREAKER_THRESHOLD", cfg.BreakerThreshold); err != nil {
+		return songinfo.Config{}, err
+	}
+	if cfg.BreakerCooldown, err = durationEnv("API_BREAKER_COOLDOWN", cfg.BreakerCooldown); err != nil {
+		return songinfo.Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// startFakeInfoAPI запускает подделку внешнего API на свободном локальном порту и возвращает ее адрес.
+// Фикстуры и сбои задаются FAKE_INFO_FIXTURES, FAKE_INFO_LATENCY, FAKE_INFO_ERROR_RATE и FAKE_INFO_NOT_FOUND_RATE.
+func startFakeInfoAPI(log *slog.Logger) (string, error) {
+	var cfg fakeinfo.Config
+
+	var err error
+	if cfg.Latency, err = durationEnv("FAKE_INFO_LATENCY", 0); err != nil {
+		return "", err
+	}
+	if cfg.ErrorRate, err = floatEnv("FAKE_INFO_ERROR_RATE", 0); err != nil {
+		return "", err
+	}
+	if cfg.NotFoundRate, err = floatEnv("FAKE_INFO_NOT_FOUND_RATE", 0); err != nil {
+		return "", err
+	}
+
+	fixtures, err := fakeinfo.LoadFixtures(os.Getenv("FAKE_INFO_FIXTURES"))
+	if err != nil {
+		return "", err
+	}
+
+	fakeLog := log.With(slog.String("component", "fake-info-api"))
+	server, err := fakeinfo.NewServer(fixtures, cfg, fakeLog)
+	if err != nil {
+		return "", err
+	}
+
+	baseURL, _, err := server.Start("127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	log.Warn("using in-process fake info API",
+		slog.String("url", baseURL),
+		slog.Int("fixtures", len(fixtures)))
+
+	return baseURL, nil
+}
+
+// setupMetadataProviders создает источники сведений о песнях. Если задан METADATA_CONFIG, источники
+// и их приоритет берутся из этого YAML-файла, иначе используется один внешний API по адресу apiURL.
+func setupMetadataProviders(cfg songinfo.Config, apiURL string, log *slog.Logger) ([]songinfo.MetadataProvider, error) {
+	if path := os.Getenv("METADATA_CONFIG"); path != "" {
+		return songinfo.LoadProviders(path, cfg, log)
+	}
+
+	return []songinfo.MetadataProvider{songinfo.NewClient(apiURL, cfg, log)}, nil
+}
+
+// setupInfoCache создает кэш ответов источников сведений. INFO_CACHE выбирает хранилище кэша: memory
+// (по умолчанию) — LRU в памяти процесса на INFO_CACHE_SIZE записей, storage — общее для экземпляров
+// хранилище песен, off — без кэша (тогда возвращается nil). Сроки хранения задают INFO_CACHE_TTL
+// и INFO_CACHE_NOT_FOUND_TTL.
+func setupInfoCache(provider songinfo.MetadataProvider, repo storage.Repository, log *slog.Logger) (*songinfo.CachedProvider, error) {
+	var store songinfo.CacheStore
+
+	switch kind := os.Getenv("INFO_CACHE"); kind {
+	case infoCacheOff:
+		log.Info("song info cache is disabled")
+		return nil, nil
+	case infoCacheMemory, "":
+		size, err := intEnv("INFO_CACHE_SIZE", defaultInfoCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		if size < 1 {
+			return nil, fmt.Errorf("INFO_CACHE_SIZE must be positive")
+		}
+		store = songinfo.NewLRUCache(size)
+	case infoCacheStorage:
+		store = songinfo.NewRepositoryCache(repo)
+	default:
+		return nil, fmt.Errorf("unknown INFO_CACHE %q", kind)
+	}
+
+	var cfg songinfo.CacheConfig
+
+	var err error
+	if cfg.TTL, err = durationEnv("INFO_CACHE_TTL", defaultInfoCacheTTL); err != nil {
+		return nil, err
+	}
+	if cfg.NotFoundTTL, err = durationEnv("INFO_CACHE_NOT_FOUND_TTL", defaultInfoCacheNotFoundTTL); err != nil {
+		return nil, err
+	}
+
+	return songinfo.NewCachedProvider(provider, store, cfg, log), nil
+}
+
+// setupEnrichment читает настройки фонового обогащения: ENRICH_BATCH_SIZE, ENRICH_MAX_ATTEMPTS и ENRICH_RETRY_BACKOFF.
+func setupEnrichment() (service.EnrichmentConfig, error) {
+	batchSize, err := intEnv("ENRICH_BATCH_SIZE", defaultEnrichBatchSize)
+	if err != nil {
+		return service.EnrichmentConfig{}, err
+	}
+	if batchSize < 1 {
+		return service.EnrichmentConfig{}, fmt.Errorf("ENRICH_BATCH_SIZE must be positive")
+	}
+
+	maxAttempts, err := intEnv("ENRICH_MAX_ATTEMPTS", defaultEnrichMaxAttempts)
+	if err != nil {
+		return service.EnrichmentConfig{}, err
+	}
+	if maxAttempts < 1 {
+		return service.EnrichmentConfig{}, fmt.Errorf("ENRICH_MAX_ATTEMPTS must be positive")
+	}
+
+	retryBackoff, err := durationEnv("ENRICH_RETRY_BACKOFF", defaultEnrichRetryBackoff)
+	if err != nil {
+		return service.EnrichmentConfig{}, err
+	}
+
+	return service.EnrichmentConfig{BatchSize: batchSize, MaxAttempts: maxAttempts, RetryBackoff: retryBackoff}, nil
+}
+
+func setupRefresh() (service.RefreshConfig, error) {
+	batchSize, err := intEnv("REFRESH_BATCH_SIZE", defaultRefreshBatchSize)
+	if err != nil {
+		return service.RefreshConfig{}, err
+	}
+	if batchSize < 1 {
+		return service.RefreshConfig{}, fmt.Errorf("REFRESH_BATCH_SIZE must be positive")
+	}
+
+	maxAge, err := durationEnv("REFRESH_MAX_AGE", defaultRefreshMaxAge)
+	if err != nil {
+		return service.RefreshConfig{}, err
+	}
+	if maxAge <= 0 {
+		return service.RefreshConfig{}, fmt.Errorf("REFRESH_MAX_AGE must be positive")
+	}
+
+	policy := os.Getenv("REFRESH_POLICY")
+	switch policy {
+	case "":
+		policy = models.RefreshPolicyReview
+	case models.RefreshPolicyAuto, models.RefreshPolicyReview:
+	default:
+		return service.RefreshConfig{}, fmt.Errorf("REFRESH_POLICY must be %q or %q, got %q",
+			models.RefreshPolicyAuto, models.RefreshPolicyReview, policy)
+	}
+
+	return service.RefreshConfig{BatchSize: batchSize, MaxAge: maxAge, Policy: policy}, nil
+}
+
+// durationEnv возвращает значение переменной окружения в формате time.Duration или значение по умолчанию.
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return d, nil
+}
+
+// floatEnv возвращает дробное значение переменной окружения или значение по умолчанию.
+func floatEnv(key string, def float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return f, nil
+}
+
+// intEnv возвращает целочисленное значение переменной окружения или значение по умолчанию.
+func intEnv(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	infoCacheService *service.InfoCacheService
+	songService      *service.SongService
+}
+
+func NewAdminHandler(infoCacheService *service.InfoCacheService, songService *service.SongService) *AdminHandler {
+	return &AdminHandler{infoCacheService: infoCacheService, songService: songService}
+}
+
+// InvalidateInfoCache godoc
+// @Summary      Invalidate song info cache
+// @Description  Remove the cached metadata provider response for a song, so the next lookup goes upstream.
+// @Description  Without group and song the whole cache is cleared.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        group query string false "Group name"
+// @Param        song query string false "Song name"
+// @Success      200  {object}  map[string]int
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /admin/info-cache [delete]
+func (h *AdminHandler) InvalidateInfoCache(c *gin.Context) {
+	group := c.Query("group")
+	song := c.Query("song")
+
+	if (group == "") != (song == "") {
+		_ = c.Error(errors.New("group and song must be given together")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	var removed int
+	var err error
+	if group == "" {
+		removed, err = h.infoCacheService.Clear(c.Request.Context())
+	} else {
+		removed, err = h.infoCacheService.Invalidate(c.Request.Context(), group, song)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// ReparseVerses godoc
+// @Summary      Reparse song verses
+// @Description  Split song texts into verses again. By default only songs parsed by an outdated parser
+// @Description  version are reparsed; with all=true every song is.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        all query bool false "Reparse all songs"
+// @Success      200  {object}  map[string]int
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /admin/verses/reparse [post]
+func (h *AdminHandler) ReparseVerses(c *gin.Context) {
+	all, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+	if err != nil {
+		_ = c.Error(errors.New("invalid all")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	reparsed, err := h.songService.ReparseVerses(c.Request.Context(), all)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reparsed": reparsed})
+}
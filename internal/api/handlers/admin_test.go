@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newAdminRouter создает роутер с маршрутами администрирования. Если cache не nil, сервис песен
+// запрашивает сведения через него.
+func newAdminRouter(t *testing.T, cache *songinfo.CachedProvider) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	var info songinfo.MetadataProvider
+	if cache != nil {
+		info = cache
+	}
+	songs := service.NewSongService(store, info, service.Timeouts{}, log)
+	h := NewAdminHandler(service.NewInfoCacheService(cache, service.Timeouts{}, log), songs)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(log))
+	admin := router.Group("/api/admin")
+	admin.DELETE("/info-cache", h.InvalidateInfoCache)
+	admin.POST("/verses/reparse", h.ReparseVerses)
+	return router, store
+}
+
+func TestInvalidateInfoCache(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := songinfo.NewCachedProvider(hysteriaInfo{}, songinfo.NewLRUCache(10),
+		songinfo.CacheConfig{TTL: time.Hour, NotFoundTTL: time.Hour}, log)
+	router, _ := newAdminRouter(t, cache)
+
+	// В кэше ответы для известной источнику песни и для неизвестной
+	for _, song := range []string{"Hysteria", "Starlight"} {
+		_, _ = cache.Lookup(context.Background(), "Muse", song)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantRemoved int
+	}{
+		{name: "song", query: "?group=Muse&song=Hysteria", wantStatus: http.StatusOK, wantRemoved: 1},
+		{name: "song again", query: "?group=Muse&song=Hysteria", wantStatus: http.StatusOK, wantRemoved: 0},
+		{name: "group without song", query: "?group=Muse", wantStatus: http.StatusBadRequest},
+		{name: "everything", wantStatus: http.StatusOK, wantRemoved: 1},
+		{name: "everything again", wantStatus: http.StatusOK, wantRemoved: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodDelete, "/api/admin/info-cache"+tt.query, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Removed int `json:"removed"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Removed != tt.wantRemoved {
+				t.Errorf("removed = %d, want %d", resp.Removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestInvalidateDisabledInfoCache(t *testing.T) {
+	router, _ := newAdminRouter(t, nil)
+
+	for _, query := range []string{"", "?group=Muse&song=Hysteria"} {
+		w := serve(router, http.MethodDelete, "/api/admin/info-cache"+query, "")
+		if w.Code != http.StatusOK || w.Body.String() != `{"removed":0}` {
+			t.Errorf("DELETE%s = %d %s, want 200 with nothing removed", query, w.Code, w.Body)
+		}
+	}
+}
+
+func TestReparseVersesHandler(t *testing.T) {
+	router, store := newAdminRouter(t, nil)
+	for _, name := range []string{"Hysteria", "Starlight"} {
+		if _, err := store.AddSong(context.Background(), "Muse", name, models.SongDetail{Text: "la la"}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		wantStatus   int
+		wantReparsed int
+	}{
+		{name: "outdated only", wantStatus: http.StatusOK, wantReparsed: 0},
+		{name: "all", query: "?all=true", wantStatus: http.StatusOK, wantReparsed: 2},
+		{name: "invalid all", query: "?all=maybe", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodPost, "/api/admin/verses/reparse"+tt.query, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Reparsed int `json:"reparsed"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Reparsed != tt.wantReparsed {
+				t.Errorf("reparsed = %d, want %d", resp.Reparsed, tt.wantReparsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type AlbumHandler struct {
+	albumService *service.AlbumService
+}
+
+func NewAlbumHandler(albumService *service.AlbumService) *AlbumHandler {
+	return &AlbumHandler{albumService: albumService}
+}
+
+type AlbumRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=255"`
+	ArtistID    int    `json:"artist_id" binding:"required,min=1"`
+	ReleaseDate string `json:"release_date" binding:"omitempty,datetime=2006-01-02"`
+	CoverLink   string `json:"cover_link" binding:"omitempty,url"`
+	SongIDs     []int  `json:"song_ids" binding:"omitempty,dive,min=1"`
+}
+
+type AlbumUpdateRequest struct {
+	Title       *string `json:"title,omitempty" binding:"omitempty,min=1,max=255"`
+	ArtistID    *int    `json:"artist_id,omitempty" binding:"omitempty,min=1"`
+	ReleaseDate *string `json:"release_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	CoverLink   *string `json:"cover_link,omitempty" binding:"omitempty,url"`
+}
+
+type AlbumTracksRequest struct {
+	SongIDs []int `json:"song_ids" binding:"required,dive,min=1"`
+}
+
+// GetAlbums godoc
+// @Summary      Get albums list
+// @Description  Get albums ordered by id with pagination. Track lists are not included.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        artist_id query int false "Filter by artist id"
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.Album
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums [get]
+func (h *AlbumHandler) GetAlbums(c *gin.Context) {
+	var artistID int
+	if artist := c.Query("artist_id"); artist != "" {
+		var err error
+		artistID, err = strconv.Atoi(artist)
+		if err != nil || artistID <= 0 {
+			_ = c.Error(errors.New("invalid artist_id")).SetType(gin.ErrorTypeBind)
+			return
+		}
+	}
+
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	albums, err := h.albumService.GetAlbums(c.Request.Context(), artistID, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, albums)
+}
+
+// GetAlbum godoc
+// @Summary      Get album
+// @Description  Get album by id with its track list ordered by position
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Album ID"
+// @Success      200  {object}  models.Album
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums/{id} [get]
+func (h *AlbumHandler) GetAlbum(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	album, err := h.albumService.GetAlbum(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
+// AddAlbum godoc
+// @Summary      Add new album
+// @Description  Add a new album. song_ids sets the track list in order; positions start at 1.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        request body AlbumRequest true "Album details"
+// @Success      201  {object}  map[string]int
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums [post]
+func (h *AlbumHandler) AddAlbum(c *gin.Context) {
+	var request AlbumRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	id, err := h.albumService.AddAlbum(c.Request.Context(), &models.Album{
+		Title:       request.Title,
+		ArtistID:    request.ArtistID,
+		ReleaseDate: request.ReleaseDate,
+		CoverLink:   request.CoverLink,
+	}, request.SongIDs)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// UpdateAlbum godoc
+// @Summary      Update album
+// @Description  Update album details. The track list is changed via PUT /albums/{id}/tracks.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Album ID"
+// @Param        request body AlbumUpdateRequest true "Album update details"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums/{id} [put]
+func (h *AlbumHandler) UpdateAlbum(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var request AlbumUpdateRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err := h.albumService.UpdateAlbum(c.Request.Context(), id, request.Title, request.ArtistID, request.ReleaseDate, request.CoverLink)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "message": "album updated successfully"})
+}
+
+// SetAlbumTracks godoc
+// @Summary      Set album tracks
+// @Description  Replace the album track list. The order of song_ids defines track positions; use it to reorder tracks.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Album ID"
+// @Param        request body AlbumTracksRequest true "Ordered song ids"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums/{id}/tracks [put]
+func (h *AlbumHandler) SetAlbumTracks(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var request AlbumTracksRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err := h.albumService.SetAlbumTracks(c.Request.Context(), id, request.SongIDs)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "message": "album tracks updated successfully"})
+}
+
+// DeleteAlbum godoc
+// @Summary      Delete album
+// @Description  Delete an album. Songs of the album are kept.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Album ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums/{id} [delete]
+func (h *AlbumHandler) DeleteAlbum(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	err := h.albumService.DeleteAlbum(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "album deleted successfully"})
+}
+
+// GetAlbumSongs godoc
+// @Summary      Get album songs
+// @Description  Get songs of an album in track list order, each with its verses
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Album ID"
+// @Success      200  {array}   models.AlbumSong
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /albums/{id}/songs [get]
+func (h *AlbumHandler) GetAlbumSongs(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	songs, err := h.albumService.GetAlbumSongs(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, songs)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newAlbumRouter создает роутер с маршрутами альбомов поверх хранилища в памяти.
+func newAlbumRouter(t *testing.T) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	h := NewAlbumHandler(service.NewAlbumService(store, service.Timeouts{}, log))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(log))
+	albums := router.Group("/api/albums")
+	albums.GET("", h.GetAlbums)
+	albums.GET("/:id", h.GetAlbum)
+	albums.GET("/:id/songs", h.GetAlbumSongs)
+	albums.POST("", h.AddAlbum)
+	albums.PUT("/:id", h.UpdateAlbum)
+	albums.PUT("/:id/tracks", h.SetAlbumTracks)
+	albums.DELETE("/:id", h.DeleteAlbum)
+	return router, store
+}
+
+// seedAlbum добавляет песни Hysteria (1), Time Is Running Out (2) и Song 2 (3), исполнителей
+// Muse (1) и Blur (2) и альбом Absolution (1) с трек-листом 2, 1.
+func seedAlbum(t *testing.T, store *memory.Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, song := range []models.Song{
+		{Group: "Muse", Song: "Hysteria", Text: "It's bugging me\n\nGrating me"},
+		{Group: "Muse", Song: "Time Is Running Out"},
+		{Group: "Blur", Song: "Song 2"},
+	} {
+		if _, err := store.AddSong(ctx, song.Group, song.Song, models.SongDetail{Text: song.Text}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+	if _, err := store.AddAlbum(ctx, &models.Album{Title: "Absolution", ArtistID: 1}, []int{2, 1}); err != nil {
+		t.Fatalf("AddAlbum() error = %v", err)
+	}
+}
+
+func TestAlbumHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "add", method: http.MethodPost, target: "/api/albums", body: `{"title":"Showbiz","artist_id":1,"release_date":"1999-10-04","song_ids":[1]}`, wantStatus: http.StatusCreated},
+		{name: "add existing title", method: http.MethodPost, target: "/api/albums", body: `{"title":"Absolution","artist_id":1}`, wantStatus: http.StatusConflict, wantCode: middleware.CodeAlbumExists},
+		{name: "same title of another artist", method: http.MethodPost, target: "/api/albums", body: `{"title":"Absolution","artist_id":2}`, wantStatus: http.StatusCreated},
+		{name: "add for unknown artist", method: http.MethodPost, target: "/api/albums", body: `{"title":"Showbiz","artist_id":10}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add with unknown song", method: http.MethodPost, target: "/api/albums", body: `{"title":"Showbiz","artist_id":1,"song_ids":[1,10]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add with repeated song", method: http.MethodPost, target: "/api/albums", body: `{"title":"Showbiz","artist_id":1,"song_ids":[1,1]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add with invalid date", method: http.MethodPost, target: "/api/albums", body: `{"title":"Showbiz","artist_id":1,"release_date":"04.10.1999"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add without title", method: http.MethodPost, target: "/api/albums", body: `{"artist_id":1}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "get", method: http.MethodGet, target: "/api/albums/1", wantStatus: http.StatusOK},
+		{name: "get unknown", method: http.MethodGet, target: "/api/albums/10", wantStatus: http.StatusNotFound, wantCode: middleware.CodeAlbumNotFound},
+		{name: "list by artist", method: http.MethodGet, target: "/api/albums?artist_id=1", wantStatus: http.StatusOK},
+		{name: "list by invalid artist", method: http.MethodGet, target: "/api/albums?artist_id=muse", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{name: "update", method: http.MethodPut, target: "/api/albums/1", body: `{"cover_link":"https://example.com/cover.jpg"}`, wantStatus: http.StatusOK},
+		{name: "update with invalid link", method: http.MethodPut, target: "/api/albums/1", body: `{"cover_link":"cover.jpg"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "update unknown", method: http.MethodPut, target: "/api/albums/10", body: `{"title":"Showbiz"}`, wantStatus: http.StatusNotFound, wantCode: middleware.CodeAlbumNotFound},
+		{name: "move to unknown artist", method: http.MethodPut, target: "/api/albums/1", body: `{"artist_id":10}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "set tracks", method: http.MethodPut, target: "/api/albums/1/tracks", body: `{"song_ids":[1,2,3]}`, wantStatus: http.StatusOK},
+		{name: "set tracks without list", method: http.MethodPut, target: "/api/albums/1/tracks", body: `{}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "set tracks of unknown album", method: http.MethodPut, target: "/api/albums/10/tracks", body: `{"song_ids":[1]}`, wantStatus: http.StatusNotFound, wantCode: middleware.CodeAlbumNotFound},
+		{name: "delete", method: http.MethodDelete, target: "/api/albums/1", wantStatus: http.StatusOK},
+		{name: "delete unknown", method: http.MethodDelete, target: "/api/albums/10", wantStatus: http.StatusNotFound, wantCode: middleware.CodeAlbumNotFound},
+		{name: "songs", method: http.MethodGet, target: "/api/albums/1/songs", wantStatus: http.StatusOK},
+		{name: "songs of unknown album", method: http.MethodGet, target: "/api/albums/10/songs", wantStatus: http.StatusNotFound, wantCode: middleware.CodeAlbumNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newAlbumRouter(t)
+			seedAlbum(t, store)
+
+			w := serve(router, tt.method, tt.target, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w.Body.Bytes()); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestAlbumTracks(t *testing.T) {
+	router, store := newAlbumRouter(t)
+	seedAlbum(t, store)
+
+	var album models.Album
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/albums/1", "").Body.Bytes(), &album); err != nil {
+		t.Fatalf("decode album: %v", err)
+	}
+	want := []models.AlbumTrack{{Position: 1, SongID: 2, Song: "Time Is Running Out"}, {Position: 2, SongID: 1, Song: "Hysteria"}}
+	if album.Artist != "Muse" || !reflect.DeepEqual(album.Tracks, want) {
+		t.Errorf("album = %+v, want Muse with tracks %+v", album, want)
+	}
+
+	if w := serve(router, http.MethodPut, "/api/albums/1/tracks", `{"song_ids":[1,3]}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT tracks status = %d, body %s", w.Code, w.Body)
+	}
+
+	var songs []models.AlbumSong
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/albums/1/songs", "").Body.Bytes(), &songs); err != nil {
+		t.Fatalf("decode album songs: %v", err)
+	}
+	if len(songs) != 2 || songs[0].Song.ID != 1 || songs[1].Song.ID != 3 || songs[1].Position != 2 {
+		t.Fatalf("album songs = %+v, want songs 1 and 3", songs)
+	}
+	if !reflect.DeepEqual(songs[0].Verses, []string{"It's bugging me", "Grating me"}) {
+		t.Errorf("verses = %q, want two verses", songs[0].Verses)
+	}
+	if songs[1].Verses == nil {
+		t.Error("verses of a song without text = null, want []")
+	}
+}
+
+func TestGetSongsByAlbum(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	seedAlbum(t, store)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []int
+	}{
+		{name: "album tracks", query: "album=1", wantStatus: http.StatusOK, want: []int{1, 2}},
+		{name: "album and song", query: "album=1&song=Hysteria", wantStatus: http.StatusOK, want: []int{1}},
+		{name: "unknown album", query: "album=10", wantStatus: http.StatusOK, want: []int{}},
+		{name: "invalid album", query: "album=absolution", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/api/songs?"+tt.query, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var songs []models.Song
+			if err := json.Unmarshal(w.Body.Bytes(), &songs); err != nil {
+				t.Fatalf("decode songs: %v", err)
+			}
+			got := []int{}
+			for _, song := range songs {
+				got = append(got, song.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("songs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type ArtistHandler struct {
+	artistService *service.ArtistService
+}
+
+func NewArtistHandler(artistService *service.ArtistService) *ArtistHandler {
+	return &ArtistHandler{artistService: artistService}
+}
+
+type ArtistRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=255"`
+	Country     string   `json:"country" binding:"max=100"`
+	FormedYear  *int     `json:"formed_year,omitempty" binding:"omitempty,min=1000,max=9999"`
+	Description string   `json:"description"`
+	Links       []string `json:"links" binding:"omitempty,dive,url"`
+}
+
+type ArtistUpdateRequest struct {
+	Name        *string   `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Country     *string   `json:"country,omitempty" binding:"omitempty,max=100"`
+	FormedYear  *int      `json:"formed_year,omitempty" binding:"omitempty,min=1000,max=9999"`
+	Description *string   `json:"description,omitempty"`
+	Links       *[]string `json:"links,omitempty" binding:"omitempty,dive,url"`
+}
+
+// GetArtists godoc
+// @Summary      Get artists list
+// @Description  Get artists ordered by id with pagination
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.Artist
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists [get]
+func (h *ArtistHandler) GetArtists(c *gin.Context) {
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	artists, err := h.artistService.GetArtists(c.Request.Context(), limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, artists)
+}
+
+// GetArtist godoc
+// @Summary      Get artist
+// @Description  Get artist by id
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Artist ID"
+// @Success      200  {object}  models.Artist
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists/{id} [get]
+func (h *ArtistHandler) GetArtist(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	artist, err := h.artistService.GetArtist(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, artist)
+}
+
+// AddArtist godoc
+// @Summary      Add new artist
+// @Description  Add a new artist
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        request body ArtistRequest true "Artist details"
+// @Success      201  {object}  map[string]int
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists [post]
+func (h *ArtistHandler) AddArtist(c *gin.Context) {
+	var request ArtistRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	id, err := h.artistService.AddArtist(c.Request.Context(), &models.Artist{
+		Name:        request.Name,
+		Country:     request.Country,
+		FormedYear:  request.FormedYear,
+		Description: request.Description,
+		Links:       request.Links,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// UpdateArtist godoc
+// @Summary      Update artist
+// @Description  Update artist details. Renaming an artist renames the group of all its songs.
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Artist ID"
+// @Param        request body ArtistUpdateRequest true "Artist update details"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists/{id} [put]
+func (h *ArtistHandler) UpdateArtist(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var request ArtistUpdateRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	err := h.artistService.UpdateArtist(c.Request.Context(), id, request.Name, request.Country, request.FormedYear, request.Description, request.Links)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "message": "artist updated successfully"})
+}
+
+// DeleteArtist godoc
+// @Summary      Delete artist
+// @Description  Delete an artist that has no songs or albums
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Artist ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists/{id} [delete]
+func (h *ArtistHandler) DeleteArtist(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	err := h.artistService.DeleteArtist(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "artist deleted successfully"})
+}
+
+// GetArtistSongs godoc
+// @Summary      Get artist songs
+// @Description  Get songs of an artist ordered by id with pagination
+// @Tags         artists
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Artist ID"
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /artists/{id}/songs [get]
+func (h *ArtistHandler) GetArtistSongs(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	songs, err := h.artistService.GetArtistSongs(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, songs)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+// newArtistRouter создает роутер с маршрутами исполнителей поверх хранилища в памяти.
+func newArtistRouter(t *testing.T) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	h := NewArtistHandler(service.NewArtistService(store, service.Timeouts{}, log))
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(log))
+	artists := router.Group("/api/artists")
+	artists.GET("", h.GetArtists)
+	artists.GET("/:id", h.GetArtist)
+	artists.GET("/:id/songs", h.GetArtistSongs)
+	artists.POST("", h.AddArtist)
+	artists.PUT("/:id", h.UpdateArtist)
+	artists.DELETE("/:id", h.DeleteArtist)
+	return router, store
+}
+
+// errorCode возвращает код ошибки из тела ответа.
+func errorCode(t *testing.T, body []byte) string {
+	t.Helper()
+
+	var resp middleware.ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode error response %s: %v", body, err)
+	}
+	return resp.Code
+}
+
+func TestArtistHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "add", method: http.MethodPost, target: "/api/artists", body: `{"name":"Radiohead","formed_year":1985,"links":["https://radiohead.com"]}`, wantStatus: http.StatusCreated},
+		{name: "add existing name", method: http.MethodPost, target: "/api/artists", body: `{"name":"Blur"}`, wantStatus: http.StatusConflict, wantCode: middleware.CodeArtistExists},
+		{name: "add without name", method: http.MethodPost, target: "/api/artists", body: `{"country":"UK"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add with invalid link", method: http.MethodPost, target: "/api/artists", body: `{"name":"Oasis","links":["oasis"]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add with invalid year", method: http.MethodPost, target: "/api/artists", body: `{"name":"Oasis","formed_year":91}`, wantStatus: http.StatusUnprocessableEntity, wantCode: middleware.CodeValidationFailed},
+		{name: "add malformed json", method: http.MethodPost, target: "/api/artists", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{name: "get", method: http.MethodGet, target: "/api/artists/1", wantStatus: http.StatusOK},
+		{name: "get unknown", method: http.MethodGet, target: "/api/artists/10", wantStatus: http.StatusNotFound, wantCode: middleware.CodeArtistNotFound},
+		{name: "get invalid id", method: http.MethodGet, target: "/api/artists/first", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{name: "list", method: http.MethodGet, target: "/api/artists?limit=1", wantStatus: http.StatusOK},
+		{name: "list invalid limit", method: http.MethodGet, target: "/api/artists?limit=0", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{name: "update", method: http.MethodPut, target: "/api/artists/1", body: `{"country":"UK"}`, wantStatus: http.StatusOK},
+		{name: "rename to existing name", method: http.MethodPut, target: "/api/artists/1", body: `{"name":"Muse"}`, wantStatus: http.StatusConflict, wantCode: middleware.CodeArtistExists},
+		{name: "update unknown", method: http.MethodPut, target: "/api/artists/10", body: `{"country":"UK"}`, wantStatus: http.StatusNotFound, wantCode: middleware.CodeArtistNotFound},
+		{name: "delete", method: http.MethodDelete, target: "/api/artists/1", wantStatus: http.StatusOK},
+		{name: "delete with songs", method: http.MethodDelete, target: "/api/artists/2", wantStatus: http.StatusConflict, wantCode: middleware.CodeArtistHasSongs},
+		{name: "delete unknown", method: http.MethodDelete, target: "/api/artists/10", wantStatus: http.StatusNotFound, wantCode: middleware.CodeArtistNotFound},
+		{name: "songs", method: http.MethodGet, target: "/api/artists/2/songs", wantStatus: http.StatusOK},
+		{name: "songs of unknown artist", method: http.MethodGet, target: "/api/artists/10/songs", wantStatus: http.StatusNotFound, wantCode: middleware.CodeArtistNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newArtistRouter(t)
+			if _, err := store.AddArtist(context.Background(), &models.Artist{Name: "Blur"}); err != nil {
+				t.Fatalf("AddArtist() error = %v", err)
+			}
+			// Песня создает исполнителя Muse с id 2
+			if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{}); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+
+			w := serve(router, tt.method, tt.target, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w.Body.Bytes()); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestArtistRoundTrip(t *testing.T) {
+	router, store := newArtistRouter(t)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	if _, err := store.AddSong(context.Background(), "Muse", "Starlight", models.SongDetail{}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	w := serve(router, http.MethodGet, "/api/artists/1", "")
+	var artist models.Artist
+	if err := json.Unmarshal(w.Body.Bytes(), &artist); err != nil {
+		t.Fatalf("decode artist: %v", err)
+	}
+	if artist.Name != "Muse" || artist.Links == nil {
+		t.Errorf("artist = %+v, want Muse with empty links", artist)
+	}
+
+	w = serve(router, http.MethodPut, "/api/artists/1", `{"name":"MUSE","formed_year":1994,"links":["https://muse.mu"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body)
+	}
+
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/artists/1", "").Body.Bytes(), &artist); err != nil {
+		t.Fatalf("decode artist: %v", err)
+	}
+	if artist.Name != "MUSE" || artist.FormedYear == nil || *artist.FormedYear != 1994 || len(artist.Links) != 1 {
+		t.Errorf("artist after update = %+v", artist)
+	}
+
+	// Переименование исполнителя переименовывает группу у его песен
+	var songs []models.Song
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/artists/1/songs?limit=1&offset=1", "").Body.Bytes(), &songs); err != nil {
+		t.Fatalf("decode songs: %v", err)
+	}
+	if len(songs) != 1 || songs[0].Song != "Starlight" || songs[0].Group != "MUSE" {
+		t.Errorf("artist songs page = %+v, want Starlight by MUSE", songs)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// songETag возвращает сильный ETag песни — ее версию в кавычках.
+func songETag(song *models.Song) string {
+	return `"` + strconv.Itoa(song.Version) + `"`
+}
+
+// notModified отвечает 304, если ETag песни совпадает с одним из тегов If-None-Match
+// (сравнение слабое, * совпадает с любой песней), и возвращает true.
+func notModified(c *gin.Context, song *models.Song) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	etag := songETag(song)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch возвращает версию песни из заголовка If-Match; 0 означает, что версия не проверяется
+// (заголовка нет или передан *). Без заголовка, если он обязателен, регистрируется
+// service.ErrVersionRequired. Слабые и не наши теги не совпадают ни с одной версией, поэтому
+// регистрируется storage.ErrSongVersionMismatch. При ошибке возвращается false.
+func (h *SongHandler) ifMatch(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch header {
+	case "":
+		if h.requireIfMatch {
+			_ = c.Error(service.ErrVersionRequired)
+			return 0, false
+		}
+		return 0, true
+	case "*":
+		return 0, true
+	}
+
+	tags := strings.Split(header, ",")
+	if len(tags) > 1 {
+		_ = c.Error(errors.New("If-Match must contain a single entity tag")).SetType(gin.ErrorTypeBind)
+		return 0, false
+	}
+
+	tag := strings.TrimSpace(tags[0])
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		_ = c.Error(storage.ErrSongVersionMismatch)
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		_ = c.Error(storage.ErrSongVersionMismatch)
+		return 0, false
+	}
+
+	return version, true
+}
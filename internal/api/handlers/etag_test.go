@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"net/http"
+	"testing"
+)
+
+func TestGetSongIfNoneMatch(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: "It's bugging me"}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "no header", wantStatus: http.StatusOK},
+		{name: "matching tag", ifNoneMatch: `"1"`, wantStatus: http.StatusNotModified},
+		{name: "weak comparison", ifNoneMatch: `W/"1"`, wantStatus: http.StatusNotModified},
+		{name: "one of several tags", ifNoneMatch: `"3", "1"`, wantStatus: http.StatusNotModified},
+		{name: "any", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale tag", ifNoneMatch: `"0"`, wantStatus: http.StatusOK},
+		{name: "unquoted version", ifNoneMatch: "1", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/api/songs/1", "", "If-None-Match", tt.ifNoneMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if etag := w.Header().Get("ETag"); etag != `"1"` {
+				t.Errorf("ETag = %q, want %q", etag, `"1"`)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 body = %q, want empty", w.Body)
+			}
+		})
+	}
+}
+
+func TestSongIfMatch(t *testing.T) {
+	const (
+		replace = `{"group":"Muse","song":"Hysteria","text":"replaced"}`
+		patch   = `{"text":"patched"}`
+	)
+
+	tests := []struct {
+		name           string
+		requireIfMatch bool
+		method         string
+		target         string
+		body           string
+		ifMatch        string
+		wantStatus     int
+		wantCode       string
+	}{
+		{name: "put with current tag", method: http.MethodPut, target: "/api/songs/1", body: replace, ifMatch: `"1"`, wantStatus: http.StatusOK},
+		{name: "patch with current tag", method: http.MethodPatch, target: "/api/songs/1", body: patch, ifMatch: `"1"`, wantStatus: http.StatusOK},
+		{name: "delete with current tag", method: http.MethodDelete, target: "/api/songs/1", ifMatch: `"1"`, wantStatus: http.StatusOK},
+		{
+			name: "put with stale tag", method: http.MethodPut, target: "/api/songs/1", body: replace, ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: middleware.CodeVersionMismatch,
+		},
+		{
+			name: "patch with stale tag", method: http.MethodPatch, target: "/api/songs/1", body: patch, ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: middleware.CodeVersionMismatch,
+		},
+		{
+			name: "delete with stale tag", method: http.MethodDelete, target: "/api/songs/1", ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: middleware.CodeVersionMismatch,
+		},
+		{
+			name: "name-based delete with stale tag", method: http.MethodDelete, target: "/api/songs?group=Muse&song=Hysteria", ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: middleware.CodeVersionMismatch,
+		},
+		{
+			name: "weak tag never matches", method: http.MethodPatch, target: "/api/songs/1", body: patch, ifMatch: `W/"1"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: middleware.CodeVersionMismatch,
+		},
+		{
+			name: "several tags", method: http.MethodPatch, target: "/api/songs/1", body: patch, ifMatch: `"1", "2"`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "optional tag is missing", method: http.MethodPatch, target: "/api/songs/1", body: patch, wantStatus: http.StatusOK},
+		{
+			name: "required tag is missing on put", requireIfMatch: true, method: http.MethodPut, target: "/api/songs/1", body: replace,
+			wantStatus: http.StatusPreconditionRequired, wantCode: middleware.CodeVersionRequired,
+		},
+		{
+			name: "required tag is missing on patch", requireIfMatch: true, method: http.MethodPatch, target: "/api/songs/1", body: patch,
+			wantStatus: http.StatusPreconditionRequired, wantCode: middleware.CodeVersionRequired,
+		},
+		{
+			name: "required tag is missing on delete", requireIfMatch: true, method: http.MethodDelete, target: "/api/songs/1",
+			wantStatus: http.StatusPreconditionRequired, wantCode: middleware.CodeVersionRequired,
+		},
+		{
+			name: "required tag is missing on name-based delete", requireIfMatch: true, method: http.MethodDelete,
+			target:     "/api/songs?group=Muse&song=Hysteria",
+			wantStatus: http.StatusPreconditionRequired, wantCode: middleware.CodeVersionRequired,
+		},
+		{name: "any tag when required", requireIfMatch: true, method: http.MethodPatch, target: "/api/songs/1", body: patch, ifMatch: "*", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newSongRouter(t, tt.requireIfMatch)
+			if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: "original"}); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+
+			w := serve(router, tt.method, tt.target, tt.body, "If-Match", tt.ifMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantStatus == http.StatusOK && tt.method != http.MethodDelete {
+				if etag := w.Header().Get("ETag"); etag != `"2"` {
+					t.Errorf("ETag = %q, want %q", etag, `"2"`)
+				}
+			}
+			if tt.wantStatus < http.StatusBadRequest {
+				return
+			}
+
+			var resp middleware.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode error response: %v", err)
+			}
+			if tt.wantCode != "" && resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+
+			// Отклоненный запрос не меняет песню
+			song, err := store.GetSong(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("GetSong() error = %v", err)
+			}
+			if song.Text != "original" || song.Version != 1 {
+				t.Errorf("song = %q version %d, want the original at version 1", song.Text, song.Version)
+			}
+		})
+	}
+}
+
+// Два клиента прочитали одну версию песни: изменение второго клиента отклоняется,
+// и он не затирает правку первого.
+func TestSongLostUpdate(t *testing.T) {
+	second := []struct {
+		name   string
+		method string
+		body   string
+	}{
+		{name: "put", method: http.MethodPut, body: `{"group":"Muse","song":"Hysteria","text":"second"}`},
+		{name: "patch", method: http.MethodPatch, body: `{"text":"second"}`},
+		{name: "delete", method: http.MethodDelete},
+	}
+
+	for _, tt := range second {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newSongRouter(t, true)
+			if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: "original"}); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+
+			etag := serve(router, http.MethodGet, "/api/songs/1", "").Header().Get("ETag")
+
+			first := serve(router, http.MethodPatch, "/api/songs/1", `{"text":"first"}`, "If-Match", etag)
+			if first.Code != http.StatusOK {
+				t.Fatalf("first PATCH status = %d, body %s", first.Code, first.Body)
+			}
+
+			w := serve(router, tt.method, "/api/songs/1", tt.body, "If-Match", etag)
+			if w.Code != http.StatusPreconditionFailed {
+				t.Fatalf("second %s status = %d, want %d, body %s", tt.method, w.Code, http.StatusPreconditionFailed, w.Body)
+			}
+
+			got := serve(router, http.MethodGet, "/api/songs/1", "")
+			var song models.Song
+			if err := json.Unmarshal(got.Body.Bytes(), &song); err != nil {
+				t.Fatalf("decode song: %v", err)
+			}
+			if song.Text != "first" || got.Header().Get("ETag") != first.Header().Get("ETag") {
+				t.Errorf("song = %q, ETag %s, want the first change with ETag %s", song.Text, got.Header().Get("ETag"), first.Header().Get("ETag"))
+			}
+
+			// Перечитав песню, второй клиент может применить свое изменение
+			w = serve(router, tt.method, "/api/songs/1", tt.body, "If-Match", got.Header().Get("ETag"))
+			if w.Code >= http.StatusBadRequest {
+				t.Errorf("retried %s status = %d, body %s", tt.method, w.Code, w.Body)
+			}
+		})
+	}
+}
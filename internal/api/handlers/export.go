@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// exportFlushEvery — через сколько песен буфер выгрузки принудительно отправляется клиенту.
+const exportFlushEvery = 100
+
+// exportColumns — колонки CSV-выгрузки; group, song, release_date, text и link совпадают с форматом импорта.
+var exportColumns = []string{"id", "group", "song", "release_date", "text", "link", "artist_id", "enrichment_status"}
+
+// songEncoder пишет песни в одном из форматов выгрузки.
+type songEncoder interface {
+	begin() error
+	encode(song *models.Song) error
+	end() error
+	// flush отправляет накопленные данные в ответ.
+	flush() error
+}
+
+// ExportSongs godoc
+// @Summary      Export songs
+// @Description  Stream the whole library, or the songs matching the same filters as GET /songs, ordered by id.
+// @Description  Formats: ndjson (one song per line), csv (header row, columns id, group, song, release_date, text, link, artist_id, enrichment_status)
+// @Description  and json (a single array). The response is streamed; if the export fails midway the connection is closed
+// @Description  without completing the response, so a truncated download is reported as a transfer error.
+// @Tags         songs
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format query string false "Export format: ndjson, csv, json" default(ndjson)
+// @Param        group query string false "Filter by group name"
+// @Param        song query string false "Filter by song name"
+// @Param        release_date query string false "Filter by release date (YYYY-MM-DD)"
+// @Param        album query int false "Filter by album id"
+// @Param        enrichment_status query string false "Filter by enrichment status: enriched, pending_enrichment, enrichment_failed"
+// @Param        group_contains query string false "Group name contains, case-insensitive"
+// @Param        group_prefix query string false "Group name starts with, case-insensitive"
+// @Param        song_contains query string false "Song name contains, case-insensitive"
+// @Param        song_prefix query string false "Song name starts with, case-insensitive"
+// @Param        text_contains query string false "Lyrics contain, case-insensitive"
+// @Param        release_date_from query string false "Released on or after (YYYY-MM-DD)"
+// @Param        release_date_to query string false "Released on or before (YYYY-MM-DD)"
+// @Param        groups query []string false "Any of the groups; repeat the parameter for each group" collectionFormat(multi)
+// @Param        has_link query bool false "Only songs with (true) or without (false) a link"
+// @Param        has_text query bool false "Only songs with (true) or without (false) lyrics"
+// @Success      200  {array}   models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/export [get]
+func (h *SongHandler) ExportSongs(c *gin.Context) {
+	filters, ok := songFilters(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "ndjson")
+
+	var enc songEncoder
+	var contentType string
+	switch format {
+	case "ndjson":
+		enc = &ndjsonEncoder{w: bufio.NewWriter(c.Writer)}
+		contentType = "application/x-ndjson"
+	case "csv":
+		enc = &csvEncoder{w: csv.NewWriter(c.Writer)}
+		contentType = "text/csv; charset=utf-8"
+	case "json":
+		enc = &jsonArrayEncoder{w: bufio.NewWriter(c.Writer)}
+		contentType = "application/json; charset=utf-8"
+	default:
+		_ = c.Error(errors.New("invalid format")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Заголовки и начало документа отправляются вместе с первой песней, чтобы ошибку,
+	// случившуюся до нее, можно было вернуть обычным ответом в JSON
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="songs.`+format+`"`)
+		c.Status(http.StatusOK)
+		return enc.begin()
+	}
+	count := 0
+
+	err := h.songService.ExportSongs(c.Request.Context(), filters, func(song *models.Song) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := enc.encode(song); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			_ = c.Error(err)
+			return
+		}
+		abortStream(c)
+		return
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			abortStream(c)
+			return
+		}
+	}
+
+	if err := enc.end(); err != nil {
+		abortStream(c)
+		return
+	}
+	if err := enc.flush(); err != nil {
+		abortStream(c)
+	}
+}
+
+// abortStream обрывает соединение посреди ответа. Незавершенный ответ клиент распознает
+// как ошибку передачи и не примет обрезанную выгрузку за полную. Паника http.ErrAbortHandler
+// проходит мимо middleware.Recovery, и сервер закрывает соединение HTTP/1.x или сбрасывает поток HTTP/2.
+func abortStream(c *gin.Context) {
+	c.Abort()
+	panic(http.ErrAbortHandler)
+}
+
+type ndjsonEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+func (e *ndjsonEncoder) encode(song *models.Song) error {
+	return json.NewEncoder(e.w).Encode(song)
+}
+
+func (e *ndjsonEncoder) end() error { return nil }
+
+func (e *ndjsonEncoder) flush() error { return e.w.Flush() }
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write(exportColumns)
+}
+
+func (e *csvEncoder) encode(song *models.Song) error {
+	return e.w.Write([]string{
+		strconv.Itoa(song.ID),
+		song.Group,
+		song.Song,
+		song.ReleaseDate,
+		song.Text,
+		song.Link,
+		strconv.Itoa(song.ArtistID),
+		song.EnrichmentStatus,
+	})
+}
+
+func (e *csvEncoder) end() error { return nil }
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonArrayEncoder struct {
+	w     *bufio.Writer
+	first bool
+}
+
+func (e *jsonArrayEncoder) begin() error {
+	e.first = true
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonArrayEncoder) encode(song *models.Song) error {
+	if !e.first {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.first = false
+
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonArrayEncoder) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+func (e *jsonArrayEncoder) flush() error { return e.w.Flush() }
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingExport — хранилище, выгрузка из которого завершается ошибкой до первой песни.
+type failingExport struct {
+	*memory.Storage
+}
+
+func (failingExport) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	return errors.New("connection refused")
+}
+
+// brokenExport — хранилище, выгрузка из которого обрывается ошибкой после songs песен.
+type brokenExport struct {
+	*memory.Storage
+	songs int
+}
+
+func (s brokenExport) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	for i := 1; i <= s.songs; i++ {
+		if err := fn(&models.Song{ID: i, Group: "Muse", Song: fmt.Sprintf("Song %d", i)}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset by peer")
+}
+
+func TestExportSongs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name            string
+		format          string
+		seed            bool
+		fail            bool
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "ndjson", format: "ndjson", seed: true, wantStatus: http.StatusOK, wantContentType: "application/x-ndjson", wantBody: `"group":"Muse"`},
+		{name: "csv", format: "csv", seed: true, wantStatus: http.StatusOK, wantContentType: "text/csv; charset=utf-8", wantBody: "id,group,song"},
+		{name: "empty json", format: "json", wantStatus: http.StatusOK, wantContentType: "application/json; charset=utf-8", wantBody: "[]"},
+		{name: "empty csv has header", format: "csv", wantStatus: http.StatusOK, wantContentType: "text/csv; charset=utf-8", wantBody: "id,group,song"},
+		{name: "ndjson error before first row", format: "ndjson", fail: true, wantStatus: http.StatusInternalServerError, wantContentType: "application/json; charset=utf-8", wantBody: `"code"`},
+		{name: "csv error before first row", format: "csv", fail: true, wantStatus: http.StatusInternalServerError, wantContentType: "application/json; charset=utf-8", wantBody: `"code"`},
+		{name: "invalid format", format: "xml", wantStatus: http.StatusBadRequest, wantContentType: "application/json; charset=utf-8", wantBody: `"code"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStorage(log)
+			if tt.seed {
+				if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{}); err != nil {
+					t.Fatalf("AddSong() error = %v", err)
+				}
+			}
+
+			songs := service.NewSongService(store, nil, service.Timeouts{}, log)
+			if tt.fail {
+				songs = service.NewSongService(failingExport{store}, nil, service.Timeouts{}, log)
+			}
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler(log))
+			router.GET("/songs/export", NewSongHandler(songs, false).ExportSongs)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs/export?format="+tt.format, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestExportSongsFailsMidway(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Больше exportFlushEvery песен, чтобы часть выгрузки ушла клиенту до ошибки
+	songs := service.NewSongService(brokenExport{Storage: memory.NewStorage(log), songs: exportFlushEvery + 10}, nil, service.Timeouts{}, log)
+
+	router := gin.New()
+	router.Use(middleware.Recovery(log), middleware.ErrorHandler(log))
+	router.GET("/songs/export", NewSongHandler(songs, false).ExportSongs)
+
+	tests := []struct {
+		name  string
+		http2 bool
+	}{
+		{name: "http/1.1 connection is closed"},
+		{name: "http/2 stream is reset", http2: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(router)
+			srv.EnableHTTP2 = tt.http2
+			if tt.http2 {
+				srv.StartTLS()
+			} else {
+				srv.Start()
+			}
+			defer srv.Close()
+
+			for _, format := range []string{"ndjson", "csv", "json"} {
+				resp, err := srv.Client().Get(srv.URL + "/songs/export?format=" + format)
+				if err != nil {
+					t.Fatalf("GET %s error = %v", format, err)
+				}
+				if tt.http2 && resp.ProtoMajor != 2 {
+					t.Fatalf("GET %s used %s, want HTTP/2", format, resp.Proto)
+				}
+
+				body, err := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("%s status = %d, want %d", format, resp.StatusCode, http.StatusOK)
+				}
+				if err == nil {
+					t.Errorf("%s body read without error (%d bytes), want a truncated response", format, len(body))
+				}
+				if !strings.Contains(string(body), "Song 1") {
+					t.Errorf("%s body = %q, want the songs sent before the failure", format, body)
+				}
+				if strings.Contains(string(body), fmt.Sprintf("Song %d", exportFlushEvery+10)) {
+					t.Errorf("%s body contains the songs buffered after the last flush", format)
+				}
+			}
+		})
+	}
+}
@@ -1,18 +1,35 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
 	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
+	"unicode/utf8"
 )
 
+// maxFilterGroups ограничивает число групп в фильтре groups.
+const maxFilterGroups = 100
+
+// maxPatchSize ограничивает размер тела PATCH /songs/{id}.
+const maxPatchSize = 1 << 20
+
 type SongHandler struct {
 	songService *service.SongService
+	// requireIfMatch означает, что изменение и удаление песни без заголовка If-Match отклоняются.
+	requireIfMatch bool
 }
 
-func NewSongHandler(songService *service.SongService) *SongHandler {
-	return &SongHandler{songService: songService}
+func NewSongHandler(songService *service.SongService, requireIfMatch bool) *SongHandler {
+	return &SongHandler{songService: songService, requireIfMatch: requireIfMatch}
 }
 
 type SongAddRequest struct {
@@ -20,30 +37,113 @@ type SongAddRequest struct {
 	Song  string `json:"song"  binding:"required,min=1,max=255"`
 }
 
+// SongReplaceRequest задает все изменяемые поля песни; незаданные дата выпуска, текст и ссылка очищаются.
+// Дата выпуска и ссылка проверяются сервисом, только если меняются, чтобы песню можно было
+// отправить обратно в том виде, в каком ее вернул GET.
+type SongReplaceRequest struct {
+	Group       string `json:"group" binding:"required,min=1,max=255"`
+	Song        string `json:"song"  binding:"required,min=1,max=255"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// SongUpdateRequest задает изменяемые поля песни; дата выпуска и ссылка проверяются так же, как в SongReplaceRequest.
 type SongUpdateRequest struct {
 	Group       *string `json:"group,omitempty" binding:"omitempty,min=1,max=255"`
 	Song        *string `json:"song,omitempty"  binding:"omitempty,min=1,max=255"`
-	ReleaseDate *string `json:"release_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	ReleaseDate *string `json:"release_date,omitempty"`
 	Text        *string `json:"text,omitempty"`
-	Link        *string `json:"link,omitempty" binding:"omitempty,url"`
+	Link        *string `json:"link,omitempty"`
 }
 
 // GetSongs godoc
 // @Summary      Get songs list
-// @Description  Get songs with filtering and pagination
+// @Description  Get songs with filtering and pagination.
+// @Description  Offset mode (default) returns a plain array of songs ordered by sort, then by id.
+// @Description  sort lists fields separated by commas, a leading - means descending, e.g. sort=group,-release_date.
+// @Description  Cursor mode is enabled by passing the cursor parameter (empty for the first page) and returns
+// @Description  a models.SongPage object with songs and next_cursor; pass next_cursor back to get the following page.
+// @Description  Cursor mode sorts by a single ascending field: id, group, song or release_date.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
 // @Param        group query string false "Filter by group name"
 // @Param        song query string false "Filter by song name"
 // @Param        release_date query string false "Filter by release date (YYYY-MM-DD)"
+// @Param        album query int false "Filter by album id"
+// @Param        enrichment_status query string false "Filter by enrichment status: enriched, pending_enrichment, enrichment_failed"
+// @Param        group_contains query string false "Group name contains, case-insensitive"
+// @Param        group_prefix query string false "Group name starts with, case-insensitive"
+// @Param        song_contains query string false "Song name contains, case-insensitive"
+// @Param        song_prefix query string false "Song name starts with, case-insensitive"
+// @Param        text_contains query string false "Lyrics contain, case-insensitive"
+// @Param        release_date_from query string false "Released on or after (YYYY-MM-DD)"
+// @Param        release_date_to query string false "Released on or before (YYYY-MM-DD)"
+// @Param        groups query []string false "Any of the groups; repeat the parameter for each group" collectionFormat(multi)
+// @Param        has_link query bool false "Only songs with (true) or without (false) a link"
+// @Param        has_text query bool false "Only songs with (true) or without (false) lyrics"
 // @Param        limit query int false "Limit number of records" default(10)
-// @Param        offset query int false "Offset for pagination" default(0)
+// @Param        offset query int false "Offset for pagination (offset mode only)" default(0)
+// @Param        cursor query string false "Opaque cursor from next_cursor; enables cursor mode"
+// @Param        sort query string false "Sort fields: id, group, song, release_date, created_at; - for descending"
 // @Success      200  {array}   models.Song
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
 // @Router       /songs [get]
 func (h *SongHandler) GetSongs(c *gin.Context) {
+	filters, ok := songFilters(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		_ = c.Error(errors.New("invalid limit")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	sort := c.Query("sort")
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if _, hasOffset := c.GetQuery("offset"); hasOffset {
+			_ = c.Error(errors.New("cursor and offset cannot be combined")).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		if sort == "" {
+			sort = "id"
+		}
+
+		page, err := h.songService.GetSongsPage(c.Request.Context(), filters, sort, cursor, limit)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		_ = c.Error(errors.New("invalid offset")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	songs, err := h.songService.GetSongs(c.Request.Context(), filters, sort, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, songs)
+}
+
+// songFilters собирает фильтры списка песен из параметров запроса. При некорректном значении
+// записывает ошибку в контекст и возвращает false.
+func songFilters(c *gin.Context) (map[string]interface{}, bool) {
 	filters := map[string]interface{}{}
 
 	if group := c.Query("group"); group != "" {
@@ -55,30 +155,129 @@ func (h *SongHandler) GetSongs(c *gin.Context) {
 	if releaseDate := c.Query("release_date"); releaseDate != "" {
 		filters["release_date"] = releaseDate
 	}
+	if album := c.Query("album"); album != "" {
+		albumID, err := strconv.Atoi(album)
+		if err != nil || albumID <= 0 {
+			_ = c.Error(errors.New("invalid album")).SetType(gin.ErrorTypeBind)
+			return nil, false
+		}
+		filters["album"] = albumID
+	}
+	if status := c.Query("enrichment_status"); status != "" {
+		switch status {
+		case models.EnrichmentEnriched, models.EnrichmentPending, models.EnrichmentFailed:
+			filters["enrichment_status"] = status
+		default:
+			_ = c.Error(errors.New("invalid enrichment_status")).SetType(gin.ErrorTypeBind)
+			return nil, false
+		}
+	}
+
+	for _, key := range []string{"group_contains", "group_prefix", "song_contains", "song_prefix", "text_contains"} {
+		if value := c.Query(key); value != "" {
+			if utf8.RuneCountInString(value) > 255 {
+				_ = c.Error(fmt.Errorf("%s must be at most 255 characters", key)).SetType(gin.ErrorTypeBind)
+				return nil, false
+			}
+			filters[key] = value
+		}
+	}
+
+	for _, key := range []string{"release_date_from", "release_date_to"} {
+		if value := c.Query(key); value != "" {
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				_ = c.Error(fmt.Errorf("%s must be in YYYY-MM-DD format", key)).SetType(gin.ErrorTypeBind)
+				return nil, false
+			}
+			filters[key] = value
+		}
+	}
+	if from, ok := filters["release_date_from"]; ok {
+		if to, ok := filters["release_date_to"]; ok && from.(string) > to.(string) {
+			_ = c.Error(errors.New("release_date_from is after release_date_to")).SetType(gin.ErrorTypeBind)
+			return nil, false
+		}
+	}
+
+	// Названия групп могут содержать запятые, поэтому каждая группа передается отдельным параметром
+	if groups := c.QueryArray("groups"); len(groups) > 0 {
+		if len(groups) > maxFilterGroups {
+			_ = c.Error(fmt.Errorf("groups accepts at most %d values", maxFilterGroups)).SetType(gin.ErrorTypeBind)
+			return nil, false
+		}
+		for _, group := range groups {
+			if group == "" || utf8.RuneCountInString(group) > 255 {
+				_ = c.Error(errors.New("invalid groups")).SetType(gin.ErrorTypeBind)
+				return nil, false
+			}
+		}
+		filters["groups"] = groups
+	}
+
+	for _, key := range []string{"has_link", "has_text"} {
+		if value := c.Query(key); value != "" {
+			present, err := strconv.ParseBool(value)
+			if err != nil {
+				_ = c.Error(fmt.Errorf("invalid %s", key)).SetType(gin.ErrorTypeBind)
+				return nil, false
+			}
+			filters[key] = present
+		}
+	}
+
+	return filters, true
+}
+
+// SearchSongs godoc
+// @Summary      Search songs
+// @Description  Full-text search over song title, group and lyrics. Results are ordered by relevance;
+// @Description  matches in the snippet are wrapped in <b></b>.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        q query string true "Search query"
+// @Param        lang query string false "Text search configuration: simple, english, russian" default(simple)
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.SongSearchResult
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/search [get]
+func (h *SongHandler) SearchSongs(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		_ = c.Error(errors.New("q is required")).SetType(gin.ErrorTypeBind)
+		return
+	}
 
 	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		_ = c.Error(errors.New("invalid limit")).SetType(gin.ErrorTypeBind)
 		return
 	}
 	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		_ = c.Error(errors.New("invalid offset")).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	songs, err := h.songService.GetSongs(filters, limit, offset)
+	results, err := h.songService.SearchSongs(c.Request.Context(), query, c.Query("lang"), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, songs)
+	c.JSON(http.StatusOK, results)
 }
 
 // GetSongVerses godoc
 // @Summary      Get song verses
-// @Description  Get verses of a specific song with pagination
+// @Description  Get verses of a song identified by group and name. Kept for compatibility; prefer /songs/{id}/verses.
+// @Description  By default each verse is a single string with line breaks replaced by spaces, and the total number
+// @Description  of verses is returned in the X-Total-Count header. With format=structured the response is
+// @Description  a models.VersePage: every verse keeps its index, kind, lines and character range in the text,
+// @Description  along with the total and the next offset.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
@@ -86,63 +285,180 @@ func (h *SongHandler) GetSongs(c *gin.Context) {
 // @Param        song query string true "Song name"
 // @Param        limit query int false "Limit number of verses" default(5)
 // @Param        offset query int false "Offset for pagination" default(0)
+// @Param        format query string false "Response format: text, structured" default(text)
 // @Success      200  {array}   string
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Success      200  {object}  models.VersePage
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
 // @Router       /songs/verses [get]
 func (h *SongHandler) GetSongVerses(c *gin.Context) {
-	group := c.Query("group")
-	song := c.Query("song")
+	id, ok := h.songByName(c)
+	if !ok {
+		return
+	}
 
-	if group == "" || song == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "group and song are required"})
+	h.songVerses(c, id)
+}
+
+// GetSongVersesByID godoc
+// @Summary      Get song verses by id
+// @Description  Get verses of a song with pagination. By default each verse is a single string with line breaks
+// @Description  replaced by spaces, and the total number of verses is returned in the X-Total-Count header.
+// @Description  With format=structured the response is a models.VersePage: every verse keeps its index, kind,
+// @Description  lines and character range in the text, along with the total and the next offset.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        limit query int false "Limit number of verses" default(5)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Param        format query string false "Response format: text, structured" default(text)
+// @Success      200  {array}   string
+// @Success      200  {object}  models.VersePage
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id}/verses [get]
+func (h *SongHandler) GetSongVersesByID(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
 		return
 	}
 
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
-	if err != nil || limit <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+	h.songVerses(c, id)
+}
+
+// songVerses отвечает страницей частей текста песни в формате из параметра format.
+func (h *SongHandler) songVerses(c *gin.Context, id int) {
+	limit, offset, ok := pagination(c, "5")
+	if !ok {
 		return
 	}
-	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+
+	switch c.DefaultQuery("format", "text") {
+	case "text":
+	case "structured":
+		page, err := h.songService.GetSongVersesPage(c.Request.Context(), id, limit, offset)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.Header("X-Total-Count", strconv.Itoa(page.Total))
+		c.JSON(http.StatusOK, page)
+		return
+	default:
+		_ = c.Error(errors.New("invalid format")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	verses, total, err := h.songService.GetSongVerses(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	texts := make([]string, 0, len(verses))
+	for _, verse := range verses {
+		texts = append(texts, storage.FlattenVerse(*verse))
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.JSON(http.StatusOK, texts)
+}
+
+// GetSong godoc
+// @Summary      Get song
+// @Description  Get a song by id. The ETag header holds the song version; with a matching If-None-Match the response is 304.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        If-None-Match header string false "ETag of the cached song"
+// @Success      200  {object}  models.Song
+// @Success      304
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id} [get]
+func (h *SongHandler) GetSong(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
 		return
 	}
 
-	verses, err := h.songService.GetSongVerses(group, song, limit, offset)
+	song, err := h.songService.GetSong(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	if notModified(c, song) {
 		return
 	}
 
-	c.JSON(http.StatusOK, verses)
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, song)
 }
 
 // DeleteSong godoc
 // @Summary      Delete song
-// @Description  Delete existing song
+// @Description  Move a song identified by group and name to the trash. Kept for compatibility; prefer DELETE /songs/{id}.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
 // @Param        group query string true "Group name"
 // @Param        song query string true "Song name"
+// @Param        If-Match header string false "ETag of the song version being deleted"
 // @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      412  {object}  middleware.ErrorResponse
+// @Failure      428  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
 // @Router       /songs [delete]
 func (h *SongHandler) DeleteSong(c *gin.Context) {
-	group := c.Query("group")
-	song := c.Query("song")
+	id, ok := h.songByName(c)
+	if !ok {
+		return
+	}
 
-	if group == "" || song == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "group and song are required"})
+	h.deleteSong(c, id)
+}
+
+// DeleteSongByID godoc
+// @Summary      Delete song by id
+// @Description  Move a song to the trash. It can be restored until the trash is purged.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        If-Match header string false "ETag of the song version being deleted"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      412  {object}  middleware.ErrorResponse
+// @Failure      428  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id} [delete]
+func (h *SongHandler) DeleteSongByID(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
 		return
 	}
 
-	err := h.songService.DeleteSong(group, song)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	h.deleteSong(c, id)
+}
+
+func (h *SongHandler) deleteSong(c *gin.Context, id int) {
+	version, ok := h.ifMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.songService.DeleteSong(c.Request.Context(), id, version); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -151,26 +467,52 @@ func (h *SongHandler) DeleteSong(c *gin.Context) {
 
 // AddSong godoc
 // @Summary      Add new song
-// @Description  Add a new song with details from external API
+// @Description  Add a new song with details from external API.
+// @Description  With async=true the song is stored immediately in the pending_enrichment state and the response is 202;
+// @Description  a background worker fetches the details and moves the song to enriched or enrichment_failed.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
 // @Param        request body SongAddRequest true "Song details"
+// @Param        async query bool false "Store the song now and fetch details in the background"
 // @Success      201  {object}  map[string]int
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Failure      502  {object}  middleware.ErrorResponse
+// @Failure      504  {object}  middleware.ErrorResponse
 // @Router       /songs [post]
 func (h *SongHandler) AddSong(c *gin.Context) {
 	var request SongAddRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		bindError(c, err)
 		return
 	}
 
-	songID, err := h.songService.AddSongWithAPI(request.Group, request.Song)
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(errors.New("invalid async")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if async {
+		songID, err := h.songService.AddSongAsync(c.Request.Context(), request.Group, request.Song)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"id": songID, "enrichment_status": models.EnrichmentPending})
+		return
+	}
+
+	songID, err := h.songService.AddSongWithAPI(c.Request.Context(), request.Group, request.Song)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -179,43 +521,229 @@ func (h *SongHandler) AddSong(c *gin.Context) {
 
 // UpdateSong godoc
 // @Summary      Update song
-// @Description  Update existing song details
+// @Description  Update details of a song identified by group and name. Kept for compatibility; prefer PATCH /songs/{id}.
 // @Tags         songs
 // @Accept       json
 // @Produce      json
 // @Param        group query string true "Group name"
 // @Param        song query string true "Song name"
 // @Param        request body SongUpdateRequest true "Song update details"
+// @Param        If-Match header string false "ETag of the song version being changed"
 // @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      412  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      428  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
 // @Router       /songs [put]
 func (h *SongHandler) UpdateSong(c *gin.Context) {
-	group := c.Query("group")
-	song := c.Query("song")
+	id, ok := h.songByName(c)
+	if !ok {
+		return
+	}
 
-	if group == "" || song == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "group and song are required"})
+	version, ok := h.ifMatch(c)
+	if !ok {
 		return
 	}
+
 	var request SongUpdateRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		bindError(c, err)
 		return
 	}
 
-	id, err := h.songService.GetID(group, song)
+	_, err := h.songService.EditSong(c.Request.Context(), id, version, request.Group, request.Song, request.ReleaseDate, request.Text, request.Link)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "message": "song updated successfully"})
+}
+
+// ReplaceSong godoc
+// @Summary      Replace song
+// @Description  Replace all editable fields of a song. Omitted release_date, text and link are cleared.
+// @Description  With If-Match the song is replaced only if its version matches; the ETag header holds the new version.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        request body SongReplaceRequest true "Song details"
+// @Param        If-Match header string false "ETag of the song version being replaced"
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      412  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      428  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id} [put]
+func (h *SongHandler) ReplaceSong(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := h.ifMatch(c)
+	if !ok {
+		return
+	}
+
+	var request SongReplaceRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		bindError(c, err)
 		return
 	}
 
-	err = h.songService.UpdateSong(id, request.Group, request.Song, request.ReleaseDate, request.Text, request.Link)
+	h.updateSong(c, id, version, &request.Group, &request.Song, &request.ReleaseDate, &request.Text, &request.Link)
+}
+
+// PatchSong godoc
+// @Summary      Patch song
+// @Description  Change a song with a JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json
+// @Description  or application/json) or a JSON Patch (RFC 6902, Content-Type application/json-patch+json).
+// @Description  The patch applies to the document {group, song, release_date, text, link}; empty fields are null.
+// @Description  In a merge patch omitted fields are kept and null clears release_date, text or link.
+// @Description  The resulting document is validated; the response is the updated song.
+// @Description  With If-Match the patch is applied only if the song version matches; the ETag header holds the new version.
+// @Description  Renaming the song to the group and name of another song fails with 409; the body is limited to 1 MiB.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        request body SongUpdateRequest true "Merge patch, or an array of JSON Patch operations"
+// @Param        If-Match header string false "ETag of the song version being patched"
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      412  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      428  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id} [patch]
+func (h *SongHandler) PatchSong(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var format string
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json", "":
+		format = service.PatchFormatMerge
+	case "application/json-patch+json":
+		format = service.PatchFormatJSON
+	default:
+		_ = c.Error(fmt.Errorf("unsupported content type %q, use application/merge-patch+json or application/json-patch+json", c.ContentType())).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	version, ok := h.ifMatch(c)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = c.Error(fmt.Errorf("patch exceeds %d bytes", maxPatchSize)).SetType(gin.ErrorTypeBind)
+			return
+		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if !json.Valid(patch) {
+		_ = c.Error(errors.New("invalid JSON")).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id": id, "message": "song updated successfully"})
+	song, err := h.songService.PatchSong(c.Request.Context(), id, version, format, patch)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, song)
+}
+
+// updateSong меняет переданные поля песни версии version (0 — любой) и отвечает песней после изменения.
+func (h *SongHandler) updateSong(c *gin.Context, id, version int, group, song, releaseDate, text, link *string) {
+	updated, err := h.songService.EditSong(c.Request.Context(), id, version, group, song, releaseDate, text, link)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("ETag", songETag(updated))
+	c.JSON(http.StatusOK, updated)
+}
+
+// songByName находит id песни по параметрам запроса group и song. При ошибке регистрирует ее и возвращает false.
+func (h *SongHandler) songByName(c *gin.Context) (int, bool) {
+	group := c.Query("group")
+	song := c.Query("song")
+
+	if group == "" || song == "" {
+		_ = c.Error(errors.New("group and song are required")).SetType(gin.ErrorTypeBind)
+		return 0, false
+	}
+
+	id, err := h.songService.GetID(c.Request.Context(), group, song)
+	if err != nil {
+		_ = c.Error(err)
+		return 0, false
+	}
+	return id, true
+}
+
+// bindError регистрирует ошибку разбора тела запроса. Нарушения правил валидации полей
+// возвращаются клиенту как ошибка валидации, остальные ошибки считаются некорректным запросом.
+func bindError(c *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		_ = c.Error(fmt.Errorf("%w: %s", service.ErrValidation, err))
+		return
+	}
+
+	_ = c.Error(err).SetType(gin.ErrorTypeBind)
+}
+
+// pathID разбирает параметр пути id. При ошибке регистрирует ее и возвращает false.
+func pathID(c *gin.Context) (int, bool) {
+	return pathInt(c, "id")
+}
+
+// pathInt разбирает положительный целочисленный параметр пути. При ошибке регистрирует ее и возвращает false.
+func pathInt(c *gin.Context, name string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil || value <= 0 {
+		_ = c.Error(fmt.Errorf("invalid %s", name)).SetType(gin.ErrorTypeBind)
+		return 0, false
+	}
+	return value, true
+}
+
+// pagination разбирает параметры limit и offset. При ошибке регистрирует ее и возвращает false.
+func pagination(c *gin.Context, defaultLimit string) (int, int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", defaultLimit))
+	if err != nil || limit <= 0 {
+		_ = c.Error(errors.New("invalid limit")).SetType(gin.ErrorTypeBind)
+		return 0, 0, false
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		_ = c.Error(errors.New("invalid offset")).SetType(gin.ErrorTypeBind)
+		return 0, 0, false
+	}
+	return limit, offset, true
 }
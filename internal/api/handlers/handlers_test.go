@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newSongRouter создает роутер с маршрутами песен поверх хранилища в памяти.
+func newSongRouter(t *testing.T, requireIfMatch bool) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	h := NewSongHandler(service.NewSongService(store, nil, service.Timeouts{}, log), requireIfMatch)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(log), middleware.Actor())
+	songs := router.Group("/api/songs")
+	songs.GET("", h.GetSongs)
+	songs.PUT("", h.UpdateSong)
+	songs.DELETE("", h.DeleteSong)
+	songs.GET("/verses", h.GetSongVerses)
+	songs.GET("/:id", h.GetSong)
+	songs.PUT("/:id", h.ReplaceSong)
+	songs.PATCH("/:id", h.PatchSong)
+	songs.DELETE("/:id", h.DeleteSongByID)
+	songs.GET("/:id/verses", h.GetSongVersesByID)
+	songs.GET("/:id/revisions", h.GetSongRevisions)
+	songs.GET("/:id/revisions/:rev", h.GetSongRevision)
+	songs.POST("/:id/revisions/:rev/restore", h.RestoreSongRevision)
+	return router, store
+}
+
+// serve выполняет запрос; headers задаются парами имя, значение.
+func serve(router http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestSongFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "empty", query: "", want: map[string]interface{}{}},
+		{
+			name:  "exact fields",
+			query: "group=Muse&song=Hysteria&release_date=01.12.2003&album=3&enrichment_status=enriched",
+			want: map[string]interface{}{
+				"group": "Muse", "song": "Hysteria", "release_date": "01.12.2003", "album": 3, "enrichment_status": "enriched",
+			},
+		},
+		{
+			name:  "contains and prefix",
+			query: "group_contains=%D0%9A%D0%B8%D0%BD%D0%BE&song_prefix=Hy&text_contains=100%25",
+			want:  map[string]interface{}{"group_contains": "Кино", "song_prefix": "Hy", "text_contains": "100%"},
+		},
+		{
+			name:  "date range",
+			query: "release_date_from=2003-12-01&release_date_to=2006-09-04",
+			want:  map[string]interface{}{"release_date_from": "2003-12-01", "release_date_to": "2006-09-04"},
+		},
+		{
+			name:  "single-day range",
+			query: "release_date_from=2003-12-01&release_date_to=2003-12-01",
+			want:  map[string]interface{}{"release_date_from": "2003-12-01", "release_date_to": "2003-12-01"},
+		},
+		{
+			name:  "groups keep commas",
+			query: "groups=Rock%2C+Paper&groups=Muse",
+			want:  map[string]interface{}{"groups": []string{"Rock, Paper", "Muse"}},
+		},
+		{name: "presence", query: "has_link=true&has_text=0", want: map[string]interface{}{"has_link": true, "has_text": false}},
+		{name: "invalid album", query: "album=first", wantErr: true},
+		{name: "non-positive album", query: "album=0", wantErr: true},
+		{name: "invalid enrichment status", query: "enrichment_status=done", wantErr: true},
+		{name: "contains too long", query: "song_contains=" + strings.Repeat("я", 256), wantErr: true},
+		{name: "date in source format", query: "release_date_from=01.12.2003", wantErr: true},
+		{name: "inverted date range", query: "release_date_from=2006-09-04&release_date_to=2003-12-01", wantErr: true},
+		{name: "empty group in groups", query: "groups=Muse&groups=", wantErr: true},
+		{name: "too many groups", query: "groups=a" + strings.Repeat("&groups=a", maxFilterGroups), wantErr: true},
+		{name: "invalid presence", query: "has_link=maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/songs?"+tt.query, nil)
+
+			got, ok := songFilters(c)
+			if tt.wantErr {
+				if ok {
+					t.Fatalf("songFilters() = %v, want an error", got)
+				}
+				if err := c.Errors.Last(); err == nil || err.Type != gin.ErrorTypeBind {
+					t.Errorf("registered error = %v, want a bind error", err)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("songFilters() error = %v", c.Errors.Last())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("songFilters() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceSongRoundTrip(t *testing.T) {
+	// Дата выпуска из внешнего API хранится в его формате, а не в YYYY-MM-DD
+	detail := models.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/1"}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       func(got string) string
+		wantStatus int
+		wantDate   string
+		// wantVersion — версия песни после запроса: неизменившаяся песня не получает новую версию
+		wantVersion int
+	}{
+		{
+			name:        "put back what get returned",
+			method:      http.MethodPut,
+			target:      "/api/songs/1",
+			body:        func(got string) string { return got },
+			wantStatus:  http.StatusOK,
+			wantDate:    "16.07.2006",
+			wantVersion: 1,
+		},
+		{
+			name:        "name-based put keeps the stored date",
+			method:      http.MethodPut,
+			target:      "/api/songs?group=Muse&song=Supermassive",
+			body:        func(string) string { return `{"release_date":"16.07.2006","text":"Ooh"}` },
+			wantStatus:  http.StatusOK,
+			wantDate:    "16.07.2006",
+			wantVersion: 2,
+		},
+		{
+			name:        "merge patch keeps the stored date",
+			method:      http.MethodPatch,
+			target:      "/api/songs/1",
+			body:        func(string) string { return `{"release_date":"16.07.2006","text":"Ooh"}` },
+			wantStatus:  http.StatusOK,
+			wantDate:    "16.07.2006",
+			wantVersion: 2,
+		},
+		{
+			name:        "changed date is validated",
+			method:      http.MethodPut,
+			target:      "/api/songs/1",
+			body:        func(string) string { return `{"group":"Muse","song":"Supermassive","release_date":"17.07.2006"}` },
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDate:    "16.07.2006",
+			wantVersion: 1,
+		},
+		{
+			name:        "name-based put validates a changed date",
+			method:      http.MethodPut,
+			target:      "/api/songs?group=Muse&song=Supermassive",
+			body:        func(string) string { return `{"release_date":"17.07.2006"}` },
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDate:    "16.07.2006",
+			wantVersion: 1,
+		},
+		{
+			name:        "changed link is validated",
+			method:      http.MethodPut,
+			target:      "/api/songs/1",
+			body:        func(string) string { return `{"group":"Muse","song":"Supermassive","link":"example"}` },
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDate:    "16.07.2006",
+			wantVersion: 1,
+		},
+		{
+			name:        "date in YYYY-MM-DD",
+			method:      http.MethodPut,
+			target:      "/api/songs/1",
+			body:        func(string) string { return `{"group":"Muse","song":"Supermassive","release_date":"2006-07-17"}` },
+			wantStatus:  http.StatusOK,
+			wantDate:    "2006-07-17",
+			wantVersion: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newSongRouter(t, false)
+			if _, err := store.AddSong(context.Background(), "Muse", "Supermassive", detail); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+
+			got := serve(router, http.MethodGet, "/api/songs/1", "")
+			if got.Code != http.StatusOK {
+				t.Fatalf("GET status = %d, body %s", got.Code, got.Body)
+			}
+
+			w := serve(router, tt.method, tt.target, tt.body(got.Body.String()))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%s status = %d, want %d, body %s", tt.method, w.Code, tt.wantStatus, w.Body)
+			}
+
+			var song models.Song
+			if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/1", "").Body.Bytes(), &song); err != nil {
+				t.Fatalf("decode song: %v", err)
+			}
+			if song.ReleaseDate != tt.wantDate {
+				t.Errorf("release_date = %q, want %q", song.ReleaseDate, tt.wantDate)
+			}
+			if song.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", song.Version, tt.wantVersion)
+			}
+		})
+	}
+}
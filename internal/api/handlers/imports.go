@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// maxImportSize ограничивает размер загружаемого файла импорта.
+const maxImportSize = 64 << 20
+
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// StartImport godoc
+// @Summary      Import songs
+// @Description  Upload a CSV or NDJSON file of songs and import it as a background job.
+// @Description  The file is sent either as multipart form field "file" or as the raw request body.
+// @Description  Columns (CSV header) or keys (NDJSON): group, song (required), release_date, text, link.
+// @Description  Rows without text are completed from the external API like POST /songs; release_date and link from the file take precedence.
+// @Description  Existing songs and repeats within the file are skipped. Poll GET /imports/{id} for per-row results.
+// @Tags         imports
+// @Accept       mpfd
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        format query string false "File format: csv or ndjson; detected from the file name or Content-Type when omitted"
+// @Param        file formData file false "Import file"
+// @Success      202  {object}  models.ImportJob
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      422  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /imports [post]
+func (h *ImportHandler) StartImport(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	format := strings.ToLower(c.Query("format"))
+
+	var body io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			_ = c.Error(fmt.Errorf("file is required: %w", err)).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		defer file.Close()
+
+		body = file
+		if format == "" {
+			format = formatFromFileName(header.Filename)
+		}
+	} else if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+
+	if format == "" {
+		_ = c.Error(errors.New("cannot detect import format, pass format=csv or format=ndjson")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	rows, err := service.ParseImport(body, format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = c.Error(fmt.Errorf("import file exceeds %d bytes", maxImportSize)).SetType(gin.ErrorTypeBind)
+			return
+		}
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.importService.StartImport(c.Request.Context(), format, rows)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Location", "/api/imports/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImportJob godoc
+// @Summary      Get import job
+// @Description  Get import job progress and a page of per-row results: created, skipped (with reason) or failed (with reason)
+// @Tags         imports
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Import job ID"
+// @Param        status query string false "Filter results by row status: pending, created, skipped, failed"
+// @Param        limit query int false "Limit number of results" default(100)
+// @Param        offset query int false "Offset for results" default(0)
+// @Success      200  {object}  models.ImportJob
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /imports/{id} [get]
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ImportRowPending, models.ImportRowCreated, models.ImportRowSkipped, models.ImportRowFailed:
+	default:
+		_ = c.Error(errors.New("invalid status")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	limit, offset, ok := pagination(c, "100")
+	if !ok {
+		return
+	}
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), c.Param("id"), status, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func formatFromFileName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return service.ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return service.ImportFormatNDJSON
+	default:
+		return ""
+	}
+}
+
+func formatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return service.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return service.ImportFormatNDJSON
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type RefreshHandler struct {
+	refreshService *service.RefreshService
+}
+
+func NewRefreshHandler(refreshService *service.RefreshService) *RefreshHandler {
+	return &RefreshHandler{refreshService: refreshService}
+}
+
+// RefreshSong godoc
+// @Summary      Refresh song details
+// @Description  Re-fetch song details from the metadata providers and compare them with the stored ones.
+// @Description  With REFRESH_POLICY=auto differences are applied, with review they are queued as proposals;
+// @Description  fields edited manually are always only proposed. With async=true the song is marked stale
+// @Description  and refreshed by the background worker, and the response is 202.
+// @Tags         refresh
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        async query bool false "Mark the song stale instead of refreshing it now"
+// @Success      200  {object}  models.RefreshResult
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Failure      502  {object}  middleware.ErrorResponse
+// @Failure      504  {object}  middleware.ErrorResponse
+// @Router       /songs/{id}/refresh [post]
+func (h *RefreshHandler) RefreshSong(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		_ = c.Error(errors.New("invalid async")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if async {
+		if err := h.refreshService.ScheduleRefresh(c.Request.Context(), id); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"song_id": id, "message": "song refresh scheduled"})
+		return
+	}
+
+	result, err := h.refreshService.RefreshSong(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRefreshProposals godoc
+// @Summary      Get refresh proposals
+// @Description  Get changes from the metadata providers that wait for an editor's decision, oldest first
+// @Tags         refresh
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.RefreshProposal
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/refresh-proposals [get]
+func (h *RefreshHandler) GetRefreshProposals(c *gin.Context) {
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	proposals, err := h.refreshService.GetProposals(c.Request.Context(), limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+// AcceptRefreshProposal godoc
+// @Summary      Accept refresh proposal
+// @Description  Apply the proposed value to the song and record a revision
+// @Tags         refresh
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Proposal ID"
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/refresh-proposals/{id}/accept [post]
+func (h *RefreshHandler) AcceptRefreshProposal(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	song, err := h.refreshService.AcceptProposal(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, song)
+}
+
+// RejectRefreshProposal godoc
+// @Summary      Reject refresh proposal
+// @Description  Reject the proposed value. The same value is not proposed again for this field.
+// @Tags         refresh
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Proposal ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/refresh-proposals/{id}/reject [post]
+func (h *RefreshHandler) RejectRefreshProposal(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.refreshService.RejectProposal(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "refresh proposal rejected"})
+}
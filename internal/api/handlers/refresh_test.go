@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// hysteriaInfo — источник сведений, который знает только Muse — Hysteria.
+type hysteriaInfo struct{}
+
+func (hysteriaInfo) Name() string {
+	return "stub"
+}
+
+func (hysteriaInfo) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	if group != "Muse" || song != "Hysteria" {
+		return nil, songinfo.ErrNotFound
+	}
+	return &models.SongDetail{
+		ReleaseDate: "15.12.2003",
+		Text:        "It's bugging me",
+		Link:        "https://example.com/hysteria",
+		Sources:     map[string]string{models.FieldReleaseDate: "stub", models.FieldText: "stub", models.FieldLink: "stub"},
+	}, nil
+}
+
+// newRefreshRouter создает роутер с маршрутами обновления сведений по политике review
+// и песнями Hysteria (1) и Starlight (2).
+func newRefreshRouter(t *testing.T) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	songs := service.NewSongService(store, hysteriaInfo{}, service.Timeouts{}, log)
+	cfg := service.RefreshConfig{BatchSize: 10, MaxAge: time.Hour, Policy: models.RefreshPolicyReview}
+	h := NewRefreshHandler(service.NewRefreshService(store, songs, cfg, service.Timeouts{}, log))
+
+	for _, name := range []string{"Hysteria", "Starlight"} {
+		if _, err := store.AddSong(context.Background(), "Muse", name, models.SongDetail{Text: "old text"}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(log), middleware.Actor())
+	router.POST("/api/songs/:id/refresh", h.RefreshSong)
+	proposals := router.Group("/api/songs/refresh-proposals")
+	proposals.GET("", h.GetRefreshProposals)
+	proposals.POST("/:id/accept", h.AcceptRefreshProposal)
+	proposals.POST("/:id/reject", h.RejectRefreshProposal)
+	return router, store
+}
+
+func TestRefreshSongHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "refresh", target: "/api/songs/1/refresh", wantStatus: http.StatusOK},
+		{name: "schedule", target: "/api/songs/1/refresh?async=true", wantStatus: http.StatusAccepted},
+		{name: "unknown to providers", target: "/api/songs/2/refresh", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongInfoNotFound},
+		{name: "unknown song", target: "/api/songs/10/refresh", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongNotFound},
+		{name: "schedule unknown song", target: "/api/songs/10/refresh?async=true", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongNotFound},
+		{name: "invalid async", target: "/api/songs/1/refresh?async=later", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{name: "invalid id", target: "/api/songs/first/refresh", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newRefreshRouter(t)
+
+			w := serve(router, http.MethodPost, tt.target, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w.Body.Bytes()); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshProposals(t *testing.T) {
+	router, store := newRefreshRouter(t)
+
+	w := serve(router, http.MethodPost, "/api/songs/1/refresh", "")
+	var result models.RefreshResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode refresh result %s: %v", w.Body, err)
+	}
+	if result.Applied == nil || len(result.Applied) != 0 || len(result.Proposed) != 3 {
+		t.Fatalf("refresh result = %+v, want nothing applied and 3 proposals", result)
+	}
+
+	var proposals []models.RefreshProposal
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/refresh-proposals?limit=2", "").Body.Bytes(), &proposals); err != nil {
+		t.Fatalf("decode proposals: %v", err)
+	}
+	if len(proposals) != 2 || proposals[0].SongID != 1 || proposals[0].ID >= proposals[1].ID {
+		t.Fatalf("proposals = %+v, want the two oldest proposals of song 1", proposals)
+	}
+	accepted, rejected := proposals[0], proposals[1]
+
+	w = serve(router, http.MethodPost, "/api/songs/refresh-proposals/"+strconv.Itoa(accepted.ID)+"/accept", "", middleware.ActorHeader, "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("accept status = %d, body %s", w.Code, w.Body)
+	}
+	var song models.Song
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("decode song: %v", err)
+	}
+	if value := song.Sources[accepted.Field]; value != "stub" {
+		t.Errorf("source of %s after accept = %q, want stub", accepted.Field, value)
+	}
+	revisions, err := store.GetSongRevisions(context.Background(), 1, 1, 0)
+	if err != nil || len(revisions) != 1 || revisions[0].Actor != "alice" {
+		t.Errorf("latest revision = %+v, %v, want one by alice", revisions, err)
+	}
+
+	if w := serve(router, http.MethodPost, "/api/songs/refresh-proposals/"+strconv.Itoa(rejected.ID)+"/reject", ""); w.Code != http.StatusOK {
+		t.Fatalf("reject status = %d, body %s", w.Code, w.Body)
+	}
+
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/refresh-proposals", "").Body.Bytes(), &proposals); err != nil {
+		t.Fatalf("decode proposals: %v", err)
+	}
+	if len(proposals) != 1 || proposals[0].ID == accepted.ID || proposals[0].ID == rejected.ID {
+		t.Errorf("pending proposals = %+v, want only the undecided one", proposals)
+	}
+
+	for _, tt := range []struct {
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{target: "/api/songs/refresh-proposals/" + strconv.Itoa(accepted.ID) + "/accept", wantStatus: http.StatusNotFound, wantCode: middleware.CodeRefreshProposalNotFound},
+		{target: "/api/songs/refresh-proposals/" + strconv.Itoa(rejected.ID) + "/accept", wantStatus: http.StatusNotFound, wantCode: middleware.CodeRefreshProposalNotFound},
+		{target: "/api/songs/refresh-proposals/" + strconv.Itoa(rejected.ID) + "/reject", wantStatus: http.StatusNotFound, wantCode: middleware.CodeRefreshProposalNotFound},
+		{target: "/api/songs/refresh-proposals/first/reject", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+	} {
+		w := serve(router, http.MethodPost, tt.target, "")
+		if w.Code != tt.wantStatus || errorCode(t, w.Body.Bytes()) != tt.wantCode {
+			t.Errorf("POST %s status = %d, body %s, want %d %s", tt.target, w.Code, w.Body, tt.wantStatus, tt.wantCode)
+		}
+	}
+
+	if w := serve(router, http.MethodGet, "/api/songs/refresh-proposals?limit=-1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("GET with an invalid limit status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetSongRevisions godoc
+// @Summary      Get song revisions
+// @Description  Get the revision history of a song, newest first. Each revision holds full before/after snapshots,
+// @Description  the time of the change and the actor taken from the X-Actor header.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.SongRevision
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id}/revisions [get]
+func (h *SongHandler) GetSongRevisions(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.songService.GetSongRevisions(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetSongRevision godoc
+// @Summary      Get song revision
+// @Description  Get a single revision of a song by its number
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        rev path int true "Revision number"
+// @Success      200  {object}  models.SongRevision
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id}/revisions/{rev} [get]
+func (h *SongHandler) GetSongRevision(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	rev, ok := pathInt(c, "rev")
+	if !ok {
+		return
+	}
+
+	revision, err := h.songService.GetSongRevision(c.Request.Context(), id, rev)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// RestoreSongRevision godoc
+// @Summary      Restore song revision
+// @Description  Restore the song to its state after the given revision (before it, for a delete revision).
+// @Description  A deleted song is recreated under its former id. The restore itself is recorded as a new revision.
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Param        rev path int true "Revision number"
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/{id}/revisions/{rev}/restore [post]
+func (h *SongHandler) RestoreSongRevision(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	rev, ok := pathInt(c, "rev")
+	if !ok {
+		return
+	}
+
+	song, err := h.songService.RestoreSongRevision(c.Request.Context(), id, rev)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, song)
+}
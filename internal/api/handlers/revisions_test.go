@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// revisionSummary описывает правки как "номер:действие:автор", начиная с новой.
+func revisionSummary(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var revisions []models.SongRevision
+	if err := json.Unmarshal(body, &revisions); err != nil {
+		t.Fatalf("decode revisions %s: %v", body, err)
+	}
+
+	summary := make([]string, 0, len(revisions))
+	for _, r := range revisions {
+		summary = append(summary, strconv.Itoa(r.Revision)+":"+r.Action+":"+r.Actor)
+	}
+	return summary
+}
+
+func TestSongRevisions(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: "first"}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	if w := serve(router, http.MethodPatch, "/api/songs/1", `{"text":"second"}`, middleware.ActorHeader, "alice"); w.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, body %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodPut, "/api/songs/1", `{"group":"Muse","song":"Hysteria (Live)","text":"second"}`, middleware.ActorHeader, "bob"); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   string
+		want       []string
+	}{
+		{name: "newest first", target: "/api/songs/1/revisions", wantStatus: http.StatusOK, want: []string{"3:update:bob", "2:update:alice", "1:create:"}},
+		{name: "page", target: "/api/songs/1/revisions?limit=1&offset=1", wantStatus: http.StatusOK, want: []string{"2:update:alice"}},
+		{name: "past the end", target: "/api/songs/1/revisions?offset=3", wantStatus: http.StatusOK, want: []string{}},
+		{name: "unknown song", target: "/api/songs/10/revisions", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongNotFound},
+		{name: "invalid limit", target: "/api/songs/1/revisions?limit=-1", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, tt.target, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				if code := errorCode(t, w.Body.Bytes()); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			if got := revisionSummary(t, w.Body.Bytes()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("revisions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	w := serve(router, http.MethodGet, "/api/songs/1/revisions/2", "")
+	var revision models.SongRevision
+	if err := json.Unmarshal(w.Body.Bytes(), &revision); err != nil {
+		t.Fatalf("decode revision %s: %v", w.Body, err)
+	}
+	if revision.Before == nil || revision.After == nil || revision.Before.Text != "first" || revision.After.Text != "second" {
+		t.Errorf("revision 2 = %+v, want first -> second", revision)
+	}
+	if revision.CreatedAt.IsZero() {
+		t.Error("revision 2 created_at is zero")
+	}
+
+	for target, wantStatus := range map[string]int{
+		"/api/songs/1/revisions/10":    http.StatusNotFound,
+		"/api/songs/1/revisions/first": http.StatusBadRequest,
+		"/api/songs/1/revisions/0":     http.StatusBadRequest,
+	} {
+		if w := serve(router, http.MethodGet, target, ""); w.Code != wantStatus {
+			t.Errorf("GET %s status = %d, want %d", target, w.Code, wantStatus)
+		}
+	}
+}
+
+func TestRestoreSongRevision(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: "first"}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	if w := serve(router, http.MethodPut, "/api/songs/1", `{"group":"Muse","song":"Hysteria (Live)","text":"second"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body %s", w.Code, w.Body)
+	}
+
+	// Восстановление — новая правка: версия растет, а ETag, полученный до него, устаревает
+	w := serve(router, http.MethodPost, "/api/songs/1/revisions/1/restore", "", middleware.ActorHeader, "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body %s", w.Code, w.Body)
+	}
+	var song models.Song
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("decode song: %v", err)
+	}
+	if song.Song != "Hysteria" || song.Text != "first" {
+		t.Errorf("restored song = %s / %q, want Hysteria / first", song.Song, song.Text)
+	}
+
+	got := serve(router, http.MethodGet, "/api/songs/1", "")
+	if etag := got.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag after restore = %s, want \"3\"", etag)
+	}
+	if w := serve(router, http.MethodPatch, "/api/songs/1", `{"text":"stale"}`, "If-Match", `"2"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH with the pre-restore ETag status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+
+	summary := revisionSummary(t, serve(router, http.MethodGet, "/api/songs/1/revisions?limit=1", "").Body.Bytes())
+	if len(summary) != 1 || summary[0] != "3:restore:alice" {
+		t.Errorf("latest revision = %v, want 3:restore:alice", summary)
+	}
+
+	// Удаленная песня восстанавливается из правки под прежним id
+	if w := serve(router, http.MethodDelete, "/api/songs/1", ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, body %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodPost, "/api/songs/1/revisions/2/restore", ""); w.Code != http.StatusOK {
+		t.Fatalf("restore of a deleted song status = %d, body %s", w.Code, w.Body)
+	}
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/1", "").Body.Bytes(), &song); err != nil {
+		t.Fatalf("decode song: %v", err)
+	}
+	if song.Song != "Hysteria (Live)" || song.Text != "second" {
+		t.Errorf("song after restoring a deleted song = %s / %q, want Hysteria (Live) / second", song.Song, song.Text)
+	}
+
+	// Название из правки занято другой песней
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	w = serve(router, http.MethodPost, "/api/songs/1/revisions/1/restore", "")
+	if w.Code != http.StatusConflict || errorCode(t, w.Body.Bytes()) != middleware.CodeSongExists {
+		t.Errorf("restore onto a taken name status = %d, body %s, want 409 song_exists", w.Code, w.Body)
+	}
+
+	w = serve(router, http.MethodPost, "/api/songs/1/revisions/10/restore", "")
+	if w.Code != http.StatusNotFound || errorCode(t, w.Body.Bytes()) != middleware.CodeRevisionNotFound {
+		t.Errorf("restore of an unknown revision status = %d, body %s, want 404 revision_not_found", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type TrashHandler struct {
+	trashService *service.TrashService
+}
+
+func NewTrashHandler(trashService *service.TrashService) *TrashHandler {
+	return &TrashHandler{trashService: trashService}
+}
+
+// GetTrashedSongs godoc
+// @Summary      Get trashed songs
+// @Description  Get deleted songs that are still in the trash, most recently deleted first
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Limit number of records" default(10)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200  {array}   models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/trash [get]
+func (h *TrashHandler) GetTrashedSongs(c *gin.Context) {
+	limit, offset, ok := pagination(c, "10")
+	if !ok {
+		return
+	}
+
+	songs, err := h.trashService.GetTrashedSongs(c.Request.Context(), limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, songs)
+}
+
+// RestoreSong godoc
+// @Summary      Restore song from trash
+// @Description  Move a deleted song back from the trash. Fails with 409 if a song with the same group and name was added since.
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Song ID"
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  middleware.ErrorResponse
+// @Failure      404  {object}  middleware.ErrorResponse
+// @Failure      409  {object}  middleware.ErrorResponse
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/trash/{id}/restore [post]
+func (h *TrashHandler) RestoreSong(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	song, err := h.trashService.RestoreSong(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, song)
+}
+
+// PurgeTrash godoc
+// @Summary      Purge trash
+// @Description  Permanently remove songs that have been in the trash longer than the retention period (TRASH_RETENTION)
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]int
+// @Failure      500  {object}  middleware.ErrorResponse
+// @Router       /songs/trash [delete]
+func (h *TrashHandler) PurgeTrash(c *gin.Context) {
+	purged, err := h.trashService.Purge(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
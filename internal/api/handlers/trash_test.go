@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTrashRouter добавляет к маршрутам песен маршруты корзины с указанным сроком хранения.
+func newTrashRouter(t *testing.T, retention time.Duration) (*gin.Engine, *memory.Storage) {
+	t.Helper()
+
+	router, store := newSongRouter(t, false)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewTrashHandler(service.NewTrashService(store, retention, service.Timeouts{}, log))
+
+	trash := router.Group("/api/songs/trash")
+	trash.GET("", h.GetTrashedSongs)
+	trash.POST("/:id/restore", h.RestoreSong)
+	trash.DELETE("", h.PurgeTrash)
+	return router, store
+}
+
+func TestSongTrash(t *testing.T) {
+	router, store := newTrashRouter(t, time.Hour)
+	for _, name := range []string{"Hysteria", "Starlight"} {
+		if _, err := store.AddSong(context.Background(), "Muse", name, models.SongDetail{Text: name + " lyrics"}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+
+	if w := serve(router, http.MethodDelete, "/api/songs/1", ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, body %s", w.Code, w.Body)
+	}
+
+	// Удаленная песня скрыта отовсюду, кроме корзины
+	if w := serve(router, http.MethodGet, "/api/songs/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET of a trashed song status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	var songs []models.Song
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs?group=Muse", "").Body.Bytes(), &songs); err != nil {
+		t.Fatalf("decode songs: %v", err)
+	}
+	if len(songs) != 1 || songs[0].ID != 2 {
+		t.Errorf("songs = %+v, want only song 2", songs)
+	}
+	if w := serve(router, http.MethodDelete, "/api/songs/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second DELETE status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	var trashed []models.Song
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/trash", "").Body.Bytes(), &trashed); err != nil {
+		t.Fatalf("decode trash: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != 1 || trashed[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v, want song 1 with deleted_at", trashed)
+	}
+
+	// Пока песня в корзине, ее название можно занять, и тогда восстановить ее нельзя
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{}); err != nil {
+		t.Fatalf("AddSong() of a trashed name error = %v", err)
+	}
+	w := serve(router, http.MethodPost, "/api/songs/trash/1/restore", "")
+	if w.Code != http.StatusConflict || errorCode(t, w.Body.Bytes()) != middleware.CodeSongExists {
+		t.Errorf("restore onto a taken name status = %d, body %s, want 409 song_exists", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodDelete, "/api/songs/3", ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, body %s", w.Code, w.Body)
+	}
+
+	w = serve(router, http.MethodPost, "/api/songs/trash/1/restore", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body %s", w.Code, w.Body)
+	}
+	var song models.Song
+	if err := json.Unmarshal(serve(router, http.MethodGet, "/api/songs/1", "").Body.Bytes(), &song); err != nil {
+		t.Fatalf("decode song: %v", err)
+	}
+	if song.Text != "Hysteria lyrics" || song.DeletedAt != nil {
+		t.Errorf("restored song = %+v, want the original text without deleted_at", song)
+	}
+
+	for target, wantStatus := range map[string]int{
+		"/api/songs/trash/1/restore":     http.StatusNotFound,
+		"/api/songs/trash/10/restore":    http.StatusNotFound,
+		"/api/songs/trash/first/restore": http.StatusBadRequest,
+	} {
+		if w := serve(router, http.MethodPost, target, ""); w.Code != wantStatus {
+			t.Errorf("POST %s status = %d, want %d", target, w.Code, wantStatus)
+		}
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	tests := []struct {
+		name       string
+		retention  time.Duration
+		wantPurged int
+	}{
+		{name: "within retention", retention: time.Hour, wantPurged: 0},
+		{name: "retention expired", retention: time.Millisecond, wantPurged: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store := newTrashRouter(t, tt.retention)
+			for _, name := range []string{"Hysteria", "Starlight"} {
+				if _, err := store.AddSong(context.Background(), "Muse", name, models.SongDetail{}); err != nil {
+					t.Fatalf("AddSong() error = %v", err)
+				}
+			}
+			if w := serve(router, http.MethodDelete, "/api/songs/1", ""); w.Code != http.StatusOK {
+				t.Fatalf("DELETE status = %d, body %s", w.Code, w.Body)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			w := serve(router, http.MethodDelete, "/api/songs/trash", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("purge status = %d, body %s", w.Code, w.Body)
+			}
+			var resp struct {
+				Purged int `json:"purged"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode purge response: %v", err)
+			}
+			if resp.Purged != tt.wantPurged {
+				t.Errorf("purged = %d, want %d", resp.Purged, tt.wantPurged)
+			}
+
+			// Окончательно удаленную песню уже нельзя вернуть из корзины
+			wantRestore := http.StatusOK
+			if tt.wantPurged > 0 {
+				wantRestore = http.StatusNotFound
+			}
+			if w := serve(router, http.MethodPost, "/api/songs/trash/1/restore", ""); w.Code != wantRestore {
+				t.Errorf("restore status = %d, want %d", w.Code, wantRestore)
+			}
+			if w := serve(router, http.MethodGet, "/api/songs/2", ""); w.Code != http.StatusOK {
+				t.Errorf("GET of a live song status = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+}
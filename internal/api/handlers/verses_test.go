@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/TakuroBreath/song-library/internal/api/middleware"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// versesText — текст из припева и двух куплетов; припев занимает символы 9–20.
+const versesText = "[Chorus]\nla la\nна-на\n\nverse one\n\nverse two"
+
+func TestGetSongVersesStructured(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: versesText}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	offset := func(v int) *int { return &v }
+
+	tests := []struct {
+		name   string
+		target string
+		want   models.VersePage
+	}{
+		{
+			name:   "first page",
+			target: "/api/songs/1/verses?format=structured&limit=2",
+			want: models.VersePage{
+				Verses: []*models.StructuredVerse{
+					{Index: 0, Kind: models.VerseKindChorus, Lines: []string{"la la", "на-на"}, Start: 9, End: 20},
+					{Index: 1, Kind: models.VerseKindVerse, Lines: []string{"verse one"}, Start: 22, End: 31},
+				},
+				Total:      3,
+				NextOffset: offset(2),
+			},
+		},
+		{
+			name:   "last page",
+			target: "/api/songs/1/verses?format=structured&limit=2&offset=2",
+			want: models.VersePage{
+				Verses:     []*models.StructuredVerse{{Index: 2, Kind: models.VerseKindVerse, Lines: []string{"verse two"}, Start: 33, End: 42}},
+				Total:      3,
+				NextOffset: nil,
+			},
+		},
+		{
+			name:   "past the end",
+			target: "/api/songs/1/verses?format=structured&offset=5",
+			want:   models.VersePage{Verses: []*models.StructuredVerse{}, Total: 3},
+		},
+		{
+			name:   "by name",
+			target: "/api/songs/verses?group=Muse&song=Hysteria&format=structured&limit=1&offset=2",
+			want: models.VersePage{
+				Verses: []*models.StructuredVerse{{Index: 2, Kind: models.VerseKindVerse, Lines: []string{"verse two"}, Start: 33, End: 42}},
+				Total:  3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, tt.target, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			if total := w.Header().Get("X-Total-Count"); total != "3" {
+				t.Errorf("X-Total-Count = %q, want 3", total)
+			}
+
+			var page models.VersePage
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode page %s: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(page, tt.want) {
+				t.Errorf("page = %s, want %+v", w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSongVersesText(t *testing.T) {
+	router, store := newSongRouter(t, false)
+	if _, err := store.AddSong(context.Background(), "Muse", "Hysteria", models.SongDetail{Text: versesText}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	// Без format части отдаются строками, в которых переводы строк заменены пробелами
+	w := serve(router, http.MethodGet, "/api/songs/1/verses?limit=2", "")
+	var verses []string
+	if err := json.Unmarshal(w.Body.Bytes(), &verses); err != nil {
+		t.Fatalf("decode verses %s: %v", w.Body, err)
+	}
+	if want := []string{"la la на-на", "verse one"}; !reflect.DeepEqual(verses, want) {
+		t.Errorf("verses = %q, want %q", verses, want)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "3" {
+		t.Errorf("X-Total-Count = %q, want 3", total)
+	}
+
+	for _, tt := range []struct {
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{target: "/api/songs/1/verses?format=html", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{target: "/api/songs/1/verses?format=structured&limit=-1", wantStatus: http.StatusBadRequest, wantCode: middleware.CodeBadRequest},
+		{target: "/api/songs/10/verses?format=structured", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongNotFound},
+		{target: "/api/songs/verses?group=Muse&song=Starlight&format=structured", wantStatus: http.StatusNotFound, wantCode: middleware.CodeSongNotFound},
+	} {
+		w := serve(router, http.MethodGet, tt.target, "")
+		if w.Code != tt.wantStatus || errorCode(t, w.Body.Bytes()) != tt.wantCode {
+			t.Errorf("GET %s = %d %s, want %d %s", tt.target, w.Code, w.Body, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
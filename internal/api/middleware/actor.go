@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// ActorHeader — заголовок, в котором клиент передает автора изменений.
+const ActorHeader = "X-Actor"
+
+// Actor передает автора изменений из заголовка X-Actor в контекст запроса,
+// чтобы хранилище записало его в историю изменений песен.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := strings.TrimSpace(c.GetHeader(ActorHeader)); actor != "" {
+			c.Request = c.Request.WithContext(storage.WithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/service"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// Машиночитаемые коды ошибок API. Значения являются частью контракта и не должны меняться.
+const (
+	CodeBadRequest              = "bad_request"
+	CodeValidationFailed        = "validation_failed"
+	CodeNotFound                = "not_found"
+	CodeSongNotFound            = "song_not_found"
+	CodeRevisionNotFound        = "revision_not_found"
+	CodeImportJobNotFound       = "import_job_not_found"
+	CodeRefreshProposalNotFound = "refresh_proposal_not_found"
+	CodeSongInfoNotFound        = "song_info_not_found"
+	CodeArtistNotFound          = "artist_not_found"
+	CodeConflict                = "conflict"
+	CodePatchConflict           = "patch_conflict"
+	CodeVersionMismatch         = "version_mismatch"
+	CodeVersionRequired         = "version_required"
+	CodeSongExists              = "song_exists"
+	CodeArtistExists            = "artist_exists"
+	CodeArtistHasSongs          = "artist_has_songs"
+	CodeArtistHasAlbums         = "artist_has_albums"
+	CodeAlbumNotFound           = "album_not_found"
+	CodeAlbumExists             = "album_exists"
+	CodeUpstreamUnavailable     = "upstream_unavailable"
+	CodeUpstreamCircuitOpen     = "upstream_circuit_open"
+	CodeUpstreamInvalidResponse = "upstream_invalid_response"
+	CodeUpstreamTimeout         = "upstream_timeout"
+	CodeTimeout                 = "timeout"
+	CodeInternal                = "internal_error"
+)
+
+// ErrorResponse описывает тело ответа с ошибкой.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+type errorRule struct {
+	target error
+	status int
+	code   string
+	// detailed означает, что клиенту отдается полный текст ошибки, а не только текст target.
+	detailed bool
+}
+
+// errorRules проверяются по порядку: сначала конкретные ошибки, затем их базовые виды.
+var errorRules = []errorRule{
+	{target: storage.ErrSongNotFound, status: http.StatusNotFound, code: CodeSongNotFound},
+	{target: storage.ErrRevisionNotFound, status: http.StatusNotFound, code: CodeRevisionNotFound},
+	{target: service.ErrSongInfoNotFound, status: http.StatusNotFound, code: CodeSongInfoNotFound},
+	{target: storage.ErrArtistNotFound, status: http.StatusNotFound, code: CodeArtistNotFound},
+	{target: storage.ErrAlbumNotFound, status: http.StatusNotFound, code: CodeAlbumNotFound},
+	{target: service.ErrImportJobNotFound, status: http.StatusNotFound, code: CodeImportJobNotFound},
+	{target: storage.ErrRefreshProposalNotFound, status: http.StatusNotFound, code: CodeRefreshProposalNotFound},
+	{target: storage.ErrNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{target: storage.ErrSongExists, status: http.StatusConflict, code: CodeSongExists},
+	{target: storage.ErrArtistExists, status: http.StatusConflict, code: CodeArtistExists},
+	{target: storage.ErrArtistHasSongs, status: http.StatusConflict, code: CodeArtistHasSongs},
+	{target: storage.ErrAlbumExists, status: http.StatusConflict, code: CodeAlbumExists},
+	{target: storage.ErrArtistHasAlbums, status: http.StatusConflict, code: CodeArtistHasAlbums},
+	{target: storage.ErrAlreadyExists, status: http.StatusConflict, code: CodeConflict},
+	{target: service.ErrPatchConflict, status: http.StatusConflict, code: CodePatchConflict, detailed: true},
+	{target: storage.ErrSongVersionMismatch, status: http.StatusPreconditionFailed, code: CodeVersionMismatch},
+	{target: service.ErrVersionRequired, status: http.StatusPreconditionRequired, code: CodeVersionRequired},
+	{target: service.ErrValidation, status: http.StatusUnprocessableEntity, code: CodeValidationFailed, detailed: true},
+	{target: service.ErrUpstreamTimeout, status: http.StatusGatewayTimeout, code: CodeUpstreamTimeout},
+	{target: service.ErrUpstreamCircuitOpen, status: http.StatusServiceUnavailable, code: CodeUpstreamCircuitOpen},
+	{target: service.ErrUpstreamUnavailable, status: http.StatusBadGateway, code: CodeUpstreamUnavailable},
+	{target: service.ErrUpstreamInvalidResponse, status: http.StatusBadGateway, code: CodeUpstreamInvalidResponse},
+	{target: context.DeadlineExceeded, status: http.StatusGatewayTimeout, code: CodeTimeout},
+}
+
+// ErrorHandler преобразует ошибки, добавленные обработчиками через c.Error, в HTTP-ответ
+// с подходящим статусом и стабильным кодом ошибки.
+func ErrorHandler(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		ginErr := c.Errors.Last()
+
+		if ginErr.IsType(gin.ErrorTypeBind) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: ginErr.Error(), Code: CodeBadRequest})
+			return
+		}
+
+		err := ginErr.Err
+
+		// Клиент уже отключился, отвечать некому.
+		if errors.Is(err, context.Canceled) {
+			c.Abort()
+			return
+		}
+
+		for _, rule := range errorRules {
+			if errors.Is(err, rule.target) {
+				message := rule.target.Error()
+				if rule.detailed {
+					message = err.Error()
+				}
+				c.JSON(rule.status, ErrorResponse{Error: message, Code: rule.code})
+				return
+			}
+		}
+
+		log.Error("Unhandled request error",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.FullPath()),
+			slog.Any("error", err))
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: CodeInternal})
+	}
+}
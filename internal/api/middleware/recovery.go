@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery перехватывает панику обработчика, записывает ее в журнал и отвечает 500.
+// Паника http.ErrAbortHandler пропускается дальше: ею обработчик обрывает уже начатый ответ,
+// и сервер сам закрывает соединение HTTP/1.x или сбрасывает поток HTTP/2.
+func Recovery(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.Error("Panic recovered",
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.FullPath()),
+				slog.Any("panic", recovered),
+				slog.String("stack", string(debug.Stack())))
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: CodeInternal})
+		}()
+
+		c.Next()
+	}
+}
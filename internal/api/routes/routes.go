@@ -11,16 +11,148 @@ func SetupSongRoutes(router *gin.Engine, songHandler *handlers.SongHandler) {
 		// GET /api/songs - получение списка песен с фильтрацией и пагинацией
 		songs.GET("", songHandler.GetSongs)
 
-		// GET /api/songs/verses - получение куплетов песни
+		// GET /api/songs/search - полнотекстовый поиск песен
+		songs.GET("/search", songHandler.SearchSongs)
+
+		// GET /api/songs/export - потоковая выгрузка песен в NDJSON, CSV или JSON
+		songs.GET("/export", songHandler.ExportSongs)
+
+		// GET /api/songs/verses - получение куплетов песни по группе и названию
 		songs.GET("/verses", songHandler.GetSongVerses)
 
 		// POST /api/songs - добавление новой песни
 		songs.POST("", songHandler.AddSong)
 
-		// PUT /api/songs - обновление информации о песне
+		// PUT /api/songs - обновление информации о песне по группе и названию
 		songs.PUT("", songHandler.UpdateSong)
 
-		// DELETE /api/songs - удаление песни
+		// DELETE /api/songs - удаление песни по группе и названию
 		songs.DELETE("", songHandler.DeleteSong)
+
+		// GET /api/songs/:id - получение песни
+		songs.GET("/:id", songHandler.GetSong)
+
+		// PUT /api/songs/:id - замена всех изменяемых полей песни
+		songs.PUT("/:id", songHandler.ReplaceSong)
+
+		// PATCH /api/songs/:id - изменение переданных полей песни
+		songs.PATCH("/:id", songHandler.PatchSong)
+
+		// DELETE /api/songs/:id - удаление песни
+		songs.DELETE("/:id", songHandler.DeleteSongByID)
+
+		// GET /api/songs/:id/verses - получение куплетов песни
+		songs.GET("/:id/verses", songHandler.GetSongVersesByID)
+
+		// GET /api/songs/:id/revisions - история изменений песни
+		songs.GET("/:id/revisions", songHandler.GetSongRevisions)
+
+		// GET /api/songs/:id/revisions/:rev - получение правки песни
+		songs.GET("/:id/revisions/:rev", songHandler.GetSongRevision)
+
+		// POST /api/songs/:id/revisions/:rev/restore - восстановление песни из правки
+		songs.POST("/:id/revisions/:rev/restore", songHandler.RestoreSongRevision)
+	}
+}
+
+func SetupTrashRoutes(router *gin.Engine, trashHandler *handlers.TrashHandler) {
+	trash := router.Group("/api/songs/trash")
+	{
+		// GET /api/songs/trash - получение списка удаленных песен
+		trash.GET("", trashHandler.GetTrashedSongs)
+
+		// POST /api/songs/trash/:id/restore - восстановление песни из корзины
+		trash.POST("/:id/restore", trashHandler.RestoreSong)
+
+		// DELETE /api/songs/trash - окончательное удаление песен с истекшим сроком хранения
+		trash.DELETE("", trashHandler.PurgeTrash)
+	}
+}
+
+func SetupRefreshRoutes(router *gin.Engine, refreshHandler *handlers.RefreshHandler) {
+	// POST /api/songs/:id/refresh - сверка сведений песни с источниками
+	router.POST("/api/songs/:id/refresh", refreshHandler.RefreshSong)
+
+	proposals := router.Group("/api/songs/refresh-proposals")
+	{
+		// GET /api/songs/refresh-proposals - изменения из источников, ожидающие решения редактора
+		proposals.GET("", refreshHandler.GetRefreshProposals)
+
+		// POST /api/songs/refresh-proposals/:id/accept - применение предложенного изменения
+		proposals.POST("/:id/accept", refreshHandler.AcceptRefreshProposal)
+
+		// POST /api/songs/refresh-proposals/:id/reject - отклонение предложенного изменения
+		proposals.POST("/:id/reject", refreshHandler.RejectRefreshProposal)
+	}
+}
+
+func SetupArtistRoutes(router *gin.Engine, artistHandler *handlers.ArtistHandler) {
+	artists := router.Group("/api/artists")
+	{
+		// GET /api/artists - получение списка исполнителей
+		artists.GET("", artistHandler.GetArtists)
+
+		// GET /api/artists/:id - получение исполнителя
+		artists.GET("/:id", artistHandler.GetArtist)
+
+		// GET /api/artists/:id/songs - получение песен исполнителя
+		artists.GET("/:id/songs", artistHandler.GetArtistSongs)
+
+		// POST /api/artists - добавление исполнителя
+		artists.POST("", artistHandler.AddArtist)
+
+		// PUT /api/artists/:id - обновление исполнителя
+		artists.PUT("/:id", artistHandler.UpdateArtist)
+
+		// DELETE /api/artists/:id - удаление исполнителя
+		artists.DELETE("/:id", artistHandler.DeleteArtist)
+	}
+}
+
+func SetupAlbumRoutes(router *gin.Engine, albumHandler *handlers.AlbumHandler) {
+	albums := router.Group("/api/albums")
+	{
+		// GET /api/albums - получение списка альбомов
+		albums.GET("", albumHandler.GetAlbums)
+
+		// GET /api/albums/:id - получение альбома с трек-листом
+		albums.GET("/:id", albumHandler.GetAlbum)
+
+		// GET /api/albums/:id/songs - получение песен альбома с куплетами
+		albums.GET("/:id/songs", albumHandler.GetAlbumSongs)
+
+		// POST /api/albums - добавление альбома
+		albums.POST("", albumHandler.AddAlbum)
+
+		// PUT /api/albums/:id - обновление альбома
+		albums.PUT("/:id", albumHandler.UpdateAlbum)
+
+		// PUT /api/albums/:id/tracks - замена и переупорядочивание трек-листа
+		albums.PUT("/:id/tracks", albumHandler.SetAlbumTracks)
+
+		// DELETE /api/albums/:id - удаление альбома
+		albums.DELETE("/:id", albumHandler.DeleteAlbum)
+	}
+}
+
+func SetupImportRoutes(router *gin.Engine, importHandler *handlers.ImportHandler) {
+	imports := router.Group("/api/imports")
+	{
+		// POST /api/imports - запуск импорта песен из CSV или NDJSON
+		imports.POST("", importHandler.StartImport)
+
+		// GET /api/imports/:id - состояние задачи импорта и итоги по строкам
+		imports.GET("/:id", importHandler.GetImportJob)
+	}
+}
+
+func SetupAdminRoutes(router *gin.Engine, adminHandler *handlers.AdminHandler) {
+	admin := router.Group("/api/admin")
+	{
+		// DELETE /api/admin/info-cache - сброс кэша ответов источников сведений
+		admin.DELETE("/info-cache", adminHandler.InvalidateInfoCache)
+
+		// POST /api/admin/verses/reparse - повторный разбор текстов песен на части
+		admin.POST("/verses/reparse", adminHandler.ReparseVerses)
 	}
 }
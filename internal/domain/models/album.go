@@ -0,0 +1,25 @@
+package models
+
+type Album struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	ArtistID    int          `json:"artist_id"`
+	Artist      string       `json:"artist"`
+	ReleaseDate string       `json:"release_date"`
+	CoverLink   string       `json:"cover_link"`
+	Tracks      []AlbumTrack `json:"tracks,omitempty"`
+}
+
+// AlbumTrack — позиция в трек-листе альбома. Позиции нумеруются с 1.
+type AlbumTrack struct {
+	Position int    `json:"position"`
+	SongID   int    `json:"song_id"`
+	Song     string `json:"song"`
+}
+
+// AlbumSong — песня альбома вместе с позицией в трек-листе и куплетами.
+type AlbumSong struct {
+	Position int      `json:"position"`
+	Song     Song     `json:"song"`
+	Verses   []string `json:"verses"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type Artist struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Country     string   `json:"country"`
+	FormedYear  *int     `json:"formed_year"`
+	Description string   `json:"description"`
+	Links       []string `json:"links"`
+}
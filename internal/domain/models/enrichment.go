@@ -0,0 +1,20 @@
+package models
+
+// Состояния обогащения песни сведениями из внешнего API.
+const (
+	// EnrichmentEnriched — сведения получены или песня добавлена сразу с ними.
+	EnrichmentEnriched = "enriched"
+	// EnrichmentPending — песня сохранена и ждет, пока фоновый обработчик запросит сведения.
+	EnrichmentPending = "pending_enrichment"
+	// EnrichmentFailed — сведения получить не удалось, попытки исчерпаны.
+	EnrichmentFailed = "enrichment_failed"
+)
+
+// EnrichmentTask — задача из очереди обогащения (outbox). Attempts учитывает и текущую попытку.
+type EnrichmentTask struct {
+	ID       int
+	SongID   int
+	Group    string
+	Song     string
+	Attempts int
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Состояния задачи импорта.
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+)
+
+// Итоги обработки строки импорта.
+const (
+	ImportRowPending = "pending"
+	ImportRowCreated = "created"
+	ImportRowSkipped = "skipped"
+	ImportRowFailed  = "failed"
+)
+
+// ImportJob — фоновая задача массового импорта песен. Results содержит итоги по строкам
+// в порядке файла; в ответе API он может быть отфильтрован и разбит на страницы.
+type ImportJob struct {
+	ID         string            `json:"id"`
+	Format     string            `json:"format"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	Created    int               `json:"created"`
+	Skipped    int               `json:"skipped"`
+	Failed     int               `json:"failed"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Results    []ImportRowResult `json:"results,omitempty"`
+}
+
+// ImportRowResult — итог обработки одной строки файла. Строки нумеруются с 1 без учета заголовка CSV.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Status string `json:"status"`
+	SongID int    `json:"song_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// InfoCacheEntry — сохраненный ответ источников сведений о песне. NotFound означает, что источники
+// песню не знают (отрицательное кэширование); тогда Detail пуст.
+type InfoCacheEntry struct {
+	Detail    SongDetail
+	NotFound  bool
+	ExpiresAt time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Политики обновления сведений о песнях: auto применяет изменения из источников сразу,
+// review только предлагает их редактору. Поля, исправленные вручную, всегда только предлагаются.
+const (
+	RefreshPolicyAuto   = "auto"
+	RefreshPolicyReview = "review"
+)
+
+// FieldChange — расхождение поля песни со сведениями источника.
+type FieldChange struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+	Source   string `json:"source"`
+}
+
+// RefreshProposal — изменение поля из источника сведений, ожидающее решения редактора.
+type RefreshProposal struct {
+	ID     int `json:"id"`
+	SongID int `json:"song_id"`
+	FieldChange
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RefreshResult — итог обновления сведений одной песни.
+type RefreshResult struct {
+	SongID   int           `json:"song_id"`
+	Applied  []FieldChange `json:"applied"`
+	Proposed []FieldChange `json:"proposed"`
+}
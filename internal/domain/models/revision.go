@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Действия, которые фиксируются в истории изменений песни.
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// SongRevision — запись истории изменений песни. Before пуст для создания, After — для удаления.
+// Revision нумеруется с 1 отдельно для каждой песни.
+type SongRevision struct {
+	SongID    int       `json:"song_id"`
+	Revision  int       `json:"revision"`
+	Action    string    `json:"action"`
+	Before    *Song     `json:"before"`
+	After     *Song     `json:"after"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Song struct {
 	ID          int    `json:"id" `
 	Group       string `json:"group" binding:"required,min=1,max=255"`
@@ -7,4 +9,58 @@ type Song struct {
 	ReleaseDate string `json:"release_date" binding:"required,datetime=2006-01-02"`
 	Text        string `json:"text" binding:"required"`
 	Link        string `json:"link" binding:"required,url"`
+	ArtistID    int    `json:"artist_id"`
+	// EnrichmentStatus показывает, получены ли сведения о песне из внешнего API.
+	EnrichmentStatus string `json:"enrichment_status"`
+	// Sources указывает для полей release_date, text и link, какой источник сведений их заполнил.
+	Sources map[string]string `json:"sources,omitempty"`
+	// CreatedAt — время добавления песни.
+	CreatedAt time.Time `json:"created_at"`
+	// DeletedAt заполняется только у песен в корзине.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version — номер последней правки песни. Меняется при каждом изменении и служит для ETag.
+	Version int `json:"version"`
+}
+
+// Поля песни, которые заполняются из источников сведений.
+const (
+	FieldReleaseDate = "release_date"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+// Особые источники полей: файл импорта и правка редактора. Поля с источником manual
+// фоновое обновление сведений не меняет без решения редактора.
+const (
+	SourceImport = "import"
+	SourceManual = "manual"
+)
+
+// SongDetail — сведения о песне из источников: дата выпуска, текст и ссылка.
+// Sources указывает для каждого заполненного поля имя источника, который его дал.
+type SongDetail struct {
+	ReleaseDate string
+	Text        string
+	Link        string
+	Sources     map[string]string
+}
+
+// Complete сообщает, заполнены ли все поля сведений.
+func (d *SongDetail) Complete() bool {
+	return d.ReleaseDate != "" && d.Text != "" && d.Link != ""
+}
+
+// SongPage — страница списка песен при постраничной выборке по курсору.
+// NextCursor пуст, если страница последняя.
+type SongPage struct {
+	Songs      []*Song `json:"songs"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// SongSearchResult — песня, найденная полнотекстовым поиском, с релевантностью и фрагментом текста.
+// Совпадения во фрагменте выделены тегами <b> и </b>.
+type SongSearchResult struct {
+	Song
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
 }
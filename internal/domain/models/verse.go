@@ -0,0 +1,38 @@
+package models
+
+// Виды частей песни.
+const (
+	VerseKindVerse  = "verse"
+	VerseKindChorus = "chorus"
+	VerseKindBridge = "bridge"
+	VerseKindIntro  = "intro"
+	VerseKindOutro  = "outro"
+)
+
+// Verse — часть текста песни. Text хранит строки части с исходными переводами строк,
+// Start и End — границы части в тексте песни в символах (End не входит в часть).
+type Verse struct {
+	Position int    `json:"position"`
+	Kind     string `json:"kind"`
+	Text     string `json:"text"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// StructuredVerse — часть текста песни в структурированном ответе. Index — номер части в песне
+// начиная с нуля, Lines — строки части в исходном порядке, Start и End — границы части в тексте песни
+// в символах (End не входит в часть).
+type StructuredVerse struct {
+	Index int      `json:"index"`
+	Kind  string   `json:"kind"`
+	Lines []string `json:"lines"`
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+}
+
+// VersePage — страница частей текста песни. NextOffset равен nil, если страница последняя.
+type VersePage struct {
+	Verses     []*StructuredVerse `json:"verses"`
+	Total      int                `json:"total"`
+	NextOffset *int               `json:"next_offset"`
+}
@@ -0,0 +1,279 @@
+// Package fakeinfo — подделка внешнего API сведений о песнях (GET /info) для локальной разработки.
+// Сервер отвечает по набору фикстур и умеет имитировать задержки, ошибки сервера и ответы 404,
+// чтобы без доступа к сети проверять обработку отказов внешнего API.
+package fakeinfo
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// Fixture — ответ подделки для одной песни. Если задан Status, вместо сведений возвращается этот
+// код ответа; Latency добавляется к общей задержке сервера.
+type Fixture struct {
+	Group       string
+	Song        string
+	ReleaseDate string
+	Text        string
+	Link        string
+	Status      int
+	Latency     time.Duration
+}
+
+// fixtureFile — запись файла фикстур. Поля сведений названы так же, как в ответе API.
+type fixtureFile struct {
+	Group       string `json:"group" yaml:"group"`
+	Song        string `json:"song" yaml:"song"`
+	ReleaseDate string `json:"releaseDate" yaml:"releaseDate"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+	Status      int    `json:"status" yaml:"status"`
+	Latency     string `json:"latency" yaml:"latency"`
+}
+
+// infoResponse — тело успешного ответа, как у настоящего API.
+type infoResponse struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Config задает поведение подделки. Нулевое значение — ответы без задержек и сбоев.
+type Config struct {
+	// Latency — задержка каждого ответа, Jitter — наибольшая случайная добавка к ней.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate — доля запросов от 0 до 1, на которые отвечается 500.
+	ErrorRate float64
+	// NotFoundRate — доля запросов от 0 до 1, на которые отвечается 404 даже для известных песен.
+	NotFoundRate float64
+	// Seed делает последовательность сбоев воспроизводимой; ноль — случайная последовательность.
+	Seed uint64
+}
+
+// Validate проверяет, что доли сбоев лежат в диапазоне от 0 до 1, а задержки не отрицательны.
+func (c Config) Validate() error {
+	if c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return fmt.Errorf("error rate must be between 0 and 1, got %v", c.ErrorRate)
+	}
+	if c.NotFoundRate < 0 || c.NotFoundRate > 1 {
+		return fmt.Errorf("not found rate must be between 0 and 1, got %v", c.NotFoundRate)
+	}
+	if c.Latency < 0 || c.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	return nil
+}
+
+// LoadFixtures читает фикстуры из файла .json, .yaml или .yml; пустой путь означает встроенный набор.
+// Файл содержит список записей с полями group, song, releaseDate, text, link и необязательными
+// status и latency.
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, ext := defaultFixtures, ".json"
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read fixtures: %w", err)
+		}
+		ext = strings.ToLower(filepath.Ext(path))
+	}
+
+	var entries []fixtureFile
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("parse fixtures: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("parse fixtures: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixtures format %q", ext)
+	}
+
+	fixtures := make([]Fixture, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Group == "" || entry.Song == "" {
+			return nil, fmt.Errorf("fixture #%d: group and song are required", i+1)
+		}
+
+		fixture := Fixture{
+			Group:       entry.Group,
+			Song:        entry.Song,
+			ReleaseDate: entry.ReleaseDate,
+			Text:        entry.Text,
+			Link:        entry.Link,
+			Status:      entry.Status,
+		}
+		if entry.Latency != "" {
+			latency, err := time.ParseDuration(entry.Latency)
+			if err != nil {
+				return nil, fmt.Errorf("fixture #%d: latency: %w", i+1, err)
+			}
+			fixture.Latency = latency
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// Server отвечает на GET /info?group=&song= по фикстурам. Группа и песня сравниваются без учета регистра;
+// на неизвестную песню отвечается 404. Безопасен для одновременного использования.
+type Server struct {
+	fixtures map[string]Fixture
+	cfg      Config
+	log      *slog.Logger
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewServer(fixtures []Fixture, cfg Config, log *slog.Logger) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	byKey := make(map[string]Fixture, len(fixtures))
+	for _, fixture := range fixtures {
+		byKey[fixtureKey(fixture.Group, fixture.Song)] = fixture
+	}
+
+	return &Server{
+		fixtures: byKey,
+		cfg:      cfg,
+		log:      log,
+		rnd:      rand.New(rand.NewPCG(seed, seed)),
+	}, nil
+}
+
+func fixtureKey(group, song string) string {
+	return strings.ToLower(group) + "\x00" + strings.ToLower(song)
+}
+
+// ServeHTTP обслуживает только путь /info.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/info" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	group := r.URL.Query().Get("group")
+	song := r.URL.Query().Get("song")
+	if group == "" || song == "" {
+		http.Error(w, "group and song are required", http.StatusBadRequest)
+		return
+	}
+
+	fixture, known := s.fixtures[fixtureKey(group, song)]
+	delay, fail, notFound := s.roll()
+
+	log := s.log.With(slog.String("group", group), slog.String("song", song))
+
+	if !s.sleep(r, delay+fixture.Latency) {
+		log.Debug("Fake info request cancelled")
+		return
+	}
+
+	switch {
+	case fail:
+		log.Debug("Fake info injected failure")
+		http.Error(w, "injected failure", http.StatusInternalServerError)
+	case notFound || !known:
+		log.Debug("Fake info song not found", slog.Bool("injected", known))
+		http.NotFound(w, r)
+	case fixture.Status != 0 && fixture.Status != http.StatusOK:
+		log.Debug("Fake info fixture status", slog.Int("status", fixture.Status))
+		if fixture.Status == http.StatusTooManyRequests || fixture.Status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+		}
+		http.Error(w, http.StatusText(fixture.Status), fixture.Status)
+	default:
+		log.Debug("Fake info song served")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(infoResponse{
+			ReleaseDate: fixture.ReleaseDate,
+			Text:        fixture.Text,
+			Link:        fixture.Link,
+		})
+	}
+}
+
+// roll выбирает задержку и случайные сбои для одного запроса.
+func (s *Server) roll() (time.Duration, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delay := s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		delay += time.Duration(s.rnd.Int64N(int64(s.cfg.Jitter) + 1))
+	}
+
+	fail := s.rnd.Float64() < s.cfg.ErrorRate
+	notFound := s.rnd.Float64() < s.cfg.NotFoundRate
+
+	return delay, fail, notFound
+}
+
+// sleep ждет delay и возвращает false, если клиент отключился раньше.
+func (s *Server) sleep(r *http.Request, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Start запускает сервер в фоне на addr, например "127.0.0.1:0" для свободного порта,
+// и возвращает его базовый адрес для API_URL и функцию остановки.
+func (s *Server) Start(addr string) (string, func(context.Context) error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("listen: %w", err)
+	}
+
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("Fake info API stopped", slog.Any("error", err))
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), srv.Shutdown, nil
+}
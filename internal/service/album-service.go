@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+)
+
+type AlbumService struct {
+	Storage  storage.AlbumRepository
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+func NewAlbumService(storage storage.AlbumRepository, timeouts Timeouts, log *slog.Logger) *AlbumService {
+	return &AlbumService{Storage: storage, timeouts: timeouts, log: log}
+}
+
+func (s *AlbumService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+func (s *AlbumService) AddAlbum(ctx context.Context, album *models.Album, songIDs []int) (int, error) {
+	s.log.Info("Adding album",
+		slog.String("title", album.Title),
+		slog.Int("artist_id", album.ArtistID))
+
+	if err := checkTrackList(songIDs); err != nil {
+		return 0, err
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	id, err := s.Storage.AddAlbum(dbCtx, album, songIDs)
+	if err != nil {
+		s.log.Error("Failed to add album",
+			slog.String("title", album.Title),
+			slog.Any("error", err))
+		return 0, referenceError(err)
+	}
+	return id, nil
+}
+
+func (s *AlbumService) GetAlbum(ctx context.Context, id int) (*models.Album, error) {
+	s.log.Info("Getting album",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	album, err := s.Storage.GetAlbum(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to get album",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if album.Tracks == nil {
+		album.Tracks = []models.AlbumTrack{}
+	}
+	return album, nil
+}
+
+func (s *AlbumService) GetAlbums(ctx context.Context, artistID int, limit, offset int) ([]*models.Album, error) {
+	s.log.Info("Getting albums",
+		slog.Int("artist_id", artistID),
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	albums, err := s.Storage.GetAlbums(dbCtx, artistID, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get albums",
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if albums == nil {
+		albums = []*models.Album{}
+	}
+	return albums, nil
+}
+
+func (s *AlbumService) UpdateAlbum(ctx context.Context, id int, title *string, artistID *int, releaseDate, coverLink *string) error {
+	s.log.Info("Updating album",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.UpdateAlbum(dbCtx, id, title, artistID, releaseDate, coverLink)
+	if err != nil {
+		s.log.Error("Failed to update album",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return referenceError(err)
+	}
+	return nil
+}
+
+func (s *AlbumService) SetAlbumTracks(ctx context.Context, id int, songIDs []int) error {
+	s.log.Info("Setting album tracks",
+		slog.Int("id", id),
+		slog.Int("tracks", len(songIDs)))
+
+	if err := checkTrackList(songIDs); err != nil {
+		return err
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.SetAlbumTracks(dbCtx, id, songIDs)
+	if err != nil {
+		s.log.Error("Failed to set album tracks",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return referenceError(err)
+	}
+	return nil
+}
+
+func (s *AlbumService) DeleteAlbum(ctx context.Context, id int) error {
+	s.log.Info("Deleting album",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.DeleteAlbum(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to delete album",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// GetAlbumSongs возвращает песни альбома в порядке трек-листа вместе с куплетами.
+func (s *AlbumService) GetAlbumSongs(ctx context.Context, id int) ([]*models.AlbumSong, error) {
+	s.log.Info("Getting album songs",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songs, err := s.Storage.GetAlbumSongs(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to get album songs",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	for _, albumSong := range songs {
+		albumSong.Verses = storage.SplitVerses(albumSong.Song.Text)
+		if albumSong.Verses == nil {
+			albumSong.Verses = []string{}
+		}
+	}
+
+	if songs == nil {
+		songs = []*models.AlbumSong{}
+	}
+	return songs, nil
+}
+
+// checkTrackList проверяет, что песня встречается в трек-листе не более одного раза.
+func checkTrackList(songIDs []int) error {
+	seen := make(map[int]bool, len(songIDs))
+	for _, songID := range songIDs {
+		if seen[songID] {
+			return fmt.Errorf("%w: song %d appears in the track list more than once", ErrValidation, songID)
+		}
+		seen[songID] = true
+	}
+	return nil
+}
+
+// referenceError превращает ссылку на несуществующую песню или исполнителя в ошибку валидации:
+// отсутствует не сам альбом, а то, на что указывает тело запроса.
+func referenceError(err error) error {
+	if errors.Is(err, storage.ErrSongNotFound) || errors.Is(err, storage.ErrArtistNotFound) {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+)
+
+type ArtistService struct {
+	Storage  storage.ArtistRepository
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+func NewArtistService(storage storage.ArtistRepository, timeouts Timeouts, log *slog.Logger) *ArtistService {
+	return &ArtistService{Storage: storage, timeouts: timeouts, log: log}
+}
+
+func (s *ArtistService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+func (s *ArtistService) AddArtist(ctx context.Context, artist *models.Artist) (int, error) {
+	s.log.Info("Adding artist",
+		slog.String("name", artist.Name))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	id, err := s.Storage.AddArtist(dbCtx, artist)
+	if err != nil {
+		s.log.Error("Failed to add artist",
+			slog.String("name", artist.Name),
+			slog.Any("error", err))
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *ArtistService) GetArtist(ctx context.Context, id int) (*models.Artist, error) {
+	s.log.Info("Getting artist",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	artist, err := s.Storage.GetArtist(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to get artist",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return artist, nil
+}
+
+func (s *ArtistService) GetArtists(ctx context.Context, limit, offset int) ([]*models.Artist, error) {
+	s.log.Info("Getting artists",
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	artists, err := s.Storage.GetArtists(dbCtx, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get artists",
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if artists == nil {
+		artists = []*models.Artist{}
+	}
+	return artists, nil
+}
+
+func (s *ArtistService) UpdateArtist(ctx context.Context, id int, name, country *string, formedYear *int, description *string, links *[]string) error {
+	s.log.Info("Updating artist",
+		slog.Int("id", id),
+		slog.Any("name", name))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.UpdateArtist(dbCtx, id, name, country, formedYear, description, links)
+	if err != nil {
+		s.log.Error("Failed to update artist",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (s *ArtistService) DeleteArtist(ctx context.Context, id int) error {
+	s.log.Info("Deleting artist",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.DeleteArtist(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to delete artist",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (s *ArtistService) GetArtistSongs(ctx context.Context, id int, limit, offset int) ([]*models.Song, error) {
+	s.log.Info("Getting artist songs",
+		slog.Int("id", id),
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songs, err := s.Storage.GetArtistSongs(dbCtx, id, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get artist songs",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if songs == nil {
+		songs = []*models.Song{}
+	}
+	return songs, nil
+}
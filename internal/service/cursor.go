@@ -0,0 +1,34 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/storage"
+)
+
+// cursorPayload — содержимое непрозрачного курсора, который получает клиент.
+type cursorPayload struct {
+	SortKey string `json:"k"`
+	Value   string `json:"v,omitempty"`
+	ID      int    `json:"id"`
+}
+
+func encodeCursor(cursor storage.SongCursor) string {
+	data, _ := json.Marshal(cursorPayload{SortKey: cursor.SortKey, Value: cursor.Value, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (*storage.SongCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || !storage.IsSongSortKey(payload.SortKey) {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+
+	return &storage.SongCursor{SortKey: payload.SortKey, Value: payload.Value, ID: payload.ID}, nil
+}
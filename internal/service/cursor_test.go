@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []storage.SongCursor{
+		{SortKey: "id", ID: 42},
+		{SortKey: "group", Value: "Ляпис Трубецкой", ID: 7},
+		{SortKey: "song", Value: `"quoted", with / slashes & +plus`, ID: 1},
+		{SortKey: "release_date", Value: "2006-07-16", ID: 3},
+		{SortKey: "release_date", Value: "", ID: 9},
+	}
+
+	for _, want := range tests {
+		t.Run(want.SortKey+"/"+want.Value, func(t *testing.T) {
+			raw := encodeCursor(want)
+			if _, err := base64.RawURLEncoding.DecodeString(raw); err != nil {
+				t.Fatalf("cursor %q is not unpadded base64url: %v", raw, err)
+			}
+
+			got, err := decodeCursor(raw)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if *got != want {
+				t.Errorf("decodeCursor() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "not base64", raw: "not a cursor!"},
+		{name: "padded base64", raw: base64.URLEncoding.EncodeToString([]byte(`{"k":"id","id":1}`))},
+		{name: "not json", raw: encode("id:1")},
+		{name: "unknown sort key", raw: encode(`{"k":"text","v":"a","id":1}`)},
+		{name: "offset-only sort key", raw: encode(`{"k":"created_at","v":"a","id":1}`)},
+		{name: "missing sort key", raw: encode(`{"id":1}`)},
+		{name: "wrong id type", raw: encode(`{"k":"id","id":"1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.raw); !errors.Is(err, ErrValidation) {
+				t.Errorf("decodeCursor(%q) error = %v, want %v", tt.raw, err, ErrValidation)
+			}
+		})
+	}
+}
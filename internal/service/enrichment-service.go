@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"time"
+)
+
+const (
+	// enrichmentActor указывается автором правок, которые вносит фоновое обогащение.
+	enrichmentActor = "enrichment"
+	// enrichmentMaxBackoff ограничивает паузу между попытками обогащения одной песни.
+	enrichmentMaxBackoff = 30 * time.Minute
+	// enrichmentDefaultLease — на сколько откладывается взятая задача, если время запроса к API не ограничено.
+	enrichmentDefaultLease = 10 * time.Minute
+)
+
+// EnrichmentConfig задает работу фонового обогащения песен.
+type EnrichmentConfig struct {
+	// BatchSize — сколько задач обработчик берет из очереди за раз.
+	BatchSize int
+	// MaxAttempts — сколько попыток делается, прежде чем песня получит состояние enrichment_failed.
+	MaxAttempts int
+	// RetryBackoff — пауза перед второй попыткой; перед каждой следующей она удваивается.
+	RetryBackoff time.Duration
+}
+
+// EnrichmentService дополняет песни, добавленные без сведений, данными из источников сведений.
+// Песни попадают в очередь (outbox) в одной транзакции с созданием, поэтому задача не теряется,
+// даже если сервис остановится сразу после ответа клиенту.
+type EnrichmentService struct {
+	Storage  storage.EnrichmentRepository
+	songs    *SongService
+	cfg      EnrichmentConfig
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+func NewEnrichmentService(storage storage.EnrichmentRepository, songs *SongService, cfg EnrichmentConfig, timeouts Timeouts, log *slog.Logger) *EnrichmentService {
+	return &EnrichmentService{Storage: storage, songs: songs, cfg: cfg, timeouts: timeouts, log: log}
+}
+
+func (s *EnrichmentService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+// RunWorker разбирает очередь обогащения каждые interval, пока не будет отменен ctx.
+func (s *EnrichmentService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Полная порция означает, что в очереди могут остаться задачи: следующую берем сразу
+		for ctx.Err() == nil {
+			if s.ProcessBatch(ctx) < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch обрабатывает одну порцию задач, чей срок наступил, и возвращает их количество.
+func (s *EnrichmentService) ProcessBatch(ctx context.Context) int {
+	dbCtx, cancel := s.dbContext(ctx)
+	tasks, err := s.Storage.ClaimEnrichmentTasks(dbCtx, s.cfg.BatchSize, s.lease())
+	cancel()
+	if err != nil {
+		s.log.Error("Failed to claim enrichment tasks",
+			slog.Any("error", err))
+		return 0
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			// Невыполненные задачи снова станут доступны, когда истечет срок, на который их взяли
+			break
+		}
+		s.process(ctx, task)
+	}
+
+	return len(tasks)
+}
+
+func (s *EnrichmentService) process(ctx context.Context, task *models.EnrichmentTask) {
+	ctx = storage.WithActor(ctx, enrichmentActor)
+
+	log := s.log.With(
+		slog.Int("song_id", task.SongID),
+		slog.String("group", task.Group),
+		slog.String("song", task.Song),
+		slog.Int("attempt", task.Attempts))
+
+	detail, fetchErr := s.songs.FetchSongDetail(ctx, task.Group, task.Song)
+	if fetchErr != nil && ctx.Err() != nil {
+		return
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	switch {
+	case fetchErr == nil:
+		if err := s.Storage.CompleteEnrichment(dbCtx, task.ID, *detail); err != nil {
+			log.Error("Failed to save enriched song",
+				slog.Any("error", err))
+			return
+		}
+		log.Info("Song enriched")
+
+	// Неизвестную API песню повторный запрос не найдет
+	case errors.Is(fetchErr, ErrSongInfoNotFound) || task.Attempts >= s.cfg.MaxAttempts:
+		if err := s.Storage.FailEnrichment(dbCtx, task.ID); err != nil {
+			log.Error("Failed to mark song enrichment as failed",
+				slog.Any("error", err))
+			return
+		}
+		log.Warn("Song enrichment failed",
+			slog.Any("error", fetchErr))
+
+	default:
+		next := time.Now().Add(s.backoff(task.Attempts))
+		if err := s.Storage.RetryEnrichment(dbCtx, task.ID, next, fetchErr.Error()); err != nil {
+			log.Error("Failed to reschedule song enrichment",
+				slog.Any("error", err))
+			return
+		}
+		log.Warn("Song enrichment will be retried",
+			slog.Time("next_attempt_at", next),
+			slog.Any("error", fetchErr))
+	}
+}
+
+// backoff возвращает паузу после неудачной попытки с номером attempt.
+func (s *EnrichmentService) backoff(attempt int) time.Duration {
+	wait := s.cfg.RetryBackoff
+	for i := 1; i < attempt && wait < enrichmentMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, enrichmentMaxBackoff)
+}
+
+// lease возвращает срок, на который задачи порции откладываются для других обработчиков:
+// его должно хватить на запросы к API по всем задачам порции.
+func (s *EnrichmentService) lease() time.Duration {
+	if s.timeouts.API <= 0 {
+		return enrichmentDefaultLease
+	}
+	return time.Duration(s.cfg.BatchSize)*s.timeouts.API + time.Minute
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestEnrichmentService(t *testing.T, info *stubInfo, cfg EnrichmentConfig) (*EnrichmentService, *SongService, *memory.Storage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	songs := NewSongService(store, info, Timeouts{}, log)
+	return NewEnrichmentService(store, songs, cfg, Timeouts{}, log), songs, store
+}
+
+func TestEnrichmentBackoff(t *testing.T) {
+	s := &EnrichmentService{cfg: EnrichmentConfig{RetryBackoff: time.Minute}}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Minute},
+		{attempt: 2, want: 2 * time.Minute},
+		{attempt: 3, want: 4 * time.Minute},
+		{attempt: 5, want: 16 * time.Minute},
+		{attempt: 6, want: enrichmentMaxBackoff},
+		{attempt: 100, want: enrichmentMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichmentLease(t *testing.T) {
+	tests := []struct {
+		name       string
+		batchSize  int
+		apiTimeout time.Duration
+		want       time.Duration
+	}{
+		{name: "unlimited api", batchSize: 10, want: enrichmentDefaultLease},
+		{name: "whole batch and a margin", batchSize: 10, apiTimeout: 5 * time.Second, want: 50*time.Second + time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &EnrichmentService{cfg: EnrichmentConfig{BatchSize: tt.batchSize}, timeouts: Timeouts{API: tt.apiTimeout}}
+			if got := s.lease(); got != tt.want {
+				t.Errorf("lease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichmentProcessBatch(t *testing.T) {
+	ctx := context.Background()
+	detail := models.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/1"}
+
+	tests := []struct {
+		name       string
+		details    map[string]models.SongDetail
+		err        error
+		wantStatus string
+		wantText   string
+	}{
+		{
+			name:       "enriched",
+			details:    map[string]models.SongDetail{"Muse/Supermassive": detail},
+			wantStatus: models.EnrichmentEnriched,
+			wantText:   "Ooh baby",
+		},
+		{name: "unknown song fails at once", wantStatus: models.EnrichmentFailed},
+		{name: "unavailable api is retried", err: songinfo.ErrUnavailable, wantStatus: models.EnrichmentPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &stubInfo{details: tt.details, err: tt.err}
+			s, songs, store := newTestEnrichmentService(t, info, EnrichmentConfig{BatchSize: 10, MaxAttempts: 3, RetryBackoff: time.Hour})
+
+			id, err := songs.AddSongAsync(ctx, "Muse", "Supermassive")
+			if err != nil {
+				t.Fatalf("AddSongAsync() error = %v", err)
+			}
+
+			if n := s.ProcessBatch(ctx); n != 1 {
+				t.Fatalf("ProcessBatch() = %d, want 1", n)
+			}
+
+			song, err := store.GetSong(ctx, id)
+			if err != nil {
+				t.Fatalf("GetSong() error = %v", err)
+			}
+			if song.EnrichmentStatus != tt.wantStatus || song.Text != tt.wantText {
+				t.Errorf("song = %s / %q, want %s / %q", song.EnrichmentStatus, song.Text, tt.wantStatus, tt.wantText)
+			}
+
+			// Завершенная задача удалена, а отложенная не берется до срока следующей попытки
+			if n := s.ProcessBatch(ctx); n != 0 {
+				t.Errorf("second ProcessBatch() = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestEnrichmentRetriesUntilMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	info := &stubInfo{err: songinfo.ErrUnavailable}
+	s, songs, store := newTestEnrichmentService(t, info, EnrichmentConfig{BatchSize: 10, MaxAttempts: 3, RetryBackoff: time.Millisecond})
+
+	id, err := songs.AddSongAsync(ctx, "Muse", "Supermassive")
+	if err != nil {
+		t.Fatalf("AddSongAsync() error = %v", err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		// Пауза перед попыткой attempt не превышает 2^(attempt-2) мс
+		time.Sleep(5 * time.Millisecond)
+		if n := s.ProcessBatch(ctx); n != 1 {
+			t.Fatalf("attempt %d: ProcessBatch() = %d, want 1", attempt, n)
+		}
+	}
+
+	song, err := store.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.EnrichmentStatus != models.EnrichmentFailed {
+		t.Errorf("status after %d attempts = %s, want %s", 3, song.EnrichmentStatus, models.EnrichmentFailed)
+	}
+	if info.calls != 3 {
+		t.Errorf("provider called %d times, want 3", info.calls)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n := s.ProcessBatch(ctx); n != 0 {
+		t.Errorf("ProcessBatch() after the last attempt = %d, want 0", n)
+	}
+}
+
+func TestEnrichmentKeepsManualEdits(t *testing.T) {
+	ctx := context.Background()
+	info := &stubInfo{details: map[string]models.SongDetail{
+		"Muse/Supermassive": {ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/1"},
+	}}
+	s, songs, store := newTestEnrichmentService(t, info, EnrichmentConfig{BatchSize: 10, MaxAttempts: 3, RetryBackoff: time.Hour})
+
+	id, err := songs.AddSongAsync(ctx, "Muse", "Supermassive")
+	if err != nil {
+		t.Fatalf("AddSongAsync() error = %v", err)
+	}
+	text := "edited by hand"
+	if err := store.UpdateSong(ctx, id, 0, nil, nil, nil, &text, nil); err != nil {
+		t.Fatalf("UpdateSong() error = %v", err)
+	}
+
+	s.ProcessBatch(ctx)
+
+	song, err := store.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.Text != text || song.ReleaseDate != "16.07.2006" || song.Link != "https://example.com/1" {
+		t.Errorf("song = %+v, want the manual text and the fetched date and link", song)
+	}
+
+	revisions, err := store.GetSongRevisions(ctx, id, 1, 0)
+	if err != nil || len(revisions) != 1 || revisions[0].Actor != enrichmentActor {
+		t.Errorf("latest revision = %+v, %v, want one by %s", revisions, err, enrichmentActor)
+	}
+}
+
+func TestEnrichmentLeaseHidesClaimedTasks(t *testing.T) {
+	ctx := context.Background()
+	_, songs, store := newTestEnrichmentService(t, &stubInfo{}, EnrichmentConfig{})
+
+	for _, name := range []string{"Hysteria", "Starlight", "Uprising"} {
+		if _, err := songs.AddSongAsync(ctx, "Muse", name); err != nil {
+			t.Fatalf("AddSongAsync() error = %v", err)
+		}
+	}
+
+	first, err := store.ClaimEnrichmentTasks(ctx, 2, time.Hour)
+	if err != nil || len(first) != 2 || first[0].Song != "Hysteria" || first[0].Attempts != 1 {
+		t.Fatalf("ClaimEnrichmentTasks() = %+v, %v, want the two oldest tasks at attempt 1", first, err)
+	}
+
+	// Второй обработчик получает только задачу, которую не взял первый
+	second, err := store.ClaimEnrichmentTasks(ctx, 10, time.Millisecond)
+	if err != nil || len(second) != 1 || second[0].Song != "Uprising" {
+		t.Fatalf("ClaimEnrichmentTasks() = %+v, %v, want only Uprising", second, err)
+	}
+
+	// После истечения срока задача снова доступна, и попытка засчитывается еще раз
+	time.Sleep(5 * time.Millisecond)
+	third, err := store.ClaimEnrichmentTasks(ctx, 10, time.Hour)
+	if err != nil || len(third) != 1 || third[0].Song != "Uprising" || third[0].Attempts != 2 {
+		t.Errorf("ClaimEnrichmentTasks() after the lease = %+v, %v, want Uprising at attempt 2", third, err)
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage"
+)
+
+var (
+	// ErrValidation означает, что входные данные не прошли проверку.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrSongInfoNotFound означает, что внешний API не знает запрошенную песню.
+	ErrSongInfoNotFound = fmt.Errorf("song info %w", storage.ErrNotFound)
+
+	// ErrUpstreamUnavailable означает, что внешний API недоступен или вернул ошибку сервера.
+	ErrUpstreamUnavailable = songinfo.ErrUnavailable
+
+	// ErrUpstreamCircuitOpen означает, что запрос к внешнему API не отправлялся:
+	// после череды отказов он считается недоступным до окончания паузы.
+	ErrUpstreamCircuitOpen = songinfo.ErrCircuitOpen
+
+	// ErrUpstreamInvalidResponse означает, что ответ внешнего API не удалось разобрать.
+	ErrUpstreamInvalidResponse = songinfo.ErrInvalidResponse
+
+	// ErrUpstreamTimeout означает, что внешний API не ответил вовремя.
+	ErrUpstreamTimeout = songinfo.ErrTimeout
+
+	// ErrPatchConflict означает, что изменение JSON Patch нельзя применить к текущему состоянию песни:
+	// нет значения по указанному пути или не выполнена операция test.
+	ErrPatchConflict = errors.New("patch cannot be applied")
+
+	// ErrVersionRequired означает, что изменение песни требует ее версию, а клиент ее не передал.
+	ErrVersionRequired = errors.New("song version is required")
+
+	// ErrImportJobNotFound означает, что задачи импорта с таким id нет или она уже удалена.
+	ErrImportJobNotFound = fmt.Errorf("import job %w", storage.ErrNotFound)
+)
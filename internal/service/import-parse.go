@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Поддерживаемые форматы файлов импорта.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// ImportRow — песня из файла импорта. Если текст не указан, недостающие поля
+// запрашиваются во внешнем API, как при добавлении одной песни.
+type ImportRow struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+
+	// parseErr содержит причину, по которой строку не удалось разобрать.
+	parseErr error
+}
+
+// importColumns перечисляет колонки CSV. Обязательны только group и song.
+var importColumns = []string{"group", "song", "release_date", "text", "link"}
+
+// ParseImport разбирает файл импорта целиком. Ошибки отдельных строк не прерывают разбор
+// и попадают в итоги задачи; ошибкой считается только файл, который нельзя прочитать как формат.
+func ParseImport(r io.Reader, format string) ([]ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(r)
+	case ImportFormatNDJSON:
+		return parseImportNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported import format %q", ErrValidation, format)
+	}
+}
+
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: import file is empty", ErrValidation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid CSV header: %v", ErrValidation, err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for _, column := range importColumns {
+			if name == column {
+				index[column] = i
+			}
+		}
+	}
+	if _, ok := index["group"]; !ok {
+		return nil, fmt.Errorf("%w: CSV header must contain group and song columns", ErrValidation)
+	}
+	if _, ok := index["song"]; !ok {
+		return nil, fmt.Errorf("%w: CSV header must contain group and song columns", ErrValidation)
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, ImportRow{parseErr: fmt.Errorf("invalid CSV row: %v", parseErr.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+
+		rows = append(rows, ImportRow{
+			Group:       field(record, "group"),
+			Song:        field(record, "song"),
+			ReleaseDate: field(record, "release_date"),
+			Text:        field(record, "text"),
+			Link:        field(record, "link"),
+		})
+	}
+
+	return rows, nil
+}
+
+// maxNDJSONLine ограничивает длину строки NDJSON: текст песни может быть длинным.
+const maxNDJSONLine = 1 << 20
+
+func parseImportNDJSON(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	var rows []ImportRow
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var row ImportRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			rows = append(rows, ImportRow{parseErr: fmt.Errorf("invalid JSON: %v", err)})
+			continue
+		}
+
+		row.Group = strings.TrimSpace(row.Group)
+		row.Song = strings.TrimSpace(row.Song)
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: NDJSON line exceeds %d bytes", ErrValidation, maxNDJSONLine)
+		}
+		return nil, fmt.Errorf("read NDJSON: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: import file is empty", ErrValidation)
+	}
+
+	return rows, nil
+}
+
+// validate проверяет строку так же, как проверяется запрос на добавление песни.
+func (r *ImportRow) validate() error {
+	if r.parseErr != nil {
+		return r.parseErr
+	}
+	if r.Group == "" || r.Song == "" {
+		return errors.New("group and song are required")
+	}
+	if utf8.RuneCountInString(r.Group) > 255 || utf8.RuneCountInString(r.Song) > 255 {
+		return errors.New("group and song must be at most 255 characters")
+	}
+	if r.ReleaseDate != "" {
+		if _, err := time.Parse(time.DateOnly, r.ReleaseDate); err != nil {
+			return errors.New("release_date must be in YYYY-MM-DD format")
+		}
+	}
+	return nil
+}
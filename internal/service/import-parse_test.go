@@ -0,0 +1,29 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportRowValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     ImportRow
+		wantErr bool
+	}{
+		{name: "valid", row: ImportRow{Group: "Muse", Song: "Hysteria", ReleaseDate: "2003-12-01"}},
+		{name: "255 cyrillic characters", row: ImportRow{Group: strings.Repeat("я", 255), Song: strings.Repeat("ё", 255)}},
+		{name: "256 characters in group", row: ImportRow{Group: strings.Repeat("я", 256), Song: "Hysteria"}, wantErr: true},
+		{name: "256 characters in song", row: ImportRow{Group: "Muse", Song: strings.Repeat("a", 256)}, wantErr: true},
+		{name: "missing song", row: ImportRow{Group: "Muse"}, wantErr: true},
+		{name: "date in source format", row: ImportRow{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.row.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// importJobRetention — сколько хранится завершенная задача импорта. Задачи живут в памяти
+// процесса и не переживают перезапуск.
+const importJobRetention = 24 * time.Hour
+
+// ImportService выполняет массовый импорт песен фоновыми задачами.
+// Строки обрабатываются параллельно несколькими воркерами.
+type ImportService struct {
+	songs   *SongService
+	workers int
+	log     *slog.Logger
+
+	mu   sync.RWMutex
+	jobs map[string]*models.ImportJob
+}
+
+func NewImportService(songs *SongService, workers int, log *slog.Logger) *ImportService {
+	if workers < 1 {
+		workers = 1
+	}
+	return &ImportService{songs: songs, workers: workers, log: log, jobs: make(map[string]*models.ImportJob)}
+}
+
+// StartImport регистрирует задачу и запускает ее в фоне. Задача не зависит от отмены ctx,
+// но сохраняет его значения, например автора изменений.
+func (s *ImportService) StartImport(ctx context.Context, format string, rows []ImportRow) (*models.ImportJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("generate job id: %w", err)
+	}
+
+	job := &models.ImportJob{
+		ID:        id,
+		Format:    format,
+		Status:    models.ImportQueued,
+		Total:     len(rows),
+		CreatedAt: time.Now().UTC(),
+		Results:   make([]models.ImportRowResult, len(rows)),
+	}
+	for i, row := range rows {
+		job.Results[i] = models.ImportRowResult{
+			Row:    i + 1,
+			Group:  row.Group,
+			Song:   row.Song,
+			Status: models.ImportRowPending,
+		}
+	}
+
+	s.mu.Lock()
+	s.pruneLocked(job.CreatedAt)
+	s.jobs[id] = job
+	summary := copyImportJob(job, "", 0, 0)
+	s.mu.Unlock()
+
+	s.log.Info("Import job started",
+		slog.String("job_id", id),
+		slog.String("format", format),
+		slog.Int("rows", len(rows)))
+
+	go s.run(context.WithoutCancel(ctx), job, rows)
+
+	return summary, nil
+}
+
+// GetImportJob возвращает состояние задачи и страницу итогов по строкам.
+// Пустой status возвращает строки с любым итогом.
+func (s *ImportService) GetImportJob(ctx context.Context, id, status string, limit, offset int) (*models.ImportJob, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrImportJobNotFound
+	}
+
+	return copyImportJob(job, status, limit, offset), nil
+}
+
+func (s *ImportService) run(ctx context.Context, job *models.ImportJob, rows []ImportRow) {
+	s.mu.Lock()
+	startedAt := time.Now().UTC()
+	job.Status = models.ImportRunning
+	job.StartedAt = &startedAt
+	s.mu.Unlock()
+
+	// Повтор песни внутри файла пропускается сразу: параллельные воркеры иначе могли бы
+	// добавить ее дважды
+	seen := make(map[[2]string]int, len(rows))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < s.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				status, songID, reason := s.importRow(ctx, &rows[i])
+				s.finishRow(job, i, status, songID, reason)
+			}
+		}()
+	}
+
+	for i, row := range rows {
+		if row.validate() == nil {
+			key := [2]string{row.Group, row.Song}
+			if first, ok := seen[key]; ok {
+				s.finishRow(job, i, models.ImportRowSkipped, 0, fmt.Sprintf("duplicate of row %d", first+1))
+				continue
+			}
+			seen[key] = i
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	s.mu.Lock()
+	finishedAt := time.Now().UTC()
+	job.Status = models.ImportCompleted
+	job.FinishedAt = &finishedAt
+	created, skipped, failed := job.Created, job.Skipped, job.Failed
+	s.mu.Unlock()
+
+	s.log.Info("Import job completed",
+		slog.String("job_id", job.ID),
+		slog.Int("created", created),
+		slog.Int("skipped", skipped),
+		slog.Int("failed", failed))
+}
+
+// importRow добавляет песню из строки. Строка без текста дополняется данными источников сведений;
+// дата выпуска и ссылка из файла имеют приоритет над ними. Источником полей из файла считается import.
+func (s *ImportService) importRow(ctx context.Context, row *ImportRow) (string, int, string) {
+	if err := row.validate(); err != nil {
+		return models.ImportRowFailed, 0, err.Error()
+	}
+
+	detail := &models.SongDetail{
+		ReleaseDate: row.ReleaseDate,
+		Text:        row.Text,
+		Link:        row.Link,
+		Sources: map[string]string{
+			models.FieldReleaseDate: models.SourceImport,
+			models.FieldText:        models.SourceImport,
+			models.FieldLink:        models.SourceImport,
+		},
+	}
+	if row.Text == "" {
+		fetched, err := s.songs.FetchSongDetail(ctx, row.Group, row.Song)
+		if err != nil {
+			return models.ImportRowFailed, 0, err.Error()
+		}
+		detail.Text = fetched.Text
+		detail.Sources[models.FieldText] = fetched.Sources[models.FieldText]
+		if detail.ReleaseDate == "" {
+			detail.ReleaseDate = fetched.ReleaseDate
+			detail.Sources[models.FieldReleaseDate] = fetched.Sources[models.FieldReleaseDate]
+		}
+		if detail.Link == "" {
+			detail.Link = fetched.Link
+			detail.Sources[models.FieldLink] = fetched.Sources[models.FieldLink]
+		}
+	}
+
+	songID, err := s.songs.AddSongWithDetail(ctx, row.Group, row.Song, detail)
+	if errors.Is(err, storage.ErrSongExists) {
+		return models.ImportRowSkipped, 0, "song already exists"
+	}
+	if err != nil {
+		return models.ImportRowFailed, 0, err.Error()
+	}
+
+	return models.ImportRowCreated, songID, ""
+}
+
+func (s *ImportService) finishRow(job *models.ImportJob, i int, status string, songID int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &job.Results[i]
+	result.Status = status
+	result.SongID = songID
+	result.Reason = reason
+
+	job.Processed++
+	switch status {
+	case models.ImportRowCreated:
+		job.Created++
+	case models.ImportRowSkipped:
+		job.Skipped++
+	case models.ImportRowFailed:
+		job.Failed++
+	}
+}
+
+// pruneLocked удаляет давно завершенные задачи. Вызывающий должен держать блокировку на запись.
+func (s *ImportService) pruneLocked(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > importJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// copyImportJob копирует задачу вместе со страницей итогов, отфильтрованных по status.
+// При limit = 0 итоги не копируются.
+func copyImportJob(job *models.ImportJob, status string, limit, offset int) *models.ImportJob {
+	jobCopy := *job
+	jobCopy.Results = nil
+
+	if limit > 0 {
+		jobCopy.Results = []models.ImportRowResult{}
+		skipped := 0
+		for _, result := range job.Results {
+			if status != "" && result.Status != status {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			jobCopy.Results = append(jobCopy.Results, result)
+			if len(jobCopy.Results) == limit {
+				break
+			}
+		}
+	}
+
+	return &jobCopy
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
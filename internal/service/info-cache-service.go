@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"log/slog"
+)
+
+// InfoCacheService управляет кэшем ответов источников сведений о песнях. Если кэш отключен,
+// cache равен nil и удалять нечего.
+type InfoCacheService struct {
+	cache    *songinfo.CachedProvider
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+func NewInfoCacheService(cache *songinfo.CachedProvider, timeouts Timeouts, log *slog.Logger) *InfoCacheService {
+	return &InfoCacheService{cache: cache, timeouts: timeouts, log: log}
+}
+
+func (s *InfoCacheService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+// Invalidate удаляет из кэша ответ для песни и возвращает число удаленных записей.
+func (s *InfoCacheService) Invalidate(ctx context.Context, group, song string) (int, error) {
+	s.log.Info("Invalidating song info cache",
+		slog.String("group", group),
+		slog.String("song", song))
+
+	if s.cache == nil {
+		return 0, nil
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	removed, err := s.cache.Invalidate(dbCtx, group, song)
+	if err != nil {
+		s.log.Error("Failed to invalidate song info cache",
+			slog.String("group", group),
+			slog.String("song", song),
+			slog.Any("error", err))
+		return 0, err
+	}
+
+	if removed {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Clear удаляет из кэша все ответы и возвращает их количество.
+func (s *InfoCacheService) Clear(ctx context.Context) (int, error) {
+	s.log.Info("Clearing song info cache")
+
+	if s.cache == nil {
+		return 0, nil
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	cleared, err := s.cache.Clear(dbCtx)
+	if err != nil {
+		s.log.Error("Failed to clear song info cache",
+			slog.Any("error", err))
+		return 0, err
+	}
+	return cleared, nil
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"time"
+)
+
+const (
+	// refreshActor указывается автором правок, которые вносит обновление сведений.
+	refreshActor = "refresh"
+	// refreshDefaultLease — на сколько откладывается взятая песня, если время запроса к API не ограничено.
+	refreshDefaultLease = 10 * time.Minute
+)
+
+// RefreshConfig задает работу фонового обновления сведений о песнях.
+type RefreshConfig struct {
+	// BatchSize — сколько песен обработчик берет за раз.
+	BatchSize int
+	// MaxAge — через сколько после последней сверки песня сверяется снова.
+	MaxAge time.Duration
+	// Policy — models.RefreshPolicyAuto или models.RefreshPolicyReview.
+	Policy string
+}
+
+// RefreshService периодически сверяет сведения песен с источниками. Расхождения по политике auto
+// применяются сразу, по политике review ждут решения редактора; поля, исправленные редактором вручную,
+// при любой политике только предлагаются.
+type RefreshService struct {
+	Storage  storage.RefreshRepository
+	songs    *SongService
+	cfg      RefreshConfig
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+func NewRefreshService(storage storage.RefreshRepository, songs *SongService, cfg RefreshConfig, timeouts Timeouts, log *slog.Logger) *RefreshService {
+	return &RefreshService{Storage: storage, songs: songs, cfg: cfg, timeouts: timeouts, log: log}
+}
+
+func (s *RefreshService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+// RunWorker сверяет песни, которым пора обновиться, каждые interval, пока не будет отменен ctx.
+func (s *RefreshService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Полная порция означает, что сверки могут ждать и другие песни: следующую берем сразу
+		for ctx.Err() == nil {
+			if s.ProcessBatch(ctx) < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch сверяет одну порцию песен и возвращает их количество.
+func (s *RefreshService) ProcessBatch(ctx context.Context) int {
+	dbCtx, cancel := s.dbContext(ctx)
+	songs, err := s.Storage.ClaimRefreshCandidates(dbCtx, s.cfg.MaxAge, s.lease(), s.cfg.BatchSize)
+	cancel()
+	if err != nil {
+		s.log.Error("Failed to claim songs for refresh",
+			slog.Any("error", err))
+		return 0
+	}
+
+	for _, song := range songs {
+		if ctx.Err() != nil {
+			// Несверенные песни снова станут доступны, когда истечет срок, на который их взяли
+			break
+		}
+
+		_, err := s.refresh(ctx, song)
+		switch {
+		case err == nil:
+		// Неизвестную источникам песню нет смысла запрашивать до следующего срока
+		case errors.Is(err, ErrSongInfoNotFound):
+			dbCtx, cancel := s.dbContext(ctx)
+			_, err = s.Storage.CompleteRefresh(dbCtx, song.ID, nil, nil)
+			cancel()
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				s.log.Error("Failed to save song refresh",
+					slog.Int("song_id", song.ID),
+					slog.Any("error", err))
+			}
+		default:
+			s.log.Warn("Song refresh will be retried",
+				slog.Int("song_id", song.ID),
+				slog.Any("error", err))
+		}
+	}
+
+	return len(songs)
+}
+
+// RefreshSong сразу сверяет песню с источниками и возвращает примененные и предложенные изменения.
+func (s *RefreshService) RefreshSong(ctx context.Context, id int) (*models.RefreshResult, error) {
+	s.log.Info("Refreshing song",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	song, err := s.songs.Storage.GetSong(dbCtx, id)
+	cancel()
+	if err != nil {
+		s.log.Error("Failed to get song for refresh",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	result, err := s.refresh(ctx, song)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Applied == nil {
+		result.Applied = []models.FieldChange{}
+	}
+	if result.Proposed == nil {
+		result.Proposed = []models.FieldChange{}
+	}
+	return result, nil
+}
+
+// ScheduleRefresh помечает песню устаревшей, чтобы фоновое обновление сверило ее при ближайшем проходе.
+func (s *RefreshService) ScheduleRefresh(ctx context.Context, id int) error {
+	s.log.Info("Scheduling song refresh",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	if err := s.Storage.MarkSongStale(dbCtx, id); err != nil {
+		s.log.Error("Failed to schedule song refresh",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (s *RefreshService) refresh(ctx context.Context, song *models.Song) (*models.RefreshResult, error) {
+	ctx = storage.WithActor(ctx, refreshActor)
+
+	log := s.log.With(
+		slog.Int("song_id", song.ID),
+		slog.String("group", song.Group),
+		slog.String("song", song.Song))
+
+	// Сверка нужна с актуальными сведениями, поэтому кэш не читается, а обновляется
+	detail, err := s.songs.FetchSongDetail(songinfo.WithFreshLookup(ctx), song.Group, song.Song)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, proposed := s.diff(song, detail)
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	result, err := s.Storage.CompleteRefresh(dbCtx, song.ID, applied, proposed)
+	if err != nil {
+		log.Error("Failed to save song refresh",
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	log.Info("Song refreshed",
+		slog.Int("applied", len(result.Applied)),
+		slog.Int("proposed", len(result.Proposed)))
+
+	return result, nil
+}
+
+// diff сравнивает песню со сведениями источников. Пустые значения источников не считаются изменением.
+// Изменения полей, исправленных вручную, и все изменения по политике review только предлагаются.
+func (s *RefreshService) diff(song *models.Song, detail *models.SongDetail) ([]models.FieldChange, []models.FieldChange) {
+	var applied, proposed []models.FieldChange
+
+	for _, field := range []struct{ name, current, upstream string }{
+		{models.FieldReleaseDate, song.ReleaseDate, detail.ReleaseDate},
+		{models.FieldText, song.Text, detail.Text},
+		{models.FieldLink, song.Link, detail.Link},
+	} {
+		if field.upstream == "" || field.upstream == field.current {
+			continue
+		}
+
+		change := models.FieldChange{
+			Field:    field.name,
+			Current:  field.current,
+			Proposed: field.upstream,
+			Source:   detail.Sources[field.name],
+		}
+
+		if s.cfg.Policy == models.RefreshPolicyAuto && song.Sources[field.name] != models.SourceManual {
+			applied = append(applied, change)
+		} else {
+			proposed = append(proposed, change)
+		}
+	}
+
+	return applied, proposed
+}
+
+func (s *RefreshService) GetProposals(ctx context.Context, limit, offset int) ([]*models.RefreshProposal, error) {
+	s.log.Info("Getting refresh proposals",
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	proposals, err := s.Storage.GetRefreshProposals(dbCtx, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get refresh proposals",
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if proposals == nil {
+		proposals = []*models.RefreshProposal{}
+	}
+	return proposals, nil
+}
+
+func (s *RefreshService) AcceptProposal(ctx context.Context, id int) (*models.Song, error) {
+	s.log.Info("Accepting refresh proposal",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	song, err := s.Storage.AcceptRefreshProposal(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to accept refresh proposal",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return song, nil
+}
+
+func (s *RefreshService) RejectProposal(ctx context.Context, id int) error {
+	s.log.Info("Rejecting refresh proposal",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	if err := s.Storage.RejectRefreshProposal(dbCtx, id); err != nil {
+		s.log.Error("Failed to reject refresh proposal",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// lease возвращает срок, на который песни порции откладываются для других обработчиков:
+// его должно хватить на запросы к источникам по всем песням порции.
+func (s *RefreshService) lease() time.Duration {
+	if s.timeouts.API <= 0 {
+		return refreshDefaultLease
+	}
+	return time.Duration(s.cfg.BatchSize)*s.timeouts.API + time.Minute
+}
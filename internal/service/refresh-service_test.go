@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// upstreamHysteria — сведения источника "stub", расходящиеся с песней из addHysteria во всех полях.
+var upstreamHysteria = models.SongDetail{
+	ReleaseDate: "15.12.2003",
+	Text:        "It's bugging me",
+	Link:        "https://example.com/hysteria",
+	Sources:     map[string]string{models.FieldReleaseDate: "stub", models.FieldText: "stub", models.FieldLink: "stub"},
+}
+
+func newTestRefreshService(t *testing.T, policy string) (*RefreshService, *memory.Storage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	info := &stubInfo{details: map[string]models.SongDetail{"Muse/Hysteria": upstreamHysteria}}
+	songs := NewSongService(store, info, Timeouts{}, log)
+	return NewRefreshService(store, songs, RefreshConfig{BatchSize: 10, MaxAge: time.Hour, Policy: policy}, Timeouts{}, log), store
+}
+
+// addHysteria добавляет песню со сведениями источника "stub" и исправленным вручную текстом.
+func addHysteria(t *testing.T, store *memory.Storage) int {
+	t.Helper()
+	ctx := context.Background()
+
+	id, err := store.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{
+		ReleaseDate: "01.01.2003",
+		Text:        "old text",
+		Link:        "https://example.com/old",
+		Sources:     map[string]string{models.FieldReleaseDate: "stub", models.FieldText: "stub", models.FieldLink: "stub"},
+	})
+	if err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	text := "edited by hand"
+	if err := store.UpdateSong(ctx, id, 0, nil, nil, nil, &text, nil); err != nil {
+		t.Fatalf("UpdateSong() error = %v", err)
+	}
+	return id
+}
+
+func changedFields(changes []models.FieldChange) []string {
+	fields := []string{}
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	return fields
+}
+
+func TestRefreshSong(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		policy       string
+		wantApplied  []string
+		wantProposed []string
+	}{
+		{
+			name:         "auto applies all but manual fields",
+			policy:       models.RefreshPolicyAuto,
+			wantApplied:  []string{models.FieldReleaseDate, models.FieldLink},
+			wantProposed: []string{models.FieldText},
+		},
+		{
+			name:         "review proposes everything",
+			policy:       models.RefreshPolicyReview,
+			wantApplied:  []string{},
+			wantProposed: []string{models.FieldReleaseDate, models.FieldText, models.FieldLink},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestRefreshService(t, tt.policy)
+			id := addHysteria(t, store)
+
+			result, err := s.RefreshSong(ctx, id)
+			if err != nil {
+				t.Fatalf("RefreshSong() error = %v", err)
+			}
+			if got := changedFields(result.Applied); !reflect.DeepEqual(got, tt.wantApplied) {
+				t.Errorf("applied = %v, want %v", got, tt.wantApplied)
+			}
+			if got := changedFields(result.Proposed); !reflect.DeepEqual(got, tt.wantProposed) {
+				t.Errorf("proposed = %v, want %v", got, tt.wantProposed)
+			}
+
+			song, err := store.GetSong(ctx, id)
+			if err != nil {
+				t.Fatalf("GetSong() error = %v", err)
+			}
+			if song.Text != "edited by hand" {
+				t.Errorf("text = %q, want the manual edit kept", song.Text)
+			}
+
+			proposals, err := s.GetProposals(ctx, 10, 0)
+			if err != nil {
+				t.Fatalf("GetProposals() error = %v", err)
+			}
+			if len(proposals) != len(tt.wantProposed) {
+				t.Errorf("GetProposals() = %d proposals, want %d", len(proposals), len(tt.wantProposed))
+			}
+
+			// Повторная сверка не дублирует ожидающие предложения
+			if _, err := s.RefreshSong(ctx, id); err != nil {
+				t.Fatalf("second RefreshSong() error = %v", err)
+			}
+			again, err := s.GetProposals(ctx, 10, 0)
+			if err != nil {
+				t.Fatalf("GetProposals() error = %v", err)
+			}
+			if !reflect.DeepEqual(again, proposals) {
+				t.Errorf("proposals after the second refresh = %+v, want %+v", again, proposals)
+			}
+		})
+	}
+
+	s, _ := newTestRefreshService(t, models.RefreshPolicyAuto)
+	if _, err := s.RefreshSong(ctx, 10); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("RefreshSong() of an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+}
+
+func TestRefreshProposalDecisions(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestRefreshService(t, models.RefreshPolicyReview)
+	id := addHysteria(t, store)
+
+	if _, err := s.RefreshSong(ctx, id); err != nil {
+		t.Fatalf("RefreshSong() error = %v", err)
+	}
+	proposals, err := s.GetProposals(ctx, 10, 0)
+	if err != nil || len(proposals) != 3 {
+		t.Fatalf("GetProposals() = %+v, %v, want 3 proposals", proposals, err)
+	}
+	byField := make(map[string]int)
+	for _, proposal := range proposals {
+		byField[proposal.Field] = proposal.ID
+	}
+
+	// Принятое предложение применяется с источником и записывается правкой от имени редактора
+	song, err := s.AcceptProposal(storage.WithActor(ctx, "alice"), byField[models.FieldText])
+	if err != nil {
+		t.Fatalf("AcceptProposal() error = %v", err)
+	}
+	if song.Text != upstreamHysteria.Text || song.Sources[models.FieldText] != "stub" {
+		t.Errorf("song after accept = %q from %q, want %q from stub", song.Text, song.Sources[models.FieldText], upstreamHysteria.Text)
+	}
+	revisions, err := store.GetSongRevisions(ctx, id, 1, 0)
+	if err != nil || len(revisions) != 1 || revisions[0].Actor != "alice" || revisions[0].Action != models.RevisionUpdate {
+		t.Errorf("latest revision = %+v, %v, want an update by alice", revisions, err)
+	}
+
+	if err := s.RejectProposal(ctx, byField[models.FieldLink]); err != nil {
+		t.Fatalf("RejectProposal() error = %v", err)
+	}
+
+	// Решенные предложения больше не ожидают решения, и отклоненное значение не предлагается снова
+	if _, err := s.RefreshSong(ctx, id); err != nil {
+		t.Fatalf("RefreshSong() error = %v", err)
+	}
+	proposals, err = s.GetProposals(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetProposals() error = %v", err)
+	}
+	if got := changedFields(proposalChanges(proposals)); !reflect.DeepEqual(got, []string{models.FieldReleaseDate}) {
+		t.Errorf("pending proposals = %v, want only release_date", got)
+	}
+
+	for _, proposalID := range []int{byField[models.FieldText], byField[models.FieldLink], 100} {
+		if _, err := s.AcceptProposal(ctx, proposalID); !errors.Is(err, storage.ErrRefreshProposalNotFound) {
+			t.Errorf("AcceptProposal(%d) error = %v, want %v", proposalID, err, storage.ErrRefreshProposalNotFound)
+		}
+		if err := s.RejectProposal(ctx, proposalID); !errors.Is(err, storage.ErrRefreshProposalNotFound) {
+			t.Errorf("RejectProposal(%d) error = %v, want %v", proposalID, err, storage.ErrRefreshProposalNotFound)
+		}
+	}
+}
+
+func proposalChanges(proposals []*models.RefreshProposal) []models.FieldChange {
+	changes := make([]models.FieldChange, 0, len(proposals))
+	for _, proposal := range proposals {
+		changes = append(changes, proposal.FieldChange)
+	}
+	return changes
+}
+
+func TestRefreshProcessBatch(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestRefreshService(t, models.RefreshPolicyAuto)
+	id := addHysteria(t, store)
+	unknown, err := store.AddSong(ctx, "Muse", "Unknown", models.SongDetail{Text: "unknown"})
+	if err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	// Только что добавленные песни сверять рано
+	if n := s.ProcessBatch(ctx); n != 0 {
+		t.Fatalf("ProcessBatch() of new songs = %d, want 0", n)
+	}
+
+	for _, songID := range []int{id, unknown} {
+		if err := s.ScheduleRefresh(ctx, songID); err != nil {
+			t.Fatalf("ScheduleRefresh() error = %v", err)
+		}
+	}
+	if n := s.ProcessBatch(ctx); n != 2 {
+		t.Fatalf("ProcessBatch() = %d, want 2", n)
+	}
+	song, err := store.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.ReleaseDate != upstreamHysteria.ReleaseDate || song.Link != upstreamHysteria.Link {
+		t.Errorf("song = %+v, want the upstream date and link", song)
+	}
+
+	// Неизвестная источникам песня тоже считается сверенной до следующего срока
+	if n := s.ProcessBatch(ctx); n != 0 {
+		t.Errorf("ProcessBatch() right after a refresh = %d, want 0", n)
+	}
+
+	if err := s.ScheduleRefresh(ctx, 10); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("ScheduleRefresh() of an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+}
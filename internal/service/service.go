@@ -1,16 +1,43 @@
 package service
 
 import (
-	"github.com/TakuroBreath/song-library/internal/storage/postgresql"
+	"context"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage"
 	"log/slog"
+	"time"
 )
 
+// Timeouts задает ограничения по времени для отдельных операций сервиса.
+// Нулевое значение означает отсутствие ограничения, кроме контекста запроса.
+type Timeouts struct {
+	DB  time.Duration
+	API time.Duration
+}
+
 type SongService struct {
-	Storage *postgresql.Storage
-	apiURL  string
-	log     *slog.Logger
+	Storage  storage.SongRepository
+	info     songinfo.MetadataProvider
+	timeouts Timeouts
+	log      *slog.Logger
+}
+
+// NewSongService создает сервис песен. info — источник сведений о песнях, обычно songinfo.Chain.
+func NewSongService(storage storage.SongRepository, info songinfo.MetadataProvider, timeouts Timeouts, log *slog.Logger) *SongService {
+	return &SongService{Storage: storage, info: info, timeouts: timeouts, log: log}
+}
+
+func (s *SongService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+func (s *SongService) apiContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.API)
 }
 
-func NewSongService(storage *postgresql.Storage, apiURL string, log *slog.Logger) *SongService {
-	return &SongService{Storage: storage, apiURL: apiURL, log: log}
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// newTestSongService создает сервис песен поверх хранилища в памяти без источника сведений.
+func newTestSongService(t *testing.T) (*SongService, *memory.Storage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStorage(log)
+	return NewSongService(store, nil, Timeouts{}, log), store
+}
+
+// stubInfo — источник сведений, который знает песни из details (ключ — "группа/песня")
+// и отвечает ошибкой err, если она задана.
+type stubInfo struct {
+	mu      sync.Mutex
+	details map[string]models.SongDetail
+	err     error
+	calls   int
+}
+
+func (p *stubInfo) Name() string {
+	return "stub"
+}
+
+func (p *stubInfo) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	detail, ok := p.details[group+"/"+song]
+	if !ok {
+		return nil, songinfo.ErrNotFound
+	}
+	return &detail, nil
+}
+
+func (p *stubInfo) set(details map[string]models.SongDetail, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.details, p.err = details, err
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/TakuroBreath/song-library/pkg/jsonpatch"
+	"log/slog"
+	"net/url"
+	"time"
+	"unicode/utf8"
+)
+
+// Форматы изменения песни в PatchSong.
+const (
+	// PatchFormatMerge — JSON Merge Patch (RFC 7396): объект с новыми значениями полей, null очищает поле.
+	PatchFormatMerge = "merge"
+	// PatchFormatJSON — JSON Patch (RFC 6902): массив операций над документом песни.
+	PatchFormatJSON = "json"
+)
+
+// songDocument — изменяемые поля песни в виде JSON-документа, к которому применяется изменение.
+// Пустые дата выпуска, текст и ссылка представлены null, очищенные поля сохраняются пустыми.
+type songDocument struct {
+	Group       *string `json:"group"`
+	Song        *string `json:"song"`
+	ReleaseDate *string `json:"release_date"`
+	Text        *string `json:"text"`
+	Link        *string `json:"link"`
+}
+
+// PatchSong применяет к песне изменение в формате format и возвращает песню после изменения.
+// Изменение применяется к документу из полей group, song, release_date, text и link; получившийся
+// документ проверяется так же, как запрос на изменение песни. Поля, которые не изменились,
+// не перезаписываются. Ненулевая version должна совпадать с версией песни, к которой применялось
+// изменение, иначе возвращается storage.ErrSongVersionMismatch.
+func (s *SongService) PatchSong(ctx context.Context, id, version int, format string, patch []byte) (*models.Song, error) {
+	s.log.Info("Patching song",
+		slog.Int("id", id),
+		slog.Int("version", version),
+		slog.String("format", format))
+
+	var apply func(doc interface{}) (interface{}, error)
+	switch format {
+	case PatchFormatMerge:
+		var mergePatch interface{}
+		if err := json.Unmarshal(patch, &mergePatch); err != nil {
+			return nil, fmt.Errorf("%w: invalid merge patch: %v", ErrValidation, err)
+		}
+		if _, ok := mergePatch.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: merge patch must be an object", ErrValidation)
+		}
+		apply = func(doc interface{}) (interface{}, error) {
+			return jsonpatch.MergePatch(doc, mergePatch), nil
+		}
+	case PatchFormatJSON:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		apply = func(doc interface{}) (interface{}, error) {
+			return jsonpatch.Apply(doc, ops)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported patch format %q", ErrValidation, format)
+	}
+
+	current, err := s.songAtVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := apply(newSongDocument(current).value())
+	switch {
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+	}
+
+	after, err := decodeSongDocument(patched)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveSongDocument(ctx, current, after)
+}
+
+// EditSong меняет переданные поля песни: nil оставляет поле как есть, пустая строка очищает его.
+// Значения проверяются так же, как в PatchSong, поэтому песню можно сохранить в том виде, в каком
+// ее вернул GET, даже если дата выпуска из внешнего API записана не в формате YYYY-MM-DD.
+// Ненулевая version проверяется так же, как в PatchSong. Возвращает песню после изменения.
+func (s *SongService) EditSong(ctx context.Context, id, version int, group, song, releaseDate, text, link *string) (*models.Song, error) {
+	s.log.Info("Editing song",
+		slog.Int("id", id),
+		slog.Int("version", version))
+
+	current, err := s.songAtVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	set := func(field **string, value *string) {
+		switch {
+		case value == nil:
+		case *value == "":
+			*field = nil
+		default:
+			v := *value
+			*field = &v
+		}
+	}
+
+	after := newSongDocument(current)
+	set(&after.Group, group)
+	set(&after.Song, song)
+	set(&after.ReleaseDate, releaseDate)
+	set(&after.Text, text)
+	set(&after.Link, link)
+
+	return s.saveSongDocument(ctx, current, after)
+}
+
+// songAtVersion возвращает песню; ненулевая version должна совпадать с ее версией,
+// иначе возвращается storage.ErrSongVersionMismatch.
+func (s *SongService) songAtVersion(ctx context.Context, id, version int) (*models.Song, error) {
+	current, err := s.GetSong(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && current.Version != version {
+		return nil, fmt.Errorf("%w: expected %d, got %d", storage.ErrSongVersionMismatch, version, current.Version)
+	}
+	return current, nil
+}
+
+// saveSongDocument проверяет документ after и сохраняет поля, которые отличаются от песни current.
+func (s *SongService) saveSongDocument(ctx context.Context, current *models.Song, after songDocument) (*models.Song, error) {
+	group, song, releaseDate, text, link := newSongDocument(current).changes(after)
+	if err := after.validate(group, song, releaseDate, link); err != nil {
+		return nil, err
+	}
+
+	if group != nil || song != nil || releaseDate != nil || text != nil || link != nil {
+		// Изменение вычислено по прочитанной версии: если песню успели изменить, оно не сохраняется
+		if err := s.UpdateSong(ctx, current.ID, current.Version, group, song, releaseDate, text, link); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetSong(ctx, current.ID)
+}
+
+func newSongDocument(song *models.Song) songDocument {
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	return songDocument{
+		Group:       &song.Group,
+		Song:        &song.Song,
+		ReleaseDate: optional(song.ReleaseDate),
+		Text:        optional(song.Text),
+		Link:        optional(song.Link),
+	}
+}
+
+// value возвращает документ в том виде, в каком его декодирует encoding/json.
+func (d songDocument) value() interface{} {
+	value := make(map[string]interface{}, 5)
+	for field, v := range map[string]*string{
+		"group":        d.Group,
+		"song":         d.Song,
+		"release_date": d.ReleaseDate,
+		"text":         d.Text,
+		"link":         d.Link,
+	} {
+		if v != nil {
+			value[field] = *v
+		} else {
+			value[field] = nil
+		}
+	}
+	return value
+}
+
+// decodeSongDocument разбирает документ после изменения. Отсутствующее поле считается null,
+// неизвестные поля и значения не того типа являются ошибкой проверки.
+func decodeSongDocument(value interface{}) (songDocument, error) {
+	var doc songDocument
+
+	if _, ok := value.(map[string]interface{}); !ok {
+		return doc, fmt.Errorf("%w: song must be an object", ErrValidation)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return doc, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return doc, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return doc, nil
+}
+
+// changes возвращает новые значения полей, которые отличаются в after; очищенное поле
+// возвращается пустой строкой, неизмененное — nil.
+func (d songDocument) changes(after songDocument) (group, song, releaseDate, text, link *string) {
+	changed := func(before, after *string) *string {
+		value := ""
+		if after != nil {
+			value = *after
+		}
+		if before != nil && *before == value || before == nil && value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	return changed(d.Group, after.Group), changed(d.Song, after.Song), changed(d.ReleaseDate, after.ReleaseDate),
+		changed(d.Text, after.Text), changed(d.Link, after.Link)
+}
+
+// validate проверяет документ так же, как проверяется запрос на изменение песни. Дата выпуска
+// и ссылка проверяются, только если меняются: сведения из внешнего API могут быть в другом формате.
+func (d songDocument) validate(group, song, releaseDate, link *string) error {
+	if d.Group == nil || *d.Group == "" || d.Song == nil || *d.Song == "" {
+		return fmt.Errorf("%w: group and song are required", ErrValidation)
+	}
+	if group != nil && utf8.RuneCountInString(*group) > 255 || song != nil && utf8.RuneCountInString(*song) > 255 {
+		return fmt.Errorf("%w: group and song must be at most 255 characters", ErrValidation)
+	}
+	if releaseDate != nil && *releaseDate != "" {
+		if _, err := time.Parse(time.DateOnly, *releaseDate); err != nil {
+			return fmt.Errorf("%w: release_date must be in YYYY-MM-DD format", ErrValidation)
+		}
+	}
+	if link != nil && *link != "" {
+		if parsed, err := url.Parse(*link); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("%w: link must be a URL", ErrValidation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"testing"
+)
+
+func TestSongDocumentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		song     models.Song
+		wantJSON string
+	}{
+		{
+			name:     "all fields",
+			song:     models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com"},
+			wantJSON: `{"group":"Muse","link":"https://example.com","release_date":"01.12.2003","song":"Hysteria","text":"It's bugging me"}`,
+		},
+		{
+			name:     "empty fields are null",
+			song:     models.Song{Group: "Muse", Song: "Hysteria"},
+			wantJSON: `{"group":"Muse","link":null,"release_date":null,"song":"Hysteria","text":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := newSongDocument(&tt.song)
+
+			data, err := json.Marshal(before.value())
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("document = %s, want %s", data, tt.wantJSON)
+			}
+
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			after, err := decodeSongDocument(value)
+			if err != nil {
+				t.Fatalf("decodeSongDocument() error = %v", err)
+			}
+
+			group, song, releaseDate, text, link := before.changes(after)
+			if group != nil || song != nil || releaseDate != nil || text != nil || link != nil {
+				t.Errorf("changes() after round trip = %v %v %v %v %v, want none", group, song, releaseDate, text, link)
+			}
+		})
+	}
+}
+
+func TestSongDocumentChanges(t *testing.T) {
+	song := &models.Song{Group: "Muse", Song: "Hysteria", Text: "It's bugging me"}
+	before := newSongDocument(song)
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		after    songDocument
+		wantText *string
+		wantLink *string
+	}{
+		{name: "null keeps empty field", after: songDocument{Group: str("Muse"), Song: str("Hysteria"), Text: str("It's bugging me")}},
+		{name: "empty string keeps empty field", after: songDocument{Group: str("Muse"), Song: str("Hysteria"), Text: str("It's bugging me"), Link: str("")}},
+		{name: "null clears field", after: songDocument{Group: str("Muse"), Song: str("Hysteria")}, wantText: str("")},
+		{name: "new value", after: songDocument{Group: str("Muse"), Song: str("Hysteria"), Text: str("It's bugging me"), Link: str("https://example.com")}, wantLink: str("https://example.com")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, text, link := before.changes(tt.after)
+			if !equalPtr(text, tt.wantText) {
+				t.Errorf("text change = %v, want %v", deref(text), deref(tt.wantText))
+			}
+			if !equalPtr(link, tt.wantLink) {
+				t.Errorf("link change = %v, want %v", deref(link), deref(tt.wantLink))
+			}
+		})
+	}
+}
+
+func TestDecodeSongDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not an object", value: `["Muse"]`},
+		{name: "unknown field", value: `{"group":"Muse","song":"Hysteria","album":"Absolution"}`},
+		{name: "wrong type", value: `{"group":"Muse","song":"Hysteria","text":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if _, err := decodeSongDocument(value); !errors.Is(err, ErrValidation) {
+				t.Errorf("decodeSongDocument() error = %v, want %v", err, ErrValidation)
+			}
+		})
+	}
+}
+
+func TestPatchSong(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		patch   string
+		version int
+		want    models.Song
+		wantErr error
+	}{
+		{
+			name:   "merge null clears link and keeps unchanged date",
+			format: PatchFormatMerge,
+			patch:  `{"link":null,"text":"It's holding me"}`,
+			want:   models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's holding me"},
+		},
+		{
+			name:   "json patch on null field",
+			format: PatchFormatJSON,
+			patch:  `[{"op":"test","path":"/text","value":null},{"op":"replace","path":"/text","value":"It's holding me"}]`,
+			want:   models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's holding me", Link: "https://example.com"},
+		},
+		{
+			name:   "empty patch changes nothing",
+			format: PatchFormatMerge,
+			patch:  `{}`,
+			want:   models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Link: "https://example.com"},
+		},
+		{
+			name:    "failed test",
+			format:  PatchFormatJSON,
+			patch:   `[{"op":"test","path":"/link","value":"https://example.org"}]`,
+			wantErr: ErrPatchConflict,
+		},
+		{
+			name:    "invalid operation",
+			format:  PatchFormatJSON,
+			patch:   `[{"op":"rename","path":"/link"}]`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "cleared group",
+			format:  PatchFormatMerge,
+			patch:   `{"group":null}`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "changed date validated",
+			format:  PatchFormatMerge,
+			patch:   `{"release_date":"02.12.2003"}`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "name of another song",
+			format:  PatchFormatMerge,
+			patch:   `{"song":"Starlight"}`,
+			wantErr: storage.ErrSongExists,
+		},
+		{
+			name:    "stale version",
+			format:  PatchFormatMerge,
+			patch:   `{"text":"It's holding me"}`,
+			version: 2,
+			wantErr: storage.ErrSongVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, store := newTestSongService(t)
+
+			id, err := store.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{ReleaseDate: "01.12.2003", Link: "https://example.com"})
+			if err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+			if _, err := store.AddSong(ctx, "Muse", "Starlight", models.SongDetail{}); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+
+			got, err := s.PatchSong(ctx, id, tt.version, tt.format, []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PatchSong() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PatchSong() error = %v", err)
+			}
+
+			if got.Group != tt.want.Group || got.Song != tt.want.Song || got.ReleaseDate != tt.want.ReleaseDate ||
+				got.Text != tt.want.Text || got.Link != tt.want.Link {
+				t.Errorf("PatchSong() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func equalPtr(a, b *string) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func deref(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
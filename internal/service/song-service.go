@@ -1,48 +1,137 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/songinfo"
 	"github.com/TakuroBreath/song-library/internal/storage"
-	"io"
 	"log/slog"
-	"net/http"
-	"net/url"
+	"strings"
 )
 
-type SongDetail struct {
-	ReleaseDate string `json:"releaseDate"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+// reparseBatchSize — сколько песен ReparseVerses разбирает в одной транзакции.
+const reparseBatchSize = 100
+
+// GetSong возвращает песню по id.
+func (s *SongService) GetSong(ctx context.Context, id int) (*models.Song, error) {
+	s.log.Info("Getting song",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	song, err := s.Storage.GetSong(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to get song",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return song, nil
 }
 
-func (s *SongService) GetSongVerses(group, song string, limit, offset int) ([]string, error) {
+// GetSongVerses возвращает страницу частей текста песни и общее число частей.
+func (s *SongService) GetSongVerses(ctx context.Context, id int, limit, offset int) ([]*models.Verse, int, error) {
 	s.log.Info("Getting song verses",
-		slog.String("group", group),
-		slog.String("song", song),
+		slog.Int("id", id),
 		slog.Int("limit", limit),
 		slog.Int("offset", offset))
 
-	verses, err := s.Storage.GetSongWithPagination(group, song, limit, offset)
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	verses, total, err := s.Storage.GetSongVerses(dbCtx, id, limit, offset)
 	if err != nil {
 		s.log.Error("Failed to get song verses",
-			slog.String("group", group),
-			slog.String("song", song),
+			slog.Int("id", id),
 			slog.Any("error", err))
+		return nil, 0, err
+	}
+	return verses, total, nil
+}
+
+// GetSongVersesPage возвращает страницу частей текста песни со строками, общим числом частей
+// и смещением следующей страницы.
+func (s *SongService) GetSongVersesPage(ctx context.Context, id int, limit, offset int) (*models.VersePage, error) {
+	verses, total, err := s.GetSongVerses(ctx, id, limit, offset)
+	if err != nil {
 		return nil, err
 	}
-	return verses, nil
+
+	page := &models.VersePage{Verses: make([]*models.StructuredVerse, 0, len(verses)), Total: total}
+	for _, verse := range verses {
+		structured := storage.StructureVerse(*verse)
+		page.Verses = append(page.Verses, &structured)
+	}
+
+	if next := offset + len(verses); len(verses) > 0 && next < total {
+		page.NextOffset = &next
+	}
+
+	return page, nil
+}
+
+// ReparseVerses заново разбирает на части тексты песен, разобранные устаревшей версией разборщика,
+// и возвращает число разобранных песен. С all разбираются тексты всех песен.
+func (s *SongService) ReparseVerses(ctx context.Context, all bool) (int, error) {
+	s.log.Info("Reparsing song verses",
+		slog.Bool("all", all))
+
+	if all {
+		dbCtx, cancel := s.dbContext(ctx)
+		err := s.Storage.ResetVerses(dbCtx)
+		cancel()
+		if err != nil {
+			s.log.Error("Failed to reset song verses",
+				slog.Any("error", err))
+			return 0, err
+		}
+	}
+
+	// Песни разбираются порциями, чтобы не держать одну долгую транзакцию
+	reparsed := 0
+	for {
+		dbCtx, cancel := s.dbContext(ctx)
+		n, err := s.Storage.ReparseVerses(dbCtx, reparseBatchSize)
+		cancel()
+		if err != nil {
+			s.log.Error("Failed to reparse song verses",
+				slog.Int("reparsed", reparsed),
+				slog.Any("error", err))
+			return reparsed, err
+		}
+
+		reparsed += n
+		if n < reparseBatchSize {
+			break
+		}
+	}
+
+	s.log.Info("Song verses reparsed",
+		slog.Int("reparsed", reparsed))
+
+	return reparsed, nil
 }
 
-func (s *SongService) GetSongs(filters map[string]interface{}, limit, offset int) ([]*models.Song, error) {
+// GetSongs возвращает страницу песен, упорядоченных по sort (см. parseSongSort), а при равенстве — по id.
+func (s *SongService) GetSongs(ctx context.Context, filters map[string]interface{}, sort string, limit, offset int) ([]*models.Song, error) {
 	s.log.Info("Getting filtered songs",
 		slog.Any("filters", filters),
+		slog.String("sort", sort),
 		slog.Int("limit", limit),
 		slog.Int("offset", offset))
 
-	songs, err := s.Storage.GetFilteredSongs(filters, limit, offset)
+	order, err := parseSongSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songs, err := s.Storage.GetFilteredSongs(dbCtx, filters, order, limit, offset)
 	if err != nil {
 		s.log.Error("Failed to get filtered songs",
 			slog.Any("filters", filters),
@@ -52,13 +141,164 @@ func (s *SongService) GetSongs(filters map[string]interface{}, limit, offset int
 	return songs, nil
 }
 
-func (s *SongService) UpdateSong(id int, group, song, releaseDate, text, link *string) error {
+// parseSongSort разбирает параметр sort: поля из storage.SongListSortKeys через запятую, минус
+// перед полем означает порядок по убыванию. Каждое поле можно указать только один раз.
+func parseSongSort(raw string) ([]storage.SongSort, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var order []storage.SongSort
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		key := storage.SongSort{Key: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+
+		if !storage.IsSongListSortKey(key.Key) {
+			return nil, fmt.Errorf("%w: unsupported sort field %q, use %s",
+				ErrValidation, key.Key, strings.Join(storage.SongListSortKeys, ", "))
+		}
+		if seen[key.Key] {
+			return nil, fmt.Errorf("%w: sort field %q is repeated", ErrValidation, key.Key)
+		}
+
+		seen[key.Key] = true
+		order = append(order, key)
+	}
+
+	return order, nil
+}
+
+// GetSongsPage возвращает страницу песен, упорядоченных по sortKey и id, начиная сразу после курсора.
+// Пустой курсор означает первую страницу.
+func (s *SongService) GetSongsPage(ctx context.Context, filters map[string]interface{}, sortKey, cursor string, limit int) (*models.SongPage, error) {
+	s.log.Info("Getting songs page",
+		slog.Any("filters", filters),
+		slog.String("sort_key", sortKey),
+		slog.String("cursor", cursor),
+		slog.Int("limit", limit))
+
+	if !storage.IsSongSortKey(sortKey) {
+		return nil, fmt.Errorf("%w: cursor pagination sorts by a single ascending field (%s), got %q",
+			ErrValidation, strings.Join(storage.SongSortKeys, ", "), sortKey)
+	}
+
+	var after *storage.SongCursor
+	if cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.SortKey != sortKey {
+			return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrValidation, decoded.SortKey)
+		}
+		after = decoded
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	// Запрашиваем на одну песню больше, чтобы понять, есть ли следующая страница
+	songs, err := s.Storage.GetFilteredSongsAfter(dbCtx, filters, sortKey, after, limit+1)
+	if err != nil {
+		s.log.Error("Failed to get songs page",
+			slog.Any("filters", filters),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	page := &models.SongPage{Songs: songs}
+	if len(songs) > limit {
+		page.Songs = songs[:limit]
+		last := page.Songs[limit-1]
+		value, _ := storage.SongSortValue(last, sortKey)
+		page.NextCursor = encodeCursor(storage.SongCursor{SortKey: sortKey, Value: value, ID: last.ID})
+	}
+
+	if page.Songs == nil {
+		page.Songs = []*models.Song{}
+	}
+
+	return page, nil
+}
+
+// ExportSongs передает в fn все песни, подходящие под фильтры, в порядке id.
+// Выгрузка может идти долго, поэтому ограничение DB_TIMEOUT к ней не применяется:
+// она прерывается только отменой ctx или ошибкой fn.
+func (s *SongService) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	s.log.Info("Exporting songs", slog.Any("filters", filters))
+
+	count := 0
+	err := s.Storage.ExportSongs(ctx, filters, func(song *models.Song) error {
+		if err := fn(song); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		s.log.Error("Failed to export songs",
+			slog.Any("filters", filters),
+			slog.Int("exported", count),
+			slog.Any("error", err))
+		return err
+	}
+
+	s.log.Info("Songs exported", slog.Int("count", count))
+	return nil
+}
+
+// SearchSongs выполняет полнотекстовый поиск по названию, группе и тексту песен.
+// Пустой language означает конфигурацию поиска по умолчанию.
+func (s *SongService) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]*models.SongSearchResult, error) {
+	s.log.Info("Searching songs",
+		slog.String("query", query),
+		slog.String("language", language),
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%w: search query is empty", ErrValidation)
+	}
+
+	if language == "" {
+		language = storage.SearchLanguages[0]
+	}
+	if !storage.IsSearchLanguage(language) {
+		return nil, fmt.Errorf("%w: unsupported language %q", ErrValidation, language)
+	}
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	results, err := s.Storage.SearchSongs(dbCtx, query, language, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to search songs",
+			slog.String("query", query),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if results == nil {
+		results = []*models.SongSearchResult{}
+	}
+
+	return results, nil
+}
+
+// UpdateSong меняет переданные поля песни. Ненулевая version должна совпадать с текущей версией
+// песни, иначе возвращается storage.ErrSongVersionMismatch.
+func (s *SongService) UpdateSong(ctx context.Context, id, version int, group, song, releaseDate, text, link *string) error {
 	s.log.Info("Updating song",
 		slog.Int("id", id),
+		slog.Int("version", version),
 		slog.Any("group", group),
 		slog.Any("song", song))
 
-	err := s.Storage.UpdateSong(id, group, song, releaseDate, text, link)
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	err := s.Storage.UpdateSong(dbCtx, id, version, group, song, releaseDate, text, link)
 	if err != nil {
 		s.log.Error("Failed to update song",
 			slog.Int("id", id),
@@ -68,58 +308,94 @@ func (s *SongService) UpdateSong(id int, group, song, releaseDate, text, link *s
 	return nil
 }
 
-func (s *SongService) DeleteSong(group, song string) error {
+// DeleteSong переносит песню в корзину. Ненулевая version проверяется так же, как в UpdateSong.
+func (s *SongService) DeleteSong(ctx context.Context, id, version int) error {
 	s.log.Info("Deleting song",
-		slog.String("group", group),
-		slog.String("song", song))
+		slog.Int("id", id),
+		slog.Int("version", version))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
 
-	err := s.Storage.DeleteSong(group, song)
+	err := s.Storage.DeleteSong(dbCtx, id, version)
 	if err != nil {
 		s.log.Error("Failed to delete song",
-			slog.String("group", group),
-			slog.String("song", song),
+			slog.Int("id", id),
 			slog.Any("error", err))
 		return err
 	}
 	return nil
 }
 
-func (s *SongService) AddSongWithAPI(group, song string) (int, error) {
+func (s *SongService) AddSongWithAPI(ctx context.Context, group, song string) (int, error) {
 	s.log.Info("Adding song via API",
 		slog.String("group", group),
 		slog.String("song", song))
 
-	reqUrl := fmt.Sprintf("%s/info?group=%s&song=%s", s.apiURL, url.QueryEscape(group), url.QueryEscape(song))
-	resp, err := http.Get(reqUrl)
+	songDetail, err := s.FetchSongDetail(ctx, group, song)
 	if err != nil {
-		s.log.Error("Failed to call external API",
-			slog.String("url", reqUrl),
-			slog.Any("error", err))
-		return 0, fmt.Errorf("failed to call external API: %w", err)
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		s.log.Error("External API returned non-OK status",
-			slog.String("status", resp.Status))
-		return 0, fmt.Errorf("API returned status: %s", resp.Status)
-	}
+	return s.AddSongWithDetail(ctx, group, song, songDetail)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// AddSongAsync сохраняет песню сразу, без сведений из внешнего API, и ставит ее в очередь обогащения.
+// Песня находится в состоянии pending_enrichment, пока фоновый обработчик не получит сведения.
+func (s *SongService) AddSongAsync(ctx context.Context, group, song string) (int, error) {
+	s.log.Info("Adding song for asynchronous enrichment",
+		slog.String("group", group),
+		slog.String("song", song))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songID, err := s.Storage.AddSongForEnrichment(dbCtx, group, song)
 	if err != nil {
-		s.log.Error("Failed to read API response",
+		s.log.Error("Failed to save song in repository",
+			slog.String("group", group),
+			slog.String("song", song),
 			slog.Any("error", err))
-		return 0, fmt.Errorf("failed to read API response: %w", err)
+		return 0, err
 	}
 
-	var songDetail SongDetail
-	if err := json.Unmarshal(body, &songDetail); err != nil {
-		s.log.Error("Failed to parse API response",
+	return songID, nil
+}
+
+// FetchSongDetail запрашивает дату выпуска, текст и ссылку песни у источников сведений.
+// Повторы, задержки и выключатель реализуют HTTP-клиенты; API_TIMEOUT ограничивает запрос целиком.
+func (s *SongService) FetchSongDetail(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	apiCtx, cancelAPI := s.apiContext(ctx)
+	defer cancelAPI()
+
+	songDetail, err := s.info.Lookup(apiCtx, group, song)
+	if err != nil {
+		s.log.Error("Failed to get song info from metadata providers",
+			slog.String("group", group),
+			slog.String("song", song),
 			slog.Any("error", err))
-		return 0, fmt.Errorf("failed to parse API response: %w", err)
+		return nil, upstreamError(err)
 	}
 
-	songID, err := s.Storage.AddSong(group, song, songDetail.ReleaseDate, songDetail.Text, songDetail.Link)
+	return songDetail, nil
+}
+
+// upstreamError приводит ошибку клиента внешнего API к ошибкам сервиса. Ошибки недоступности
+// API у сервиса и клиента общие; отдельно приводится только отсутствие песни, которое API
+// сообщает как ошибку «не найдено» хранилища.
+func upstreamError(err error) error {
+	if errors.Is(err, songinfo.ErrNotFound) {
+		return ErrSongInfoNotFound
+	}
+	return err
+}
+
+// AddSongWithDetail сохраняет песню с уже известными датой выпуска, текстом, ссылкой и их источниками.
+func (s *SongService) AddSongWithDetail(ctx context.Context, group, song string, songDetail *models.SongDetail) (int, error) {
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songID, err := s.Storage.AddSong(dbCtx, group, song, *songDetail)
 	if err != nil {
 		s.log.Error("Failed to save song in repository",
 			slog.String("group", group),
@@ -140,12 +416,15 @@ func (s *SongService) AddSongWithAPI(group, song string) (int, error) {
 	return songID, nil
 }
 
-func (s *SongService) GetID(group, song string) (int, error) {
+func (s *SongService) GetID(ctx context.Context, group, song string) (int, error) {
 	s.log.Info("Getting song ID",
 		slog.String("group", group),
 		slog.String("song", song))
 
-	id, err := s.Storage.GetID(group, song)
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	id, err := s.Storage.GetID(dbCtx, group, song)
 	if err != nil {
 		s.log.Error("Failed to get song ID",
 			slog.String("group", group),
@@ -159,3 +438,64 @@ func (s *SongService) GetID(group, song string) (int, error) {
 	}
 	return id, nil
 }
+
+func (s *SongService) GetSongRevisions(ctx context.Context, songID int, limit, offset int) ([]*models.SongRevision, error) {
+	s.log.Info("Getting song revisions",
+		slog.Int("song_id", songID),
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	revisions, err := s.Storage.GetSongRevisions(dbCtx, songID, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get song revisions",
+			slog.Int("song_id", songID),
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if revisions == nil {
+		revisions = []*models.SongRevision{}
+	}
+	return revisions, nil
+}
+
+func (s *SongService) GetSongRevision(ctx context.Context, songID, revision int) (*models.SongRevision, error) {
+	s.log.Info("Getting song revision",
+		slog.Int("song_id", songID),
+		slog.Int("revision", revision))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	result, err := s.Storage.GetSongRevision(dbCtx, songID, revision)
+	if err != nil {
+		s.log.Error("Failed to get song revision",
+			slog.Int("song_id", songID),
+			slog.Int("revision", revision),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SongService) RestoreSongRevision(ctx context.Context, songID, revision int) (*models.Song, error) {
+	s.log.Info("Restoring song revision",
+		slog.Int("song_id", songID),
+		slog.Int("revision", revision))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	song, err := s.Storage.RestoreSongRevision(dbCtx, songID, revision)
+	if err != nil {
+		s.log.Error("Failed to restore song revision",
+			slog.Int("song_id", songID),
+			slog.Int("revision", revision),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return song, nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseSongSort(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []storage.SongSort
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: "group", want: []storage.SongSort{{Key: "group"}}},
+		{raw: "-release_date", want: []storage.SongSort{{Key: "release_date", Desc: true}}},
+		{
+			raw:  "group, -release_date,created_at",
+			want: []storage.SongSort{{Key: "group"}, {Key: "release_date", Desc: true}, {Key: "created_at"}},
+		},
+		{raw: "-id,song", want: []storage.SongSort{{Key: "id", Desc: true}, {Key: "song"}}},
+		{raw: "text", wantErr: true},
+		{raw: "group,", wantErr: true},
+		{raw: "--group", wantErr: true},
+		{raw: "+group", wantErr: true},
+		{raw: "group,-group", wantErr: true},
+		{raw: "Group", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseSongSort(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("parseSongSort(%q) error = %v, want %v", tt.raw, err, ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSongSort(%q) error = %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSongSort(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+// seedSongs добавляет песни для проверки фильтров и сортировки; id совпадают с порядком в списке.
+func seedSongs(t *testing.T, store storage.SongRepository) {
+	t.Helper()
+
+	songs := []struct {
+		group, song string
+		detail      models.SongDetail
+	}{
+		{"Muse", "Hysteria", models.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com/1"}},
+		{"Muse", "Starlight", models.SongDetail{ReleaseDate: "2006-09-04", Text: "Far away"}},
+		{"Кино", "Группа крови", models.SongDetail{ReleaseDate: "05.01.1988", Text: "Тёплое место"}},
+		{"Rock, Paper", "Scissors", models.SongDetail{ReleaseDate: "16.07.2006", Link: "https://example.com/4"}},
+		{"muse tribute", "Uprising", models.SongDetail{}},
+	}
+
+	for _, s := range songs {
+		if _, err := store.AddSong(context.Background(), s.group, s.song, s.detail); err != nil {
+			t.Fatalf("AddSong(%s, %s) error = %v", s.group, s.song, err)
+		}
+	}
+}
+
+func songIDs(songs []*models.Song) []int {
+	ids := make([]int, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.ID)
+	}
+	return ids
+}
+
+func TestGetSongs(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		sort    string
+		want    []int
+	}{
+		{name: "no filters", want: []int{1, 2, 3, 4, 5}},
+		{name: "exact group", filters: map[string]interface{}{"group": "Muse"}, want: []int{1, 2}},
+		{name: "contains ignores case", filters: map[string]interface{}{"group_contains": "MUSE"}, want: []int{1, 2, 5}},
+		{name: "contains cyrillic", filters: map[string]interface{}{"song_contains": "КРОВИ"}, want: []int{3}},
+		{name: "prefix", filters: map[string]interface{}{"group_prefix": "mu"}, want: []int{1, 2, 5}},
+		{name: "text contains", filters: map[string]interface{}{"text_contains": "тёплое"}, want: []int{3}},
+		{
+			name:    "date range across formats",
+			filters: map[string]interface{}{"release_date_from": "2003-12-01", "release_date_to": "2006-09-04"},
+			want:    []int{1, 2, 4},
+		},
+		{name: "date from skips empty dates", filters: map[string]interface{}{"release_date_from": "1900-01-01"}, want: []int{1, 2, 3, 4}},
+		{name: "groups with comma", filters: map[string]interface{}{"groups": []string{"Rock, Paper", "Кино"}}, want: []int{3, 4}},
+		{name: "has link", filters: map[string]interface{}{"has_link": true}, want: []int{1, 4}},
+		{name: "has no text", filters: map[string]interface{}{"has_text": false}, want: []int{4, 5}},
+		{name: "sort by release date", sort: "release_date", want: []int{5, 3, 1, 4, 2}},
+		{name: "sort by release date descending", sort: "-release_date", want: []int{2, 4, 1, 3, 5}},
+		{name: "sort by group then song descending", sort: "group,-song", want: []int{2, 1, 4, 5, 3}},
+		{name: "filter and sort", filters: map[string]interface{}{"group_contains": "muse"}, sort: "-id", want: []int{5, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestSongService(t)
+			seedSongs(t, store)
+
+			filters := tt.filters
+			if filters == nil {
+				filters = map[string]interface{}{}
+			}
+
+			songs, err := s.GetSongs(context.Background(), filters, tt.sort, 10, 0)
+			if err != nil {
+				t.Fatalf("GetSongs() error = %v", err)
+			}
+			if got := songIDs(songs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSongs() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSongsInvalidSort(t *testing.T) {
+	s, _ := newTestSongService(t)
+
+	if _, err := s.GetSongs(context.Background(), map[string]interface{}{}, "text", 10, 0); !errors.Is(err, ErrValidation) {
+		t.Errorf("GetSongs() error = %v, want %v", err, ErrValidation)
+	}
+}
+
+func TestGetSongsPage(t *testing.T) {
+	for _, sortKey := range storage.SongSortKeys {
+		t.Run(sortKey, func(t *testing.T) {
+			ctx := context.Background()
+			s, store := newTestSongService(t)
+			seedSongs(t, store)
+
+			all, err := s.GetSongs(ctx, map[string]interface{}{}, sortKey, 10, 0)
+			if err != nil {
+				t.Fatalf("GetSongs() error = %v", err)
+			}
+
+			var got []int
+			cursor := ""
+			for page := 0; ; page++ {
+				if page > len(all) {
+					t.Fatal("cursor pagination does not end")
+				}
+
+				result, err := s.GetSongsPage(ctx, map[string]interface{}{}, sortKey, cursor, 2)
+				if err != nil {
+					t.Fatalf("GetSongsPage() error = %v", err)
+				}
+				got = append(got, songIDs(result.Songs)...)
+
+				if result.NextCursor == "" {
+					break
+				}
+				cursor = result.NextCursor
+			}
+
+			if want := songIDs(all); !reflect.DeepEqual(got, want) {
+				t.Errorf("pages = %v, want the offset order %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGetSongsPageInvalid(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestSongService(t)
+	seedSongs(t, store)
+
+	page, err := s.GetSongsPage(ctx, map[string]interface{}{}, "group", "", 1)
+	if err != nil {
+		t.Fatalf("GetSongsPage() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sortKey string
+		cursor  string
+	}{
+		{name: "cursor of another sort", sortKey: "song", cursor: page.NextCursor},
+		{name: "offset-only sort", sortKey: "created_at"},
+		{name: "descending sort", sortKey: "-group"},
+		{name: "broken cursor", sortKey: "group", cursor: "broken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.GetSongsPage(ctx, map[string]interface{}{}, tt.sortKey, tt.cursor, 1); !errors.Is(err, ErrValidation) {
+				t.Errorf("GetSongsPage() error = %v, want %v", err, ErrValidation)
+			}
+		})
+	}
+}
+
+func TestReparseVerses(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestSongService(t)
+
+	// Больше одной порции, чтобы разбор прошел через несколько вызовов хранилища
+	total := reparseBatchSize + 5
+	for i := 0; i < total; i++ {
+		if _, err := store.AddSong(ctx, "Muse", "Song "+strconv.Itoa(i), models.SongDetail{Text: "[Chorus]\r\nla la\r\n\r\nverse"}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+	if err := store.DeleteSong(ctx, 1, 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+
+	// Тексты разобраны при записи текущей версией разборщика
+	reparsed, err := s.ReparseVerses(ctx, false)
+	if err != nil {
+		t.Fatalf("ReparseVerses(false) error = %v", err)
+	}
+	if reparsed != 0 {
+		t.Errorf("ReparseVerses(false) = %d, want 0", reparsed)
+	}
+
+	// all сбрасывает версию у всех песен, включая песни в корзине
+	reparsed, err = s.ReparseVerses(ctx, true)
+	if err != nil {
+		t.Fatalf("ReparseVerses(true) error = %v", err)
+	}
+	if reparsed != total {
+		t.Errorf("ReparseVerses(true) = %d, want %d", reparsed, total)
+	}
+
+	if reparsed, err = s.ReparseVerses(ctx, false); err != nil || reparsed != 0 {
+		t.Errorf("ReparseVerses(false) after reparse = %d, %v, want 0", reparsed, err)
+	}
+
+	verses, count, err := s.GetSongVerses(ctx, 2, 10, 0)
+	if err != nil {
+		t.Fatalf("GetSongVerses() error = %v", err)
+	}
+	want := []*models.Verse{
+		{Position: 1, Kind: models.VerseKindChorus, Text: "la la", Start: 10, End: 15},
+		{Position: 2, Kind: models.VerseKindVerse, Text: "verse", Start: 19, End: 24},
+	}
+	if count != len(want) || !reflect.DeepEqual(verses, want) {
+		t.Errorf("GetSongVerses() = %+v (%d), want %+v", verses, count, want)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"time"
+)
+
+// TrashService управляет корзиной удаленных песен. Песни хранятся в корзине не меньше
+// retention, после чего очистка удаляет их окончательно.
+type TrashService struct {
+	Storage   storage.SongRepository
+	retention time.Duration
+	timeouts  Timeouts
+	log       *slog.Logger
+}
+
+func NewTrashService(storage storage.SongRepository, retention time.Duration, timeouts Timeouts, log *slog.Logger) *TrashService {
+	return &TrashService{Storage: storage, retention: retention, timeouts: timeouts, log: log}
+}
+
+func (s *TrashService) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.DB)
+}
+
+func (s *TrashService) GetTrashedSongs(ctx context.Context, limit, offset int) ([]*models.Song, error) {
+	s.log.Info("Getting trashed songs",
+		slog.Int("limit", limit),
+		slog.Int("offset", offset))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	songs, err := s.Storage.GetTrashedSongs(dbCtx, limit, offset)
+	if err != nil {
+		s.log.Error("Failed to get trashed songs",
+			slog.Any("error", err))
+		return nil, err
+	}
+
+	if songs == nil {
+		songs = []*models.Song{}
+	}
+	return songs, nil
+}
+
+func (s *TrashService) RestoreSong(ctx context.Context, id int) (*models.Song, error) {
+	s.log.Info("Restoring song from trash",
+		slog.Int("id", id))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	song, err := s.Storage.RestoreSong(dbCtx, id)
+	if err != nil {
+		s.log.Error("Failed to restore song",
+			slog.Int("id", id),
+			slog.Any("error", err))
+		return nil, err
+	}
+	return song, nil
+}
+
+// Purge окончательно удаляет песни, которые пролежали в корзине дольше срока хранения.
+func (s *TrashService) Purge(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-s.retention)
+
+	s.log.Info("Purging trash",
+		slog.Time("deleted_before", deletedBefore))
+
+	dbCtx, cancel := s.dbContext(ctx)
+	defer cancel()
+
+	purged, err := s.Storage.PurgeSongs(dbCtx, deletedBefore)
+	if err != nil {
+		s.log.Error("Failed to purge trash",
+			slog.Any("error", err))
+		return 0, err
+	}
+
+	if purged > 0 {
+		s.log.Info("Trash purged",
+			slog.Int("purged", purged))
+	}
+	return purged, nil
+}
+
+// RunPurger периодически очищает корзину, пока не будет отменен ctx.
+func (s *TrashService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Ошибка уже записана в лог, следующая попытка будет на следующем тике
+			_, _ = s.Purge(ctx)
+		}
+	}
+}
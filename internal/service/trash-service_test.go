@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage/memory"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// purgeRecorder запоминает границы, с которыми вызывалась очистка корзины.
+type purgeRecorder struct {
+	*memory.Storage
+
+	mu      sync.Mutex
+	cutoffs []time.Time
+}
+
+func (r *purgeRecorder) PurgeSongs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	r.cutoffs = append(r.cutoffs, deletedBefore)
+	r.mu.Unlock()
+	return r.Storage.PurgeSongs(ctx, deletedBefore)
+}
+
+func (r *purgeRecorder) calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cutoffs)
+}
+
+func newTestTrashService(retention time.Duration) (*TrashService, *purgeRecorder) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := &purgeRecorder{Storage: memory.NewStorage(log)}
+	return NewTrashService(store, retention, Timeouts{}, log), store
+}
+
+func TestTrashPurge(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		retention  time.Duration
+		wait       time.Duration
+		wantPurged int
+	}{
+		{name: "within retention", retention: time.Hour, wantPurged: 0},
+		{name: "retention expired", retention: time.Millisecond, wait: 5 * time.Millisecond, wantPurged: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestTrashService(tt.retention)
+
+			trashed, err := store.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{})
+			if err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+			if _, err := store.AddSong(ctx, "Muse", "Starlight", models.SongDetail{}); err != nil {
+				t.Fatalf("AddSong() error = %v", err)
+			}
+			if err := store.DeleteSong(ctx, trashed, 0); err != nil {
+				t.Fatalf("DeleteSong() error = %v", err)
+			}
+			time.Sleep(tt.wait)
+
+			before := time.Now()
+			purged, err := s.Purge(ctx)
+			if err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			after := time.Now()
+			if purged != tt.wantPurged {
+				t.Errorf("Purge() = %d, want %d", purged, tt.wantPurged)
+			}
+
+			// Граница очистки отстоит от момента вызова на срок хранения
+			cutoff := store.cutoffs[0]
+			if cutoff.Before(before.Add(-tt.retention)) || cutoff.After(after.Add(-tt.retention)) {
+				t.Errorf("cutoff = %v, want between %v and %v", cutoff, before.Add(-tt.retention), after.Add(-tt.retention))
+			}
+
+			songs, err := s.GetTrashedSongs(ctx, 10, 0)
+			if err != nil {
+				t.Fatalf("GetTrashedSongs() error = %v", err)
+			}
+			if len(songs) != 1-tt.wantPurged {
+				t.Errorf("GetTrashedSongs() = %d songs, want %d", len(songs), 1-tt.wantPurged)
+			}
+
+			// Живые песни очистка не затрагивает
+			if _, err := store.GetID(ctx, "Muse", "Starlight"); err != nil {
+				t.Errorf("GetID() of a live song error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTrashRunPurger(t *testing.T) {
+	s, store := newTestTrashService(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunPurger(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for store.calls() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if store.calls() < 2 {
+		t.Fatalf("purger ran %d times, want at least 2", store.calls())
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunPurger() did not stop after cancellation")
+	}
+}
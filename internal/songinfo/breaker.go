@@ -0,0 +1,160 @@
+package songinfo
+
+import (
+	"sync"
+	"time"
+)
+
+// State — состояние автоматического выключателя.
+type State int
+
+const (
+	// StateClosed — запросы проходят, отказы подряд подсчитываются.
+	StateClosed State = iota
+	// StateOpen — запросы отклоняются сразу, пока не истечет BreakerCooldown.
+	StateOpen
+	// StateHalfOpen — пропускается один пробный запрос; его исход замыкает или снова размыкает выключатель.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker размыкается после threshold отказов подряд. Nil-значение пропускает все запросы.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to State)
+
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration, onChange func(from, to State)) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown, onChange: onChange}
+}
+
+func (b *breaker) currentState() State {
+	if b == nil {
+		return StateClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// allow сообщает, можно ли отправить запрос, и занял ли он место пробного запроса. В полуоткрытом
+// состоянии пропускается только один пробный запрос; его исход передается в success, failure или release
+// с probe = true.
+func (b *breaker) allow() (ok, probe bool) {
+	if b == nil {
+		return true, false
+	}
+
+	b.mu.Lock()
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return false, false
+		}
+		b.probing = true
+		b.transitionLocked(StateHalfOpen)
+		return true, true
+	case StateHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return false, false
+		}
+		b.probing = true
+		b.mu.Unlock()
+		return true, true
+	default:
+		b.mu.Unlock()
+		return true, false
+	}
+}
+
+// success засчитывает успешный запрос. Пробный запрос замыкает выключатель; исход остальных
+// учитывается, только пока выключатель замкнут: запрос мог быть отправлен до размыкания.
+func (b *breaker) success(probe bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if !probe {
+		if b.state == StateClosed {
+			b.failures = 0
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	b.failures = 0
+	b.probing = false
+	b.transitionLocked(StateClosed)
+}
+
+// failure засчитывает отказ. Отказ пробного запроса снова размыкает выключатель, остальные
+// учитываются, только пока выключатель замкнут.
+func (b *breaker) failure(probe bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if !probe {
+		if b.state == StateClosed {
+			b.failures++
+		}
+		if b.state != StateClosed || b.failures < b.threshold {
+			b.mu.Unlock()
+			return
+		}
+	}
+
+	b.probing = false
+	b.openedAt = time.Now()
+	b.transitionLocked(StateOpen)
+}
+
+// release освобождает место пробного запроса, не засчитывая ни успех, ни отказ.
+func (b *breaker) release(probe bool) {
+	if b == nil || !probe {
+		return
+	}
+
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// transitionLocked меняет состояние, снимает блокировку и вызывает onChange уже без нее.
+func (b *breaker) transitionLocked(to State) {
+	from := b.state
+	b.state = to
+	b.mu.Unlock()
+
+	if b.onChange != nil && from != to {
+		b.onChange(from, to)
+	}
+}
@@ -0,0 +1,179 @@
+package songinfo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transitions записывает смены состояния выключателя.
+type transitions struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (tr *transitions) record(from, to State) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.list = append(tr.list, from.String()+"->"+to.String())
+}
+
+func (tr *transitions) get() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]string(nil), tr.list...)
+}
+
+// openBreaker возвращает выключатель, разомкнутый отказами подряд, с истекшим cooldown.
+func openBreaker(t *testing.T, tr *transitions) *breaker {
+	t.Helper()
+
+	b := newBreaker(2, time.Millisecond, tr.record)
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.allow(); !ok {
+			t.Fatalf("closed breaker rejected request %d", i)
+		}
+		b.failure(false)
+	}
+	if b.currentState() != StateOpen {
+		t.Fatalf("state after %d failures = %s, want open", 2, b.currentState())
+	}
+	time.Sleep(5 * time.Millisecond)
+	return b
+}
+
+func TestBreakerNilAllowsEverything(t *testing.T) {
+	b := newBreaker(0, time.Second, nil)
+	if b != nil {
+		t.Fatalf("newBreaker(0) = %v, want nil", b)
+	}
+
+	for i := 0; i < 10; i++ {
+		b.failure(false)
+	}
+	if ok, probe := b.allow(); !ok || probe {
+		t.Errorf("allow() = %v, %v, want true, false", ok, probe)
+	}
+	if b.currentState() != StateClosed {
+		t.Errorf("state = %s, want closed", b.currentState())
+	}
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := newBreaker(3, time.Hour, nil)
+
+	b.failure(false)
+	b.failure(false)
+	b.success(false)
+	b.failure(false)
+	b.failure(false)
+	if b.currentState() != StateClosed {
+		t.Fatalf("success did not reset failures: state = %s", b.currentState())
+	}
+
+	b.failure(false)
+	if b.currentState() != StateOpen {
+		t.Fatalf("state = %s, want open", b.currentState())
+	}
+	if ok, _ := b.allow(); ok {
+		t.Error("open breaker allowed a request before cooldown")
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		finish    func(b *breaker)
+		wantState State
+		wantTrans []string
+	}{
+		{
+			name:      "probe success closes",
+			finish:    func(b *breaker) { b.success(true) },
+			wantState: StateClosed,
+			wantTrans: []string{"closed->open", "open->half-open", "half-open->closed"},
+		},
+		{
+			name:      "probe failure reopens",
+			finish:    func(b *breaker) { b.failure(true) },
+			wantState: StateOpen,
+			wantTrans: []string{"closed->open", "open->half-open", "half-open->open"},
+		},
+		{
+			name:      "probe release keeps half-open",
+			finish:    func(b *breaker) { b.release(true) },
+			wantState: StateHalfOpen,
+			wantTrans: []string{"closed->open", "open->half-open"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &transitions{}
+			b := openBreaker(t, tr)
+
+			ok, probe := b.allow()
+			if !ok || !probe {
+				t.Fatalf("allow() after cooldown = %v, %v, want a probe", ok, probe)
+			}
+			if ok, _ := b.allow(); ok {
+				t.Fatal("half-open breaker allowed a second request during the probe")
+			}
+
+			tt.finish(b)
+
+			if b.currentState() != tt.wantState {
+				t.Errorf("state = %s, want %s", b.currentState(), tt.wantState)
+			}
+			if got := tr.get(); !reflect.DeepEqual(got, tt.wantTrans) {
+				t.Errorf("transitions = %v, want %v", got, tt.wantTrans)
+			}
+		})
+	}
+}
+
+func TestBreakerReleaseFreesProbe(t *testing.T) {
+	b := openBreaker(t, &transitions{})
+
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatal("no probe after cooldown")
+	}
+	b.release(true)
+
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Errorf("allow() after release = %v, %v, want a new probe", ok, probe)
+	}
+}
+
+// Запросы, отправленные до размыкания, могут завершиться во время пробного запроса.
+// Их исход не должен освобождать место пробного запроса или менять состояние.
+func TestBreakerIgnoresStaleResultsDuringProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(b *breaker)
+	}{
+		{name: "success", finish: func(b *breaker) { b.success(false) }},
+		{name: "failure", finish: func(b *breaker) { b.failure(false) }},
+		{name: "release", finish: func(b *breaker) { b.release(false) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := openBreaker(t, &transitions{})
+
+			if ok, probe := b.allow(); !ok || !probe {
+				t.Fatal("no probe after cooldown")
+			}
+
+			tt.finish(b)
+
+			if b.currentState() != StateHalfOpen {
+				t.Errorf("state = %s, want half-open", b.currentState())
+			}
+			if ok, _ := b.allow(); ok {
+				t.Error("a second probe was allowed")
+			}
+		})
+	}
+}
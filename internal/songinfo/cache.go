@@ -0,0 +1,164 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// CacheStore хранит ответы источников сведений. Get возвращает nil без ошибки, если записи нет
+// или срок ее хранения истек. Delete сообщает, была ли запись, Clear — сколько записей удалено.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*models.InfoCacheEntry, error)
+	Set(ctx context.Context, key string, entry models.InfoCacheEntry) error
+	Delete(ctx context.Context, key string) (bool, error)
+	Clear(ctx context.Context) (int, error)
+}
+
+// CacheRepository — хранилище, через которое кэш становится общим для нескольких экземпляров
+// сервиса, например база данных. Реализуется хранилищами песен.
+type CacheRepository interface {
+	GetInfoCache(ctx context.Context, key string) (*models.InfoCacheEntry, error)
+	SaveInfoCache(ctx context.Context, key string, entry models.InfoCacheEntry) error
+	DeleteInfoCache(ctx context.Context, key string) (bool, error)
+	ClearInfoCache(ctx context.Context) (int, error)
+}
+
+// RepositoryCache — CacheStore поверх CacheRepository.
+type RepositoryCache struct {
+	repo CacheRepository
+}
+
+func NewRepositoryCache(repo CacheRepository) *RepositoryCache {
+	return &RepositoryCache{repo: repo}
+}
+
+func (c *RepositoryCache) Get(ctx context.Context, key string) (*models.InfoCacheEntry, error) {
+	return c.repo.GetInfoCache(ctx, key)
+}
+
+func (c *RepositoryCache) Set(ctx context.Context, key string, entry models.InfoCacheEntry) error {
+	return c.repo.SaveInfoCache(ctx, key, entry)
+}
+
+func (c *RepositoryCache) Delete(ctx context.Context, key string) (bool, error) {
+	return c.repo.DeleteInfoCache(ctx, key)
+}
+
+func (c *RepositoryCache) Clear(ctx context.Context) (int, error) {
+	return c.repo.ClearInfoCache(ctx)
+}
+
+// CacheConfig задает сроки хранения ответов в кэше.
+type CacheConfig struct {
+	// TTL — сколько хранятся найденные сведения.
+	TTL time.Duration
+	// NotFoundTTL — сколько хранится ответ «не найдено»; ноль отключает отрицательное кэширование.
+	NotFoundTTL time.Duration
+}
+
+// CachedProvider кэширует ответы источника сведений. Кэшируются найденные сведения и ответы
+// «не найдено»; прочие ошибки не кэшируются, чтобы сбой API не запоминался. Ошибки хранилища
+// кэша записываются в лог и не мешают запросу к источнику.
+type CachedProvider struct {
+	provider MetadataProvider
+	store    CacheStore
+	cfg      CacheConfig
+	log      *slog.Logger
+}
+
+func NewCachedProvider(provider MetadataProvider, store CacheStore, cfg CacheConfig, log *slog.Logger) *CachedProvider {
+	return &CachedProvider{provider: provider, store: store, cfg: cfg, log: log}
+}
+
+// Name возвращает имя источника, ответы которого кэшируются.
+func (c *CachedProvider) Name() string {
+	return c.provider.Name()
+}
+
+type freshLookupKey struct{}
+
+// WithFreshLookup возвращает контекст, в котором CachedProvider не читает кэш, а запрашивает источник
+// и сохраняет новый ответ. Используется, когда нужны актуальные сведения, например при обновлении песен.
+func WithFreshLookup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshLookupKey{}, true)
+}
+
+func freshLookup(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshLookupKey{}).(bool)
+	return fresh
+}
+
+// CacheKey возвращает ключ кэша для песни.
+func CacheKey(group, song string) string {
+	return url.QueryEscape(group) + "/" + url.QueryEscape(song)
+}
+
+// Lookup возвращает сведения из кэша или запрашивает источник и сохраняет ответ.
+func (c *CachedProvider) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	key := CacheKey(group, song)
+	log := c.log.With(slog.String("group", group), slog.String("song", song))
+
+	if !freshLookup(ctx) {
+		entry, err := c.store.Get(ctx, key)
+		switch {
+		case err != nil:
+			log.Warn("Failed to read song info cache",
+				slog.Any("error", err))
+		case entry != nil && entry.NotFound:
+			log.Debug("Song info cache hit",
+				slog.Bool("not_found", true))
+			return nil, ErrNotFound
+		case entry != nil:
+			log.Debug("Song info cache hit")
+			return copyDetail(&entry.Detail), nil
+		}
+	}
+
+	detail, err := c.provider.Lookup(ctx, group, song)
+	switch {
+	case err == nil:
+		c.save(ctx, log, key, models.InfoCacheEntry{Detail: *copyDetail(detail)}, c.cfg.TTL)
+	case errors.Is(err, ErrNotFound):
+		c.save(ctx, log, key, models.InfoCacheEntry{NotFound: true}, c.cfg.NotFoundTTL)
+	}
+
+	return detail, err
+}
+
+func (c *CachedProvider) save(ctx context.Context, log *slog.Logger, key string, entry models.InfoCacheEntry, ttl time.Duration) {
+	if ttl <= 0 || ctx.Err() != nil {
+		return
+	}
+
+	entry.ExpiresAt = time.Now().Add(ttl)
+	if err := c.store.Set(ctx, key, entry); err != nil {
+		log.Warn("Failed to save song info cache",
+			slog.Any("error", err))
+	}
+}
+
+// Invalidate удаляет из кэша ответ для песни и сообщает, был ли он.
+func (c *CachedProvider) Invalidate(ctx context.Context, group, song string) (bool, error) {
+	return c.store.Delete(ctx, CacheKey(group, song))
+}
+
+// Clear удаляет из кэша все ответы и возвращает их количество.
+func (c *CachedProvider) Clear(ctx context.Context) (int, error) {
+	return c.store.Clear(ctx)
+}
+
+// copyDetail копирует сведения вместе с картой источников, чтобы кэш не делил ее с вызывающим.
+func copyDetail(detail *models.SongDetail) *models.SongDetail {
+	result := *detail
+	if detail.Sources != nil {
+		result.Sources = make(map[string]string, len(detail.Sources))
+		for field, source := range detail.Sources {
+			result.Sources[field] = source
+		}
+	}
+	return &result
+}
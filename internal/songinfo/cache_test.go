@@ -0,0 +1,184 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestCachedProvider(p *stubProvider, cfg CacheConfig) *CachedProvider {
+	return NewCachedProvider(p, NewLRUCache(10), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCachedProviderLookup(t *testing.T) {
+	ctx := context.Background()
+	detail := models.SongDetail{Text: "It's bugging me", Sources: map[string]string{"text": "a"}}
+
+	tests := []struct {
+		name      string
+		err       error
+		cfg       CacheConfig
+		wait      time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "found is cached", cfg: CacheConfig{TTL: time.Hour}, wantCalls: 1},
+		{name: "found expires", cfg: CacheConfig{TTL: time.Millisecond}, wait: 5 * time.Millisecond, wantCalls: 2},
+		{name: "disabled ttl", wantCalls: 2},
+		{name: "not found is cached", err: ErrNotFound, cfg: CacheConfig{TTL: time.Hour, NotFoundTTL: time.Hour}, wantErr: ErrNotFound, wantCalls: 1},
+		{name: "not found without negative caching", err: ErrNotFound, cfg: CacheConfig{TTL: time.Hour}, wantErr: ErrNotFound, wantCalls: 2},
+		{name: "failure is not cached", err: ErrUnavailable, cfg: CacheConfig{TTL: time.Hour, NotFoundTTL: time.Hour}, wantErr: ErrUnavailable, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &stubProvider{name: "a", detail: detail, err: tt.err}
+			c := newTestCachedProvider(p, tt.cfg)
+
+			for i := 0; i < 2; i++ {
+				got, err := c.Lookup(ctx, "Muse", "Hysteria")
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Lookup() #%d error = %v, want %v", i+1, err, tt.wantErr)
+				}
+				if err == nil && got.Text != detail.Text {
+					t.Errorf("Lookup() #%d = %+v, want %+v", i+1, got, detail)
+				}
+				time.Sleep(tt.wait)
+			}
+
+			if p.calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", p.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachedProviderFreshLookup(t *testing.T) {
+	ctx := context.Background()
+	p := &stubProvider{name: "a", detail: models.SongDetail{Text: "old"}}
+	c := newTestCachedProvider(p, CacheConfig{TTL: time.Hour})
+
+	if _, err := c.Lookup(ctx, "Muse", "Hysteria"); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	// Свежий запрос идет к источнику мимо кэша и заменяет сохраненный ответ
+	p.detail.Text = "new"
+	got, err := c.Lookup(WithFreshLookup(ctx), "Muse", "Hysteria")
+	if err != nil || got.Text != "new" {
+		t.Fatalf("fresh Lookup() = %+v, %v, want the new text", got, err)
+	}
+	got, err = c.Lookup(ctx, "Muse", "Hysteria")
+	if err != nil || got.Text != "new" || p.calls != 2 {
+		t.Errorf("Lookup() after the fresh one = %+v, %v with %d calls, want the new text from cache", got, err, p.calls)
+	}
+}
+
+func TestCachedProviderInvalidate(t *testing.T) {
+	ctx := context.Background()
+	p := &stubProvider{name: "a", detail: models.SongDetail{Text: "It's bugging me"}}
+	c := newTestCachedProvider(p, CacheConfig{TTL: time.Hour})
+
+	for _, song := range []string{"Hysteria", "Starlight"} {
+		if _, err := c.Lookup(ctx, "Muse", song); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+	}
+
+	if removed, err := c.Invalidate(ctx, "Muse", "Hysteria"); err != nil || !removed {
+		t.Fatalf("Invalidate() = %v, %v, want true", removed, err)
+	}
+	if removed, err := c.Invalidate(ctx, "Muse", "Hysteria"); err != nil || removed {
+		t.Errorf("second Invalidate() = %v, %v, want false", removed, err)
+	}
+	if _, err := c.Lookup(ctx, "Muse", "Hysteria"); err != nil || p.calls != 3 {
+		t.Errorf("Lookup() after Invalidate() called the provider %d times, want 3", p.calls)
+	}
+
+	if cleared, err := c.Clear(ctx); err != nil || cleared != 2 {
+		t.Errorf("Clear() = %d, %v, want 2", cleared, err)
+	}
+	if _, err := c.Lookup(ctx, "Muse", "Starlight"); err != nil || p.calls != 4 {
+		t.Errorf("Lookup() after Clear() called the provider %d times, want 4", p.calls)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(2)
+	entry := func(text string, ttl time.Duration) models.InfoCacheEntry {
+		return models.InfoCacheEntry{Detail: models.SongDetail{Text: text}, ExpiresAt: time.Now().Add(ttl)}
+	}
+	has := func(key string) bool {
+		got, err := c.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", key, err)
+		}
+		return got != nil
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(ctx, key, entry(key, time.Hour)); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	// Обращение к a делает давно не использованной запись b, и она вытесняется
+	if !has("a") {
+		t.Fatal("Get(a) = nil, want the entry")
+	}
+	if err := c.Set(ctx, "c", entry("c", time.Hour)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if has("b") || !has("a") || !has("c") {
+		t.Errorf("after eviction b = %v, a = %v, c = %v, want only b evicted", has("b"), has("a"), has("c"))
+	}
+
+	// Замена записи не вытесняет другие
+	if err := c.Set(ctx, "c", entry("c2", time.Hour)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := c.Get(ctx, "c")
+	if err != nil || got.Detail.Text != "c2" || !has("a") {
+		t.Errorf("Get(c) after replace = %+v, %v, want c2 with a kept", got, err)
+	}
+
+	if err := c.Set(ctx, "a", entry("a", -time.Second)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if has("a") {
+		t.Error("Get() of an expired entry returned it")
+	}
+	if removed, err := c.Delete(ctx, "a"); err != nil || removed {
+		t.Errorf("Delete() of an expired entry = %v, %v, want it already removed", removed, err)
+	}
+
+	if cleared, err := c.Clear(ctx); err != nil || cleared != 1 {
+		t.Errorf("Clear() = %d, %v, want 1", cleared, err)
+	}
+}
+
+func TestLRUCacheCopiesSources(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(1)
+	sources := map[string]string{"text": "a"}
+
+	if err := c.Set(ctx, "a", models.InfoCacheEntry{Detail: models.SongDetail{Sources: sources}, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	sources["text"] = "changed"
+
+	got, err := c.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got.Detail.Sources["text"] = "changed again"
+
+	if got, _ := c.Get(ctx, "a"); got.Detail.Sources["text"] != "a" {
+		t.Errorf("cached source = %q, want a", got.Detail.Sources["text"])
+	}
+}
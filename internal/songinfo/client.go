@@ -0,0 +1,393 @@
+// Package songinfo — источники сведений о песнях (дата выпуска, текст, ссылка) и их цепочка.
+//
+// Client обращается к HTTP API: к основному API (GET /info) или к любому другому через
+// сопоставление полей ответа. Клиент ограничивает время каждой попытки, повторяет запрос при
+// сетевых ошибках и ошибках сервера с экспоненциальной задержкой и разбросом, учитывает
+// Retry-After и с помощью автоматического выключателя перестает обращаться к API, пока тот
+// недоступен. DirectoryProvider читает сведения из локального каталога, а Chain опрашивает
+// источники по приоритету и объединяет их ответы по полям.
+package songinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize ограничивает размер читаемого ответа API.
+const maxResponseSize = 4 << 20
+
+var (
+	// ErrNotFound означает, что API не знает запрошенную песню.
+	ErrNotFound = errors.New("song info not found")
+	// ErrUnavailable означает, что API недоступен или вернул ошибку сервера.
+	ErrUnavailable = errors.New("external API unavailable")
+	// ErrInvalidResponse означает, что ответ API не удалось разобрать.
+	ErrInvalidResponse = errors.New("external API returned invalid response")
+	// ErrTimeout означает, что API не ответил вовремя.
+	ErrTimeout = errors.New("external API timed out")
+	// ErrCircuitOpen означает, что запрос не отправлялся: выключатель разомкнут после череды отказов.
+	ErrCircuitOpen = fmt.Errorf("%w: circuit breaker is open", ErrUnavailable)
+)
+
+// InfoProviderName — имя основного API в сведениях об источниках полей.
+const InfoProviderName = "info-api"
+
+// infoResponse — ответ основного API на GET /info.
+type infoResponse struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Config задает поведение клиента. Нулевые значения полей заменяются значениями из DefaultConfig,
+// кроме AttemptTimeout и BreakerThreshold: их нулевое значение отключает ограничение и выключатель.
+type Config struct {
+	// AttemptTimeout ограничивает одну попытку вместе с чтением ответа.
+	// Общее время запроса со всеми повторами ограничивает контекст вызывающего.
+	AttemptTimeout time.Duration
+	// MaxRetries — сколько раз запрос повторяется после первой неудачной попытки.
+	MaxRetries int
+	// BaseBackoff и MaxBackoff задают экспоненциальную задержку между попытками: перед повтором n
+	// клиент ждет случайное время от нуля до min(MaxBackoff, BaseBackoff*2^n).
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxRetryAfter — наибольшая задержка из заголовка Retry-After, которую клиент готов выждать;
+	// при большей запрос завершается ошибкой без повтора.
+	MaxRetryAfter time.Duration
+	// BreakerThreshold — число отказов подряд, после которого выключатель размыкается.
+	BreakerThreshold int
+	// BreakerCooldown — сколько выключатель остается разомкнутым, прежде чем пропустить пробный запрос.
+	BreakerCooldown time.Duration
+	// HTTPClient выполняет запросы; по умолчанию используется отдельный клиент без общего таймаута.
+	HTTPClient *http.Client
+	// Header добавляется к каждому запросу, например для ключа доступа к API.
+	Header http.Header
+	// Hooks получают события клиента для метрик.
+	Hooks Hooks
+}
+
+// DefaultConfig возвращает настройки клиента по умолчанию.
+func DefaultConfig() Config {
+	return Config{
+		AttemptTimeout:   3 * time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		MaxRetryAfter:    5 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// Client обращается к HTTP API сведений о песнях. Безопасен для одновременного использования.
+type Client struct {
+	name     string
+	buildURL func(group, song string) string
+	decode   func(body []byte) (*models.SongDetail, error)
+	cfg      Config
+	http     *http.Client
+	breaker  *breaker
+	log      *slog.Logger
+}
+
+// NewClient создает клиент основного API, который отвечает на GET {baseURL}/info?group=&song=.
+func NewClient(baseURL string, cfg Config, log *slog.Logger) *Client {
+	return newClient(InfoProviderName, infoURL(baseURL), decodeInfo, cfg, log)
+}
+
+func infoURL(baseURL string) func(group, song string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return func(group, song string) string {
+		return fmt.Sprintf("%s/info?group=%s&song=%s", baseURL, url.QueryEscape(group), url.QueryEscape(song))
+	}
+}
+
+func decodeInfo(body []byte) (*models.SongDetail, error) {
+	var resp infoResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &models.SongDetail{ReleaseDate: resp.ReleaseDate, Text: resp.Text, Link: resp.Link}, nil
+}
+
+func newClient(name string, buildURL func(group, song string) string, decode func([]byte) (*models.SongDetail, error), cfg Config, log *slog.Logger) *Client {
+	log = log.With(slog.String("provider", name))
+
+	def := DefaultConfig()
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = def.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if cfg.MaxRetryAfter <= 0 {
+		cfg.MaxRetryAfter = def.MaxRetryAfter
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = def.BreakerCooldown
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+
+	onChange := func(from, to State) {
+		log.Warn("External API circuit breaker state changed",
+			slog.String("from", from.String()),
+			slog.String("to", to.String()))
+		if cfg.Hooks.OnStateChange != nil {
+			cfg.Hooks.OnStateChange(from, to)
+		}
+	}
+
+	return &Client{
+		name:     name,
+		buildURL: buildURL,
+		decode:   decode,
+		cfg:      cfg,
+		http:     httpClient,
+		breaker:  newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown, onChange),
+		log:      log,
+	}
+}
+
+// Name возвращает имя источника.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// BreakerState возвращает текущее состояние выключателя.
+func (c *Client) BreakerState() State {
+	return c.breaker.currentState()
+}
+
+// Lookup запрашивает дату выпуска, текст и ссылку песни. Ошибки оборачивают ErrNotFound, ErrUnavailable,
+// ErrInvalidResponse или ErrTimeout; отмена ctx вызывающим возвращается как context.Canceled.
+func (c *Client) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	reqURL := c.buildURL(group, song)
+
+	for attempt := 0; ; attempt++ {
+		ok, probe := c.breaker.allow()
+		if !ok {
+			c.cfg.Hooks.reject()
+			return nil, ErrCircuitOpen
+		}
+
+		detail, res := c.do(ctx, reqURL, attempt)
+
+		switch res.outcome {
+		case outcomeSuccess:
+			c.breaker.success(probe)
+		case outcomeFailure:
+			c.breaker.failure(probe)
+		default:
+			c.breaker.release(probe)
+		}
+
+		if res.err == nil {
+			return detail, nil
+		}
+		// Если выключатель разомкнулся из-за этой попытки, повтор все равно будет отклонен
+		if !res.retryable || attempt >= c.cfg.MaxRetries || c.breaker.currentState() == StateOpen {
+			return nil, res.err
+		}
+
+		wait := c.backoff(attempt)
+		if res.retryAfter > 0 {
+			if res.retryAfter > c.cfg.MaxRetryAfter {
+				return nil, res.err
+			}
+			wait = res.retryAfter
+		}
+
+		// Не ждем повтора, который все равно не успеет до истечения срока запроса
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return nil, res.err
+		}
+
+		c.log.Warn("Retrying external API request",
+			slog.String("url", reqURL),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("wait", wait),
+			slog.Any("error", res.err))
+		c.cfg.Hooks.retry(attempt+1, wait, res.err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, c.contextError(ctx, res.err)
+		case <-timer.C:
+		}
+	}
+}
+
+// outcome — как попытка отражается на выключателе.
+type outcome int
+
+const (
+	// outcomeNeutral не меняет счетчик отказов: запрос отменен вызывающим или API ограничил частоту.
+	outcomeNeutral outcome = iota
+	// outcomeSuccess означает, что API ответил, даже если песни у него нет.
+	outcomeSuccess
+	// outcomeFailure — сетевая ошибка, таймаут, ошибка сервера или неразборчивый ответ.
+	outcomeFailure
+)
+
+type attemptResult struct {
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+	outcome    outcome
+}
+
+// do выполняет одну попытку запроса.
+func (c *Client) do(ctx context.Context, reqURL string, attempt int) (*models.SongDetail, attemptResult) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.cfg.AttemptTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.cfg.AttemptTimeout)
+	}
+	defer cancel()
+
+	start := time.Now()
+	status := 0
+	detail, res := c.fetch(attemptCtx, reqURL, &status)
+
+	// Ошибку контекста вызывающего не повторяем: его срок истек или запрос отменен
+	if res.err != nil && ctx.Err() != nil {
+		res.err = c.contextError(ctx, res.err)
+		res.retryable = false
+		if errors.Is(ctx.Err(), context.Canceled) {
+			res.outcome = outcomeNeutral
+		}
+	}
+
+	c.cfg.Hooks.attempt(attempt, status, time.Since(start), res.err)
+
+	return detail, res
+}
+
+func (c *Client) fetch(ctx context.Context, reqURL string, status *int) (*models.SongDetail, attemptResult) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, attemptResult{err: fmt.Errorf("build request: %w", err)}
+	}
+	for key, values := range c.cfg.Header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, transportFailure(err)
+	}
+	defer resp.Body.Close()
+
+	*status = resp.StatusCode
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, attemptResult{err: ErrNotFound, outcome: outcomeSuccess}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, attemptResult{
+			err:        fmt.Errorf("%w: API returned status: %s", ErrUnavailable, resp.Status),
+			retryable:  true,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After")),
+			outcome:    outcomeNeutral,
+		}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, attemptResult{
+			err:        fmt.Errorf("%w: API returned status: %s", ErrUnavailable, resp.Status),
+			retryable:  true,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After")),
+			outcome:    outcomeFailure,
+		}
+	default:
+		return nil, attemptResult{
+			err:     fmt.Errorf("%w: API returned status: %s", ErrInvalidResponse, resp.Status),
+			outcome: outcomeSuccess,
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, transportFailure(err)
+	}
+
+	detail, err := c.decode(body)
+	if errors.Is(err, ErrNotFound) {
+		return nil, attemptResult{err: ErrNotFound, outcome: outcomeSuccess}
+	}
+	if err != nil {
+		return nil, attemptResult{
+			err:     fmt.Errorf("%w: failed to parse API response: %v", ErrInvalidResponse, err),
+			outcome: outcomeFailure,
+		}
+	}
+
+	return detail, attemptResult{outcome: outcomeSuccess}
+}
+
+// transportFailure описывает сетевую ошибку или истечение времени попытки; такие попытки повторяются.
+func transportFailure(err error) attemptResult {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return attemptResult{err: fmt.Errorf("%w: %v", ErrTimeout, err), retryable: true, outcome: outcomeFailure}
+	}
+	return attemptResult{err: fmt.Errorf("%w: %v", ErrUnavailable, err), retryable: true, outcome: outcomeFailure}
+}
+
+// contextError возвращает ошибку для запроса, прерванного контекстом вызывающего.
+func (c *Client) contextError(ctx context.Context, lastErr error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if errors.Is(lastErr, ErrTimeout) {
+			return lastErr
+		}
+		return fmt.Errorf("%w: %v", ErrTimeout, ctx.Err())
+	}
+	return ctx.Err()
+}
+
+// backoff возвращает задержку перед повтором с номером attempt+1 (full jitter).
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.MaxBackoff
+	if attempt < 30 {
+		if d := c.cfg.BaseBackoff << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return rand.N(ceiling) + 1
+}
+
+// retryAfter разбирает заголовок Retry-After в секундах или в формате HTTP-даты.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,344 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer отвечает по очереди статусами statuses (последний повторяется) и считает запросы.
+// Ответ 200 содержит сведения о песне, к остальным добавляется заголовок Retry-After, если он задан.
+func testServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+
+		if status != http.StatusOK {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = io.WriteString(w, `{"releaseDate":"16.07.2006","text":"Ooh baby","link":"https://example.com"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+// testConfig возвращает настройки с короткими задержками и отключенным выключателем.
+func testConfig() Config {
+	return Config{
+		AttemptTimeout: time.Second,
+		MaxRetries:     2,
+		BaseBackoff:    time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+		MaxRetryAfter:  5 * time.Second,
+	}
+}
+
+func newTestClient(url string, cfg Config) *Client {
+	return NewClient(url, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      error
+		wantRequests int32
+	}{
+		{name: "success", statuses: []int{200}, wantRequests: 1},
+		{name: "server error then success", statuses: []int{500, 503, 200}, wantRequests: 3},
+		{name: "rate limited then success", statuses: []int{429, 200}, wantRequests: 2},
+		{name: "server errors exhaust retries", statuses: []int{500}, wantErr: ErrUnavailable, wantRequests: 3},
+		{name: "not found is not retried", statuses: []int{404}, wantErr: ErrNotFound, wantRequests: 1},
+		{name: "client error is not retried", statuses: []int{400}, wantErr: ErrInvalidResponse, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := testServer(t, "", tt.statuses...)
+
+			var retries []int
+			cfg := testConfig()
+			cfg.Hooks.OnRetry = func(attempt int, wait time.Duration, err error) {
+				retries = append(retries, attempt)
+			}
+
+			detail, err := newTestClient(server.URL, cfg).Lookup(context.Background(), "Muse", "Hysteria")
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if len(retries) != int(tt.wantRequests)-1 {
+				t.Errorf("retries = %v, want %d", retries, tt.wantRequests-1)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if detail.ReleaseDate != "16.07.2006" || detail.Text != "Ooh baby" || detail.Link != "https://example.com" {
+				t.Errorf("Lookup() = %+v", *detail)
+			}
+		})
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	server, requests := testServer(t, "1", 503, 200)
+
+	var waits []time.Duration
+	cfg := testConfig()
+	cfg.Hooks.OnRetry = func(attempt int, wait time.Duration, err error) {
+		waits = append(waits, wait)
+	}
+
+	start := time.Now()
+	if _, err := newTestClient(server.URL, cfg).Lookup(context.Background(), "Muse", "Hysteria"); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	if len(waits) != 1 || waits[0] != time.Second {
+		t.Errorf("retry waits = %v, want [1s] from Retry-After", waits)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Lookup() returned after %s, before Retry-After", elapsed)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestClientRetryAfterTooLong(t *testing.T) {
+	server, requests := testServer(t, "10", 429, 200)
+
+	cfg := testConfig()
+	cfg.MaxRetryAfter = time.Second
+
+	start := time.Now()
+	_, err := newTestClient(server.URL, cfg).Lookup(context.Background(), "Muse", "Hysteria")
+
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Lookup() error = %v, want %v", err, ErrUnavailable)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Lookup() waited %s for a Retry-After above the limit", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty", value: "", max: 0},
+		{name: "seconds", value: "3", min: 3 * time.Second, max: 3 * time.Second},
+		{name: "zero", value: "0", max: 0},
+		{name: "negative", value: "-1", max: 0},
+		{name: "garbage", value: "soon", max: 0},
+		{name: "http date", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "past http date", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestClientBackoffBoundedByContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "cancel during backoff",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline before retry",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 200*time.Millisecond)
+			},
+			wantErr: ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Retry-After задает задержку длиннее срока запроса
+			server, requests := testServer(t, "2", 503)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := newTestClient(server.URL, testConfig()).Lookup(ctx, "Muse", "Hysteria")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Lookup() returned after %s, backoff outlived the context", elapsed)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("requests = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestClientBackoffCeiling(t *testing.T) {
+	client := newTestClient("http://localhost", Config{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+
+	for attempt := 0; attempt < 40; attempt++ {
+		ceiling := 50 * time.Millisecond
+		if attempt < 3 {
+			ceiling = 10 * time.Millisecond << attempt
+		}
+		for i := 0; i < 20; i++ {
+			if wait := client.backoff(attempt); wait <= 0 || wait > ceiling {
+				t.Fatalf("backoff(%d) = %s, want in (0, %s]", attempt, wait, ceiling)
+			}
+		}
+	}
+}
+
+func TestClientAttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.AttemptTimeout = 20 * time.Millisecond
+	cfg.MaxRetries = 0
+
+	if _, err := newTestClient(server.URL, cfg).Lookup(context.Background(), "Muse", "Hysteria"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Lookup() error = %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, `{"releaseDate":"16.07.2006"}`)
+	}))
+	defer server.Close()
+
+	var rejects atomic.Int32
+	tr := &transitions{}
+	cfg := testConfig()
+	cfg.MaxRetries = 5
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = 50 * time.Millisecond
+	cfg.Hooks.OnReject = func() { rejects.Add(1) }
+	cfg.Hooks.OnStateChange = tr.record
+	client := newTestClient(server.URL, cfg)
+	ctx := context.Background()
+
+	// Повторы прекращаются, как только выключатель размыкается
+	if _, err := client.Lookup(ctx, "Muse", "Hysteria"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Lookup() error = %v, want %v", err, ErrUnavailable)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests before opening = %d, want 2", got)
+	}
+	if client.BreakerState() != StateOpen {
+		t.Fatalf("state = %s, want open", client.BreakerState())
+	}
+
+	if _, err := client.Lookup(ctx, "Muse", "Hysteria"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Lookup() on open breaker error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if requests.Load() != 2 || rejects.Load() != 1 {
+		t.Errorf("open breaker sent a request: requests = %d, rejects = %d", requests.Load(), rejects.Load())
+	}
+
+	// Пробный запрос после cooldown снова размыкает выключатель при отказе
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.Lookup(ctx, "Muse", "Hysteria"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("failed probe error = %v, want %v", err, ErrUnavailable)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests after failed probe = %d, want 3", got)
+	}
+	if client.BreakerState() != StateOpen {
+		t.Fatalf("state after failed probe = %s, want open", client.BreakerState())
+	}
+
+	// и замыкает при успехе
+	fail.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.Lookup(ctx, "Muse", "Hysteria"); err != nil {
+		t.Fatalf("successful probe error = %v", err)
+	}
+	if client.BreakerState() != StateClosed {
+		t.Errorf("state after successful probe = %s, want closed", client.BreakerState())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if got := tr.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+}
+
+func TestClientRateLimitDoesNotOpenBreaker(t *testing.T) {
+	server, requests := testServer(t, "", 429)
+
+	cfg := testConfig()
+	cfg.BreakerThreshold = 1
+
+	client := newTestClient(server.URL, cfg)
+	if _, err := client.Lookup(context.Background(), "Muse", "Hysteria"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Lookup() error = %v, want %v", err, ErrUnavailable)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+	if client.BreakerState() != StateClosed {
+		t.Errorf("state = %s, want closed", client.BreakerState())
+	}
+}
@@ -0,0 +1,131 @@
+package songinfo
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Типы источников в файле настроек.
+const (
+	ProviderInfo      = "info"
+	ProviderDirectory = "directory"
+	ProviderHTTP      = "http"
+)
+
+// ProvidersConfig — файл настроек цепочки источников. Источники перечисляются в порядке приоритета:
+//
+//	providers:
+//	  - name: info-api
+//	    type: info
+//	    url: http://localhost:8081
+//	  - name: lyrics-dir
+//	    type: directory
+//	    path: ./lyrics
+//	  - name: lyrics-api
+//	    type: http
+//	    url: https://lyrics.example.com/v1/{group}/{song}?lang=en
+//	    headers:
+//	      Authorization: Bearer ${LYRICS_API_TOKEN}
+//	    fields:
+//	      text: result.lyrics
+//	      link: result.url
+type ProvidersConfig struct {
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+// ProviderConfig описывает один источник. URL нужен источникам info и http, Path — источнику directory,
+// Fields — источнику http. Timeout и Retries переопределяют общие настройки HTTP-клиента.
+// В URL, Path и значениях Headers подставляются переменные окружения, например ${API_URL}.
+type ProviderConfig struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url"`
+	Path    string            `yaml:"path"`
+	Fields  FieldMapping      `yaml:"fields"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout *time.Duration    `yaml:"timeout"`
+	Retries *int              `yaml:"retries"`
+}
+
+// LoadProviders читает файл настроек и создает источники в порядке приоритета.
+// base задает настройки HTTP-клиентов, которые источник не переопределил.
+func LoadProviders(path string, base Config, log *slog.Logger) ([]MetadataProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata config: %w", err)
+	}
+
+	var cfg ProvidersConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse metadata config: %w", err)
+	}
+	if len(cfg.Providers) == 0 {
+		return nil, fmt.Errorf("metadata config %s has no providers", path)
+	}
+
+	providers := make([]MetadataProvider, 0, len(cfg.Providers))
+	names := make(map[string]bool, len(cfg.Providers))
+	for i, pc := range cfg.Providers {
+		provider, err := pc.build(base, log)
+		if err != nil {
+			return nil, fmt.Errorf("metadata provider #%d: %w", i+1, err)
+		}
+		if names[provider.Name()] {
+			return nil, fmt.Errorf("metadata provider #%d: duplicate name %q", i+1, provider.Name())
+		}
+		names[provider.Name()] = true
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+func (pc ProviderConfig) build(base Config, log *slog.Logger) (MetadataProvider, error) {
+	pc.URL = os.ExpandEnv(pc.URL)
+	pc.Path = os.ExpandEnv(pc.Path)
+
+	cfg := base
+	if pc.Timeout != nil {
+		cfg.AttemptTimeout = *pc.Timeout
+	}
+	if pc.Retries != nil {
+		cfg.MaxRetries = *pc.Retries
+	}
+	if len(pc.Headers) > 0 {
+		cfg.Header = make(http.Header, len(pc.Headers))
+		for key, value := range pc.Headers {
+			cfg.Header.Set(key, os.ExpandEnv(value))
+		}
+	}
+
+	switch pc.Type {
+	case ProviderInfo:
+		if pc.URL == "" {
+			return nil, fmt.Errorf("provider %q: url is required", pc.Name)
+		}
+		name := pc.Name
+		if name == "" {
+			name = InfoProviderName
+		}
+		return newClient(name, infoURL(pc.URL), decodeInfo, cfg, log), nil
+	case ProviderDirectory:
+		if pc.Path == "" {
+			return nil, fmt.Errorf("provider %q: path is required", pc.Name)
+		}
+		return NewDirectoryProvider(pc.Name, pc.Path)
+	case ProviderHTTP:
+		if pc.Name == "" {
+			return nil, fmt.Errorf("provider of type http: name is required")
+		}
+		if pc.URL == "" {
+			return nil, fmt.Errorf("provider %q: url is required", pc.Name)
+		}
+		return NewMappedClient(pc.Name, pc.URL, pc.Fields, cfg, log)
+	default:
+		return nil, fmt.Errorf("provider %q: unknown type %q", pc.Name, pc.Type)
+	}
+}
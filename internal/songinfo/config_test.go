@@ -0,0 +1,129 @@
+package songinfo
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeConfig записывает файл настроек источников во временный каталог и возвращает путь к нему.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"providers.yaml": content})
+	return filepath.Join(dir, "providers.yaml")
+}
+
+func TestLoadProviders(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, `{"result":{"lyrics":"Ooh baby"}}`)
+	}))
+	defer server.Close()
+
+	lyricsDir := t.TempDir()
+	writeFiles(t, lyricsDir, map[string]string{"Muse/Hysteria.txt": "It's bugging me"})
+
+	t.Setenv("TEST_LYRICS_URL", server.URL)
+	t.Setenv("TEST_LYRICS_DIR", lyricsDir)
+	t.Setenv("TEST_LYRICS_TOKEN", "secret")
+
+	path := writeConfig(t, `
+providers:
+  - type: info
+    url: http://localhost:8081
+  - name: lyrics-dir
+    type: directory
+    path: ${TEST_LYRICS_DIR}
+  - name: lyrics-api
+    type: http
+    url: ${TEST_LYRICS_URL}/{group}/{song}
+    timeout: 2s
+    retries: 0
+    headers:
+      Authorization: Bearer ${TEST_LYRICS_TOKEN}
+    fields:
+      text: result.lyrics
+`)
+
+	providers, err := LoadProviders(path, testConfig(), log)
+	if err != nil {
+		t.Fatalf("LoadProviders() error = %v", err)
+	}
+
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	if want := []string{InfoProviderName, "lyrics-dir", "lyrics-api"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("providers = %v, want %v", names, want)
+	}
+
+	detail, err := providers[1].Lookup(context.Background(), "Muse", "Hysteria")
+	if err != nil {
+		t.Fatalf("directory Lookup() error = %v", err)
+	}
+	if detail.Text != "It's bugging me" {
+		t.Errorf("directory Lookup() text = %q, want %q", detail.Text, "It's bugging me")
+	}
+
+	detail, err = providers[2].Lookup(context.Background(), "Muse", "Supermassive")
+	if err != nil {
+		t.Fatalf("http Lookup() error = %v", err)
+	}
+	if want := (&models.SongDetail{Text: "Ooh baby"}); !reflect.DeepEqual(detail, want) {
+		t.Errorf("http Lookup() = %+v, want %+v", detail, want)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer secret")
+	}
+}
+
+func TestLoadProvidersErrors(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid yaml", content: "providers: [\n"},
+		{name: "no providers", content: "providers: []\n"},
+		{name: "unknown type", content: "providers:\n  - name: a\n    type: ftp\n"},
+		{name: "info without url", content: "providers:\n  - type: info\n"},
+		{name: "http without url", content: "providers:\n  - name: a\n    type: http\n    fields:\n      text: lyrics\n"},
+		{name: "http without name", content: "providers:\n  - type: http\n    url: http://localhost/{song}\n    fields:\n      text: lyrics\n"},
+		{name: "http without fields", content: "providers:\n  - name: a\n    type: http\n    url: http://localhost/{song}\n"},
+		{name: "directory without path", content: "providers:\n  - name: a\n    type: directory\n"},
+		{name: "missing directory", content: "providers:\n  - name: a\n    type: directory\n    path: ./no-such-dir\n"},
+		{
+			name:    "duplicate name",
+			content: "providers:\n  - name: a\n    type: info\n    url: http://localhost:8081\n  - name: a\n    type: info\n    url: http://localhost:8082\n",
+		},
+		{
+			name:    "duplicate default name",
+			content: "providers:\n  - type: info\n    url: http://localhost:8081\n  - type: info\n    url: http://localhost:8082\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadProviders(writeConfig(t, tt.content), testConfig(), log); err == nil {
+				t.Error("LoadProviders() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, err := LoadProviders(filepath.Join(t.TempDir(), "missing.yaml"), testConfig(), log); err == nil {
+		t.Error("LoadProviders() of a missing file error = nil, want an error")
+	}
+}
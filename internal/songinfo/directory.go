@@ -0,0 +1,118 @@
+package songinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryProviderName — имя локального каталога текстов по умолчанию.
+const DirectoryProviderName = "lyrics-dir"
+
+// directoryFile — содержимое файла .json или .yaml в каталоге текстов.
+type directoryFile struct {
+	ReleaseDate string `json:"release_date" yaml:"release_date"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+}
+
+// directoryExtensions — расширения файлов в порядке, в котором они ищутся.
+var directoryExtensions = []string{".json", ".yaml", ".yml", ".txt"}
+
+// DirectoryProvider читает сведения о песнях из локального каталога: файл <dir>/<группа>/<песня>.json,
+// .yaml, .yml или .txt. Файлы JSON и YAML содержат поля release_date, text и link, файл .txt — только текст.
+type DirectoryProvider struct {
+	name string
+	dir  string
+}
+
+func NewDirectoryProvider(name, dir string) (*DirectoryProvider, error) {
+	if name == "" {
+		name = DirectoryProviderName
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("provider %q: %w", name, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("provider %q: %s is not a directory", name, dir)
+	}
+
+	return &DirectoryProvider{name: name, dir: dir}, nil
+}
+
+// Name возвращает имя источника.
+func (p *DirectoryProvider) Name() string {
+	return p.name
+}
+
+// Lookup читает файл песни. Если файла нет, возвращается ErrNotFound, если его не удалось
+// прочитать или разобрать — ошибка, оборачивающая ErrInvalidResponse.
+func (p *DirectoryProvider) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Имена приходят от клиента, поэтому не даем им выйти за пределы каталога
+	if !safeFileName(group) || !safeFileName(song) {
+		return nil, ErrNotFound
+	}
+
+	base := filepath.Join(p.dir, group, song)
+	for _, ext := range directoryExtensions {
+		data, err := os.ReadFile(base + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: read %s: %v", ErrInvalidResponse, base+ext, err)
+		}
+
+		detail, err := decodeDirectoryFile(ext, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse %s: %v", ErrInvalidResponse, base+ext, err)
+		}
+		if detail.ReleaseDate == "" && detail.Text == "" && detail.Link == "" {
+			return nil, ErrNotFound
+		}
+
+		return detail, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func decodeDirectoryFile(ext string, data []byte) (*models.SongDetail, error) {
+	var file directoryFile
+
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	default:
+		file.Text = strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n"))
+	}
+
+	return &models.SongDetail{ReleaseDate: file.ReleaseDate, Text: file.Text, Link: file.Link}, nil
+}
+
+// safeFileName сообщает, можно ли использовать имя как имя файла внутри каталога.
+func safeFileName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`) && !strings.ContainsRune(name, 0)
+}
@@ -0,0 +1,119 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles создает в dir файлы с путями относительно него.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func TestDirectoryProviderLookup(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "lyrics")
+	writeFiles(t, root, map[string]string{
+		"lyrics/Muse/Hysteria.json":    `{"release_date":"01.12.2003","text":"It's bugging me","link":"https://example.com/1"}`,
+		"lyrics/Muse/Starlight.yaml":   "release_date: 2006-09-04\ntext: |\n  Far away\n  This ship is taking me\n",
+		"lyrics/Muse/Uprising.yml":     "link: https://example.com/3\n",
+		"lyrics/Muse/Madness.txt":      "\r\n  I can't get these memories\r\nout of my mind  \r\n",
+		"lyrics/Muse/Both.json":        `{"text":"from json"}`,
+		"lyrics/Muse/Both.txt":         "from txt",
+		"lyrics/Muse/Empty.json":       `{}`,
+		"lyrics/Muse/Broken.json":      `{"text":`,
+		"lyrics/Кино/Группа крови.txt": "Тёплое место",
+		"secret.txt":                   "outside the directory",
+	})
+
+	p, err := NewDirectoryProvider("", dir)
+	if err != nil {
+		t.Fatalf("NewDirectoryProvider() error = %v", err)
+	}
+	if p.Name() != DirectoryProviderName {
+		t.Errorf("Name() = %q, want %q", p.Name(), DirectoryProviderName)
+	}
+
+	tests := []struct {
+		name    string
+		group   string
+		song    string
+		want    *models.SongDetail
+		wantErr error
+	}{
+		{
+			name: "json", group: "Muse", song: "Hysteria",
+			want: &models.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com/1"},
+		},
+		{
+			name: "yaml keeps line breaks", group: "Muse", song: "Starlight",
+			want: &models.SongDetail{ReleaseDate: "2006-09-04", Text: "Far away\nThis ship is taking me\n"},
+		},
+		{name: "yml", group: "Muse", song: "Uprising", want: &models.SongDetail{Link: "https://example.com/3"}},
+		{
+			name: "txt is trimmed lyrics", group: "Muse", song: "Madness",
+			want: &models.SongDetail{Text: "I can't get these memories\nout of my mind"},
+		},
+		{name: "json before txt", group: "Muse", song: "Both", want: &models.SongDetail{Text: "from json"}},
+		{name: "cyrillic names", group: "Кино", song: "Группа крови", want: &models.SongDetail{Text: "Тёплое место"}},
+		{name: "missing file", group: "Muse", song: "Resistance", wantErr: ErrNotFound},
+		{name: "file without fields", group: "Muse", song: "Empty", wantErr: ErrNotFound},
+		{name: "broken file", group: "Muse", song: "Broken", wantErr: ErrInvalidResponse},
+		{name: "parent directory", group: "..", song: "secret", wantErr: ErrNotFound},
+		{name: "path in song", group: "Muse", song: "../../secret", wantErr: ErrNotFound},
+		{name: "empty group", group: "", song: "Hysteria", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Lookup(context.Background(), tt.group, tt.song)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDirectoryProvider(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"file.txt": "not a directory"})
+
+	p, err := NewDirectoryProvider("local", root)
+	if err != nil {
+		t.Fatalf("NewDirectoryProvider() error = %v", err)
+	}
+	if p.Name() != "local" {
+		t.Errorf("Name() = %q, want %q", p.Name(), "local")
+	}
+
+	if _, err := NewDirectoryProvider("", filepath.Join(root, "missing")); err == nil {
+		t.Error("NewDirectoryProvider(missing) error = nil, want an error")
+	}
+	if _, err := NewDirectoryProvider("", filepath.Join(root, "file.txt")); err == nil {
+		t.Error("NewDirectoryProvider(file) error = nil, want an error")
+	}
+}
@@ -0,0 +1,35 @@
+package songinfo
+
+import "time"
+
+// Hooks — необязательные обработчики событий клиента для метрик. Вызываются синхронно
+// в горутине запроса, поэтому должны быть быстрыми. Незаданные обработчики пропускаются.
+type Hooks struct {
+	// OnAttempt вызывается после каждой попытки: attempt считается с нуля, status — код ответа
+	// (0, если ответ не получен), err — ошибка попытки или nil.
+	OnAttempt func(attempt, status int, duration time.Duration, err error)
+	// OnRetry вызывается перед ожиданием повтора с номером attempt.
+	OnRetry func(attempt int, wait time.Duration, err error)
+	// OnReject вызывается, когда разомкнутый выключатель отклоняет запрос без обращения к API.
+	OnReject func()
+	// OnStateChange вызывается при смене состояния выключателя.
+	OnStateChange func(from, to State)
+}
+
+func (h Hooks) attempt(attempt, status int, duration time.Duration, err error) {
+	if h.OnAttempt != nil {
+		h.OnAttempt(attempt, status, duration, err)
+	}
+}
+
+func (h Hooks) retry(attempt int, wait time.Duration, err error) {
+	if h.OnRetry != nil {
+		h.OnRetry(attempt, wait, err)
+	}
+}
+
+func (h Hooks) reject() {
+	if h.OnReject != nil {
+		h.OnReject()
+	}
+}
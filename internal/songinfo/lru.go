@@ -0,0 +1,117 @@
+package songinfo
+
+import (
+	"container/list"
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"sync"
+	"time"
+)
+
+// LRUCache — CacheStore в памяти процесса, ограниченный числом записей. При переполнении
+// вытесняется запись, к которой дольше всего не обращались. Безопасен для одновременного использования.
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	// order хранит записи от недавно использованных к давно не использованным.
+	order *list.List
+}
+
+type lruItem struct {
+	key   string
+	entry models.InfoCacheEntry
+}
+
+// NewLRUCache создает кэш не больше чем на size записей.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: size, items: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *LRUCache) Get(ctx context.Context, key string) (*models.InfoCacheEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	item := element.Value.(*lruItem)
+	if !time.Now().Before(item.entry.ExpiresAt) {
+		c.removeLocked(element)
+		return nil, nil
+	}
+
+	c.order.MoveToFront(element)
+
+	entry := item.entry
+	entry.Detail = *copyDetail(&item.entry.Detail)
+	return &entry, nil
+}
+
+func (c *LRUCache) Set(ctx context.Context, key string, entry models.InfoCacheEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry.Detail = *copyDetail(&entry.Detail)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+
+	for c.order.Len() > c.size {
+		c.removeLocked(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *LRUCache) Delete(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return false, nil
+	}
+	c.removeLocked(element)
+
+	return true, nil
+}
+
+func (c *LRUCache) Clear(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cleared := len(c.items)
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+
+	return cleared, nil
+}
+
+func (c *LRUCache) removeLocked(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*lruItem).key)
+}
@@ -0,0 +1,122 @@
+package songinfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FieldMapping задает, где в JSON-ответе стороннего API лежат поля сведений. Путь записывается
+// через точку, элементы массива — по номеру: "data.lyrics", "results.0.release_date".
+// Пустой путь означает, что API это поле не дает.
+type FieldMapping struct {
+	ReleaseDate string `yaml:"release_date"`
+	Text        string `yaml:"text"`
+	Link        string `yaml:"link"`
+}
+
+// NewMappedClient создает клиент стороннего API. В urlTemplate вместо {group} и {song} подставляются
+// группа и название песни: в пути — с экранированием для пути, в строке запроса — для параметров.
+// Если в ответе нет ни одного поля из mapping, песня считается ненайденной.
+func NewMappedClient(name, urlTemplate string, mapping FieldMapping, cfg Config, log *slog.Logger) (*Client, error) {
+	if mapping == (FieldMapping{}) {
+		return nil, fmt.Errorf("provider %q: field mapping is empty", name)
+	}
+	if _, err := url.Parse(expandTemplate(urlTemplate, "group", "song")); err != nil {
+		return nil, fmt.Errorf("provider %q: invalid url: %w", name, err)
+	}
+
+	buildURL := func(group, song string) string {
+		return expandTemplate(urlTemplate, group, song)
+	}
+	decode := func(body []byte) (*models.SongDetail, error) {
+		return decodeMapped(body, mapping)
+	}
+
+	return newClient(name, buildURL, decode, cfg, log), nil
+}
+
+// expandTemplate подставляет группу и песню в шаблон адреса.
+func expandTemplate(template, group, song string) string {
+	path, query, hasQuery := strings.Cut(template, "?")
+
+	path = strings.NewReplacer("{group}", url.PathEscape(group), "{song}", url.PathEscape(song)).Replace(path)
+	if !hasQuery {
+		return path
+	}
+
+	query = strings.NewReplacer("{group}", url.QueryEscape(group), "{song}", url.QueryEscape(song)).Replace(query)
+	return path + "?" + query
+}
+
+func decodeMapped(body []byte, mapping FieldMapping) (*models.SongDetail, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var detail models.SongDetail
+	for _, field := range []struct {
+		path string
+		dst  *string
+	}{
+		{mapping.ReleaseDate, &detail.ReleaseDate},
+		{mapping.Text, &detail.Text},
+		{mapping.Link, &detail.Link},
+	} {
+		if field.path == "" {
+			continue
+		}
+		value, err := lookupPath(doc, field.path)
+		if err != nil {
+			return nil, err
+		}
+		*field.dst = value
+	}
+
+	if detail.ReleaseDate == "" && detail.Text == "" && detail.Link == "" {
+		return nil, ErrNotFound
+	}
+
+	return &detail, nil
+}
+
+// lookupPath достает строку по пути через точку. Отсутствующее поле и null дают пустую строку,
+// числа и логические значения приводятся к строке, объект или массив в конце пути — ошибка.
+func lookupPath(doc interface{}, path string) (string, error) {
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", nil
+			}
+			current = node[i]
+		default:
+			return "", nil
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	default:
+		return "", fmt.Errorf("field %q is not a scalar value", path)
+	}
+}
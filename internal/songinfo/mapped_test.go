@@ -0,0 +1,172 @@
+package songinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		group    string
+		song     string
+		want     string
+	}{
+		{
+			name:     "path and query",
+			template: "https://api.example.com/v1/{group}/{song}?lang=en",
+			group:    "Muse", song: "Hysteria",
+			want: "https://api.example.com/v1/Muse/Hysteria?lang=en",
+		},
+		{
+			name:     "slash stays inside the path segment",
+			template: "https://api.example.com/{group}/{song}",
+			group:    "AC/DC", song: "T.N.T.",
+			want: "https://api.example.com/AC%2FDC/T.N.T.",
+		},
+		{
+			name:     "space is escaped for the path and the query",
+			template: "https://api.example.com/{song}?group={group}",
+			group:    "Rock Paper", song: "Scissors Cut",
+			want: "https://api.example.com/Scissors%20Cut?group=Rock+Paper",
+		},
+		{
+			name:     "query delimiters in names",
+			template: "https://api.example.com/search?artist={group}&title={song}",
+			group:    "Simon & Garfunkel", song: "Why? #1",
+			want: "https://api.example.com/search?artist=Simon+%26+Garfunkel&title=Why%3F+%231",
+		},
+		{
+			name:     "question mark in the path",
+			template: "https://api.example.com/{song}",
+			group:    "Muse", song: "Why?",
+			want: "https://api.example.com/Why%3F",
+		},
+		{
+			name:     "cyrillic",
+			template: "https://api.example.com/{group}?song={song}",
+			group:    "Кино", song: "Группа крови",
+			want: "https://api.example.com/%D0%9A%D0%B8%D0%BD%D0%BE?song=%D0%93%D1%80%D1%83%D0%BF%D0%BF%D0%B0+%D0%BA%D1%80%D0%BE%D0%B2%D0%B8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandTemplate(tt.template, tt.group, tt.song); got != tt.want {
+				t.Errorf("expandTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	const body = `{"result":{"lyrics":"la la","year":2006,"explicit":false,"cover":null,"tags":["rock","alt"],
+		"meta":{"id":1},"items":[{"url":"https://example.com/1"}]}}`
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(body)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "result.lyrics", want: "la la"},
+		{path: "result.year", want: "2006"},
+		{path: "result.explicit", want: "false"},
+		{path: "result.cover", want: ""},
+		{path: "result.tags.1", want: "alt"},
+		{path: "result.items.0.url", want: "https://example.com/1"},
+		{path: "result.missing", want: ""},
+		{path: "result.tags.2", want: ""},
+		{path: "result.tags.-1", want: ""},
+		{path: "result.tags.first", want: ""},
+		{path: "result.lyrics.text", want: ""},
+		{path: "result.meta", wantErr: true},
+		{path: "result.tags", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := lookupPath(doc, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("lookupPath() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookupPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("lookupPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMappedClient(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mapping := FieldMapping{Text: "result.lyrics", Link: "result.url"}
+
+	if _, err := NewMappedClient("lyrics-api", "https://api.example.com/{song}", FieldMapping{}, testConfig(), log); err == nil {
+		t.Error("NewMappedClient() with an empty mapping error = nil, want an error")
+	}
+	if _, err := NewMappedClient("lyrics-api", "https://api.example.com/%zz/{song}", mapping, testConfig(), log); err == nil {
+		t.Error("NewMappedClient() with an invalid url error = nil, want an error")
+	}
+
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.EscapedPath(), r.URL.RawQuery
+		switch r.URL.Query().Get("title") {
+		case "Hysteria":
+			_, _ = io.WriteString(w, `{"result":{"lyrics":"It's bugging me","url":"https://example.com/1","year":2003}}`)
+		case "Unknown":
+			_, _ = io.WriteString(w, `{"result":{"lyrics":null}}`)
+		default:
+			_, _ = io.WriteString(w, `{"result":{"lyrics":{"text":"nested"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewMappedClient("lyrics-api", server.URL+"/v1/{group}?title={song}", mapping, testConfig(), log)
+	if err != nil {
+		t.Fatalf("NewMappedClient() error = %v", err)
+	}
+	if client.Name() != "lyrics-api" {
+		t.Errorf("Name() = %q, want %q", client.Name(), "lyrics-api")
+	}
+
+	detail, err := client.Lookup(context.Background(), "AC/DC", "Hysteria")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if want := (&models.SongDetail{Text: "It's bugging me", Link: "https://example.com/1"}); !reflect.DeepEqual(detail, want) {
+		t.Errorf("Lookup() = %+v, want %+v", detail, want)
+	}
+	if gotPath != "/v1/AC%2FDC" || gotQuery != "title=Hysteria" {
+		t.Errorf("request = %s?%s, want /v1/AC%%2FDC?title=Hysteria", gotPath, gotQuery)
+	}
+
+	if _, err := client.Lookup(context.Background(), "Muse", "Unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of a response without fields error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := client.Lookup(context.Background(), "Muse", "Nested"); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Lookup() of a non-scalar field error = %v, want %v", err, ErrInvalidResponse)
+	}
+}
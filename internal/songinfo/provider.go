@@ -0,0 +1,97 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"log/slog"
+)
+
+// MetadataProvider — источник сведений о песне. Lookup возвращает те поля, которые источник знает,
+// остальные оставляет пустыми; если сведений о песне нет совсем, возвращается ErrNotFound.
+// Name попадает в сведения о том, какой источник заполнил поле.
+type MetadataProvider interface {
+	Name() string
+	Lookup(ctx context.Context, group, song string) (*models.SongDetail, error)
+}
+
+// Chain опрашивает источники по порядку приоритета и объединяет ответы по полям: каждое поле
+// берется у первого источника, который его знает. Опрос прекращается, как только заполнены все поля.
+type Chain struct {
+	providers []MetadataProvider
+	log       *slog.Logger
+}
+
+func NewChain(providers []MetadataProvider, log *slog.Logger) *Chain {
+	return &Chain{providers: providers, log: log}
+}
+
+// Name возвращает имя цепочки.
+func (c *Chain) Name() string {
+	return "chain"
+}
+
+// Providers возвращает источники цепочки в порядке приоритета.
+func (c *Chain) Providers() []MetadataProvider {
+	return c.providers
+}
+
+// Lookup собирает сведения о песне из всех источников. Sources результата указывает источник
+// каждого заполненного поля. Ошибка источника, кроме отмены ctx, не прерывает опрос: она
+// записывается в лог, и поля берутся у следующих источников. Если хотя бы один источник ответил
+// без ошибки, возвращаются собранные сведения, даже пустые. Иначе возвращается ErrNotFound, когда
+// все ответили «не найдено», или первая ошибка.
+func (c *Chain) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	merged := &models.SongDetail{Sources: make(map[string]string)}
+	answered := false
+	var firstErr error
+
+	for _, provider := range c.providers {
+		detail, err := provider.Lookup(ctx, group, song)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if !errors.Is(err, ErrNotFound) {
+				c.log.Warn("Metadata provider failed",
+					slog.String("provider", provider.Name()),
+					slog.String("group", group),
+					slog.String("song", song),
+					slog.Any("error", err))
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			continue
+		}
+
+		answered = true
+		merge(merged, detail, provider.Name())
+		if merged.Complete() {
+			break
+		}
+	}
+
+	if !answered {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, ErrNotFound
+	}
+
+	return merged, nil
+}
+
+// merge заполняет пустые поля dst полями src и записывает источник каждого заполненного поля.
+func merge(dst, src *models.SongDetail, source string) {
+	fill := func(field string, dstValue *string, value string) {
+		if *dstValue == "" && value != "" {
+			*dstValue = value
+			dst.Sources[field] = source
+		}
+	}
+
+	fill(models.FieldReleaseDate, &dst.ReleaseDate, src.ReleaseDate)
+	fill(models.FieldText, &dst.Text, src.Text)
+	fill(models.FieldLink, &dst.Link, src.Link)
+}
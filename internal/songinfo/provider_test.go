@@ -0,0 +1,141 @@
+package songinfo
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// stubProvider отвечает заданными сведениями или ошибкой и считает обращения.
+type stubProvider struct {
+	name   string
+	detail models.SongDetail
+	err    error
+	calls  int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) Lookup(ctx context.Context, group, song string) (*models.SongDetail, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	detail := p.detail
+	return &detail, nil
+}
+
+func newTestChain(providers ...*stubProvider) *Chain {
+	list := make([]MetadataProvider, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, p)
+	}
+	return NewChain(list, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestChainLookup(t *testing.T) {
+	full := models.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/a"}
+
+	tests := []struct {
+		name      string
+		providers []*stubProvider
+		want      *models.SongDetail
+		wantErr   error
+		wantCalls []int
+	}{
+		{
+			name: "fields merged by priority",
+			providers: []*stubProvider{
+				{name: "a", detail: models.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby"}},
+				{name: "b", detail: models.SongDetail{Text: "other text", Link: "https://example.com/b"}},
+			},
+			want: &models.SongDetail{
+				ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/b",
+				Sources: map[string]string{"release_date": "a", "text": "a", "link": "b"},
+			},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "stops once complete",
+			providers: []*stubProvider{{name: "a", detail: full}, {name: "b", detail: full}},
+			want: &models.SongDetail{
+				ReleaseDate: full.ReleaseDate, Text: full.Text, Link: full.Link,
+				Sources: map[string]string{"release_date": "a", "text": "a", "link": "a"},
+			},
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "failure is skipped",
+			providers: []*stubProvider{{name: "a", err: ErrUnavailable}, {name: "b", detail: models.SongDetail{Link: full.Link}}},
+			want:      &models.SongDetail{Link: full.Link, Sources: map[string]string{"link": "b"}},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "empty answer gives empty details",
+			providers: []*stubProvider{{name: "a"}},
+			want:      &models.SongDetail{Sources: map[string]string{}},
+			wantCalls: []int{1},
+		},
+		{
+			name:      "empty answer wins over failures",
+			providers: []*stubProvider{{name: "a", err: ErrUnavailable}, {name: "b"}, {name: "c", err: ErrNotFound}},
+			want:      &models.SongDetail{Sources: map[string]string{}},
+			wantCalls: []int{1, 1, 1},
+		},
+		{
+			name:      "not found everywhere",
+			providers: []*stubProvider{{name: "a", err: ErrNotFound}, {name: "b", err: ErrNotFound}},
+			wantErr:   ErrNotFound,
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "failure reported over not found",
+			providers: []*stubProvider{{name: "a", err: ErrNotFound}, {name: "b", err: ErrUnavailable}, {name: "c", err: ErrCircuitOpen}},
+			wantErr:   ErrUnavailable,
+			wantCalls: []int{1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestChain(tt.providers...).Lookup(context.Background(), "Muse", "Supermassive")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+
+			for i, p := range tt.providers {
+				if p.calls != tt.wantCalls[i] {
+					t.Errorf("provider %s called %d times, want %d", p.name, p.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChainLookupCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	first := &stubProvider{name: "a", err: context.Canceled}
+	second := &stubProvider{name: "b", detail: models.SongDetail{Text: "Ooh baby"}}
+
+	if _, err := newTestChain(first, second).Lookup(ctx, "Muse", "Supermassive"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Lookup() error = %v, want %v", err, context.Canceled)
+	}
+	if second.calls != 0 {
+		t.Errorf("provider b called %d times after cancellation, want 0", second.calls)
+	}
+}
@@ -0,0 +1,16 @@
+package storage
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте автора изменений, который попадет в историю изменений песен.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора изменений или пустую строку, если он неизвестен.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"strings"
+	"time"
+)
+
+// Способы сравнения в фильтрах списка песен.
+const (
+	// FilterContains — поле содержит строку без учета регистра.
+	FilterContains = "contains"
+	// FilterPrefix — поле начинается со строки без учета регистра.
+	FilterPrefix = "prefix"
+	// FilterFrom — дата выпуска не раньше даты YYYY-MM-DD; песни без даты не подходят.
+	FilterFrom = "from"
+	// FilterTo — дата выпуска не позже даты YYYY-MM-DD; песни без даты не подходят.
+	FilterTo = "to"
+	// FilterIn — поле совпадает с одной из строк []string.
+	FilterIn = "in"
+	// FilterPresent — поле заполнено (true) или пусто (false).
+	FilterPresent = "present"
+)
+
+// SongFilter описывает фильтр списка песен: поле песни и способ сравнения с ним.
+type SongFilter struct {
+	Field string
+	Op    string
+}
+
+// SongFilters перечисляет фильтры списка песен помимо точного совпадения полей и album, по ключам
+// карты фильтров. Значение фильтра FilterIn — []string, FilterPresent — bool, остальных — string.
+var SongFilters = map[string]SongFilter{
+	"group_contains":    {Field: "group", Op: FilterContains},
+	"group_prefix":      {Field: "group", Op: FilterPrefix},
+	"song_contains":     {Field: "song", Op: FilterContains},
+	"song_prefix":       {Field: "song", Op: FilterPrefix},
+	"text_contains":     {Field: "text", Op: FilterContains},
+	"release_date_from": {Field: "release_date", Op: FilterFrom},
+	"release_date_to":   {Field: "release_date", Op: FilterTo},
+	"groups":            {Field: "group", Op: FilterIn},
+	"has_link":          {Field: "link", Op: FilterPresent},
+	"has_text":          {Field: "text", Op: FilterPresent},
+}
+
+// MatchSongFilter сообщает, подходит ли песня под фильтр со значением value.
+func MatchSongFilter(song *models.Song, filter SongFilter, value interface{}) bool {
+	actual, ok := SongFieldValue(song, filter.Field)
+	if !ok {
+		return false
+	}
+
+	switch filter.Op {
+	case FilterContains:
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(value.(string)))
+	case FilterPrefix:
+		return strings.HasPrefix(strings.ToLower(actual), strings.ToLower(value.(string)))
+	case FilterFrom:
+		return actual != "" && ReleaseDateISO(actual) >= value.(string)
+	case FilterTo:
+		return actual != "" && ReleaseDateISO(actual) <= value.(string)
+	case FilterIn:
+		for _, v := range value.([]string) {
+			if actual == v {
+				return true
+			}
+		}
+		return false
+	case FilterPresent:
+		return (actual != "") == value.(bool)
+	default:
+		return false
+	}
+}
+
+// ReleaseDateISO приводит дату выпуска к виду YYYY-MM-DD, чтобы даты можно было сравнивать как строки:
+// источники сведений отдают дату как DD.MM.YYYY, редакторы вводят YYYY-MM-DD. Другие значения
+// возвращаются без изменений.
+func ReleaseDateISO(value string) string {
+	if date, err := time.Parse("02.01.2006", value); err == nil {
+		return date.Format(time.DateOnly)
+	}
+	return value
+}
@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"sort"
+)
+
+func (s *Storage) AddAlbum(ctx context.Context, album *models.Album, songIDs []int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAlbumUniqueLocked(album.ArtistID, album.Title, 0); err != nil {
+		if err == storage.ErrAlbumExists {
+			s.log.Warn("Attempt to add existing album",
+				slog.Int("artist_id", album.ArtistID),
+				slog.String("title", album.Title))
+		}
+		return 0, err
+	}
+
+	if err := s.checkSongsLocked(songIDs); err != nil {
+		return 0, err
+	}
+
+	id := s.nextAlbumID
+	s.nextAlbumID++
+
+	stored := *album
+	stored.ID = id
+	stored.Tracks = nil
+	s.albums[id] = &stored
+	s.tracks[id] = append([]int(nil), songIDs...)
+
+	return id, nil
+}
+
+func (s *Storage) GetAlbum(ctx context.Context, id int) (*models.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.albums[id]
+	if !ok {
+		return nil, storage.ErrAlbumNotFound
+	}
+
+	album := s.copyAlbumLocked(stored)
+	for _, song := range s.albumSongsLocked(id) {
+		album.Tracks = append(album.Tracks, models.AlbumTrack{
+			Position: len(album.Tracks) + 1,
+			SongID:   song.ID,
+			Song:     song.Song,
+		})
+	}
+
+	return album, nil
+}
+
+func (s *Storage) GetAlbums(ctx context.Context, artistID int, limit, offset int) ([]*models.Album, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// artistID = 0 означает альбомы всех исполнителей
+	var albums []*models.Album
+	for _, stored := range s.albums {
+		if artistID == 0 || stored.ArtistID == artistID {
+			albums = append(albums, s.copyAlbumLocked(stored))
+		}
+	}
+
+	sort.Slice(albums, func(i, j int) bool {
+		return albums[i].ID < albums[j].ID
+	})
+
+	if offset >= len(albums) {
+		return nil, nil
+	}
+
+	end := offset + limit
+	if end > len(albums) {
+		end = len(albums)
+	}
+
+	return albums[offset:end], nil
+}
+
+func (s *Storage) UpdateAlbum(ctx context.Context, id int, title *string, artistID *int, releaseDate, coverLink *string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.albums[id]
+	if !ok {
+		return storage.ErrAlbumNotFound
+	}
+
+	newTitle, newArtistID := stored.Title, stored.ArtistID
+	if title != nil {
+		newTitle = *title
+	}
+	if artistID != nil {
+		newArtistID = *artistID
+	}
+
+	if newTitle != stored.Title || newArtistID != stored.ArtistID {
+		if err := s.checkAlbumUniqueLocked(newArtistID, newTitle, id); err != nil {
+			return err
+		}
+	}
+
+	stored.Title = newTitle
+	stored.ArtistID = newArtistID
+	if releaseDate != nil {
+		stored.ReleaseDate = *releaseDate
+	}
+	if coverLink != nil {
+		stored.CoverLink = *coverLink
+	}
+
+	return nil
+}
+
+func (s *Storage) SetAlbumTracks(ctx context.Context, id int, songIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.albums[id]; !ok {
+		return storage.ErrAlbumNotFound
+	}
+
+	if err := s.checkSongsLocked(songIDs); err != nil {
+		return err
+	}
+
+	s.tracks[id] = append([]int(nil), songIDs...)
+
+	return nil
+}
+
+func (s *Storage) DeleteAlbum(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.albums[id]; !ok {
+		return storage.ErrAlbumNotFound
+	}
+
+	delete(s.albums, id)
+	delete(s.tracks, id)
+
+	return nil
+}
+
+func (s *Storage) GetAlbumSongs(ctx context.Context, id int) ([]*models.AlbumSong, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.albums[id]; !ok {
+		return nil, storage.ErrAlbumNotFound
+	}
+
+	var songs []*models.AlbumSong
+	for _, song := range s.albumSongsLocked(id) {
+		songs = append(songs, &models.AlbumSong{
+			Position: len(songs) + 1,
+			Song:     *song,
+		})
+	}
+
+	return songs, nil
+}
+
+// checkAlbumUniqueLocked проверяет, что исполнитель существует и у него нет другого альбома с таким названием.
+// exceptID исключает из проверки редактируемый альбом. Вызывающий должен держать блокировку.
+func (s *Storage) checkAlbumUniqueLocked(artistID int, title string, exceptID int) error {
+	if _, ok := s.artists[artistID]; !ok {
+		return storage.ErrArtistNotFound
+	}
+
+	for _, album := range s.albums {
+		if album.ID != exceptID && album.ArtistID == artistID && album.Title == title {
+			return storage.ErrAlbumExists
+		}
+	}
+
+	return nil
+}
+
+// checkSongsLocked проверяет, что все песни трек-листа существуют. Вызывающий должен держать блокировку.
+func (s *Storage) checkSongsLocked(songIDs []int) error {
+	for _, songID := range songIDs {
+		if _, ok := s.liveLocked(songID); !ok {
+			return fmt.Errorf("song %d: %w", songID, storage.ErrSongNotFound)
+		}
+	}
+	return nil
+}
+
+// albumSongsLocked возвращает песни трек-листа по порядку, пропуская песни из корзины.
+// Вызывающий должен держать блокировку.
+func (s *Storage) albumSongsLocked(albumID int) []*models.Song {
+	var songs []*models.Song
+	for _, songID := range s.tracks[albumID] {
+		if song, ok := s.liveLocked(songID); ok {
+			songs = append(songs, song)
+		}
+	}
+	return songs
+}
+
+// removeFromTracksLocked убирает удаленную песню из всех трек-листов. Вызывающий должен держать блокировку на запись.
+func (s *Storage) removeFromTracksLocked(songID int) {
+	for albumID, songIDs := range s.tracks {
+		kept := songIDs[:0]
+		for _, id := range songIDs {
+			if id != songID {
+				kept = append(kept, id)
+			}
+		}
+		s.tracks[albumID] = kept
+	}
+}
+
+// copyAlbumLocked возвращает копию альбома без трек-листа с актуальным именем исполнителя.
+// Вызывающий должен держать блокировку.
+func (s *Storage) copyAlbumLocked(album *models.Album) *models.Album {
+	albumCopy := *album
+	albumCopy.Tracks = nil
+	if artist, ok := s.artists[album.ArtistID]; ok {
+		albumCopy.Artist = artist.Name
+	}
+	return &albumCopy
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"sort"
+)
+
+// ensureArtistLocked возвращает id исполнителя с указанным именем, создавая его при необходимости.
+// Вызывающий должен держать блокировку на запись.
+func (s *Storage) ensureArtistLocked(name string) int {
+	if artist := s.findArtistLocked(name); artist != nil {
+		return artist.ID
+	}
+
+	id := s.nextArtistID
+	s.nextArtistID++
+	s.artists[id] = &models.Artist{ID: id, Name: name, Links: []string{}}
+
+	return id
+}
+
+// findArtistLocked ищет исполнителя по имени. Вызывающий должен держать блокировку.
+func (s *Storage) findArtistLocked(name string) *models.Artist {
+	for _, artist := range s.artists {
+		if artist.Name == name {
+			return artist
+		}
+	}
+	return nil
+}
+
+func (s *Storage) AddArtist(ctx context.Context, artist *models.Artist) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findArtistLocked(artist.Name) != nil {
+		s.log.Warn("Attempt to add existing artist",
+			slog.String("name", artist.Name))
+		return 0, storage.ErrArtistExists
+	}
+
+	id := s.nextArtistID
+	s.nextArtistID++
+
+	stored := copyArtist(artist)
+	stored.ID = id
+	s.artists[id] = stored
+
+	return id, nil
+}
+
+func (s *Storage) GetArtist(ctx context.Context, id int) (*models.Artist, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artist, ok := s.artists[id]
+	if !ok {
+		return nil, storage.ErrArtistNotFound
+	}
+
+	return copyArtist(artist), nil
+}
+
+func (s *Storage) GetArtists(ctx context.Context, limit, offset int) ([]*models.Artist, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artists := make([]*models.Artist, 0, len(s.artists))
+	for _, artist := range s.artists {
+		artists = append(artists, copyArtist(artist))
+	}
+
+	sort.Slice(artists, func(i, j int) bool {
+		return artists[i].ID < artists[j].ID
+	})
+
+	if offset >= len(artists) {
+		return nil, nil
+	}
+
+	end := offset + limit
+	if end > len(artists) {
+		end = len(artists)
+	}
+
+	return artists[offset:end], nil
+}
+
+func (s *Storage) UpdateArtist(ctx context.Context, id int, name, country *string, formedYear *int, description *string, links *[]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artist, ok := s.artists[id]
+	if !ok {
+		return storage.ErrArtistNotFound
+	}
+
+	if name != nil && *name != artist.Name {
+		if s.findArtistLocked(*name) != nil {
+			return storage.ErrArtistExists
+		}
+
+		// Название группы хранится и в песнях, поэтому переименовываем его везде сразу
+		for _, song := range s.songs {
+			if song.ArtistID == id {
+				before := *song
+				song.Group = *name
+				s.recordRevisionLocked(ctx, song.ID, models.RevisionUpdate, &before, song)
+			}
+		}
+		artist.Name = *name
+	}
+	if country != nil {
+		artist.Country = *country
+	}
+	if formedYear != nil {
+		year := *formedYear
+		artist.FormedYear = &year
+	}
+	if description != nil {
+		artist.Description = *description
+	}
+	if links != nil {
+		artist.Links = append([]string{}, *links...)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteArtist(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.artists[id]; !ok {
+		return storage.ErrArtistNotFound
+	}
+
+	for _, song := range s.songs {
+		if song.ArtistID == id {
+			return storage.ErrArtistHasSongs
+		}
+	}
+
+	for _, album := range s.albums {
+		if album.ArtistID == id {
+			return storage.ErrArtistHasAlbums
+		}
+	}
+
+	delete(s.artists, id)
+
+	return nil
+}
+
+func (s *Storage) GetArtistSongs(ctx context.Context, id int, limit, offset int) ([]*models.Song, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	_, ok := s.artists[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, storage.ErrArtistNotFound
+	}
+
+	var songs []*models.Song
+	for _, song := range s.filterSongs(nil) {
+		if song.ArtistID == id {
+			songs = append(songs, song)
+		}
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].ID < songs[j].ID
+	})
+
+	return paginate(songs, limit, offset), nil
+}
+
+func copyArtist(artist *models.Artist) *models.Artist {
+	artistCopy := *artist
+	artistCopy.Links = append([]string{}, artist.Links...)
+	if artist.FormedYear != nil {
+		year := *artist.FormedYear
+		artistCopy.FormedYear = &year
+	}
+	return &artistCopy
+}
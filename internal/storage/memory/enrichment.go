@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"sort"
+	"time"
+)
+
+type enrichmentTask struct {
+	id            int
+	songID        int
+	attempts      int
+	nextAttemptAt time.Time
+	lastError     string
+}
+
+func (s *Storage) enqueueEnrichmentLocked(songID int) {
+	id := s.nextTaskID
+	s.nextTaskID++
+
+	s.outbox[id] = &enrichmentTask{id: id, songID: songID, nextAttemptAt: time.Now()}
+}
+
+// removeEnrichmentLocked удаляет задачи обогащения окончательно удаленной песни.
+func (s *Storage) removeEnrichmentLocked(songID int) {
+	for id, task := range s.outbox {
+		if task.songID == songID {
+			delete(s.outbox, id)
+		}
+	}
+}
+
+func (s *Storage) ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]*models.EnrichmentTask, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	var due []*enrichmentTask
+	for _, task := range s.outbox {
+		if !task.nextAttemptAt.After(now) {
+			due = append(due, task)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttemptAt.Equal(due[j].nextAttemptAt) {
+			return due[i].nextAttemptAt.Before(due[j].nextAttemptAt)
+		}
+		return due[i].id < due[j].id
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	tasks := make([]*models.EnrichmentTask, 0, len(due))
+	for _, task := range due {
+		task.attempts++
+		task.nextAttemptAt = now.Add(lease)
+
+		song := s.songs[task.songID]
+		tasks = append(tasks, &models.EnrichmentTask{
+			ID:       task.id,
+			SongID:   task.songID,
+			Group:    song.Group,
+			Song:     song.Song,
+			Attempts: task.attempts,
+		})
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) CompleteEnrichment(ctx context.Context, taskID int, detail models.SongDetail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.outbox[taskID]
+	if !ok {
+		return nil
+	}
+	delete(s.outbox, taskID)
+
+	stored := s.songs[task.songID]
+	before := *stored
+
+	// Поля, заполненные вручную, пока песня ждала обогащения, не перезаписываются
+	storage.ApplyDetail(stored, detail)
+	stored.EnrichmentStatus = models.EnrichmentEnriched
+	s.refreshedAt[stored.ID] = time.Now()
+
+	if stored.Text != before.Text {
+		s.saveVersesLocked(stored.ID, stored.Text)
+	}
+	s.recordRevisionLocked(ctx, stored.ID, models.RevisionUpdate, &before, stored)
+
+	return nil
+}
+
+func (s *Storage) RetryEnrichment(ctx context.Context, taskID int, nextAttemptAt time.Time, lastError string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task, ok := s.outbox[taskID]; ok {
+		task.nextAttemptAt = nextAttemptAt
+		task.lastError = lastError
+	}
+
+	return nil
+}
+
+func (s *Storage) FailEnrichment(ctx context.Context, taskID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.outbox[taskID]
+	if !ok {
+		return nil
+	}
+	delete(s.outbox, taskID)
+
+	s.songs[task.songID].EnrichmentStatus = models.EnrichmentFailed
+
+	return nil
+}
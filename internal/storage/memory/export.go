@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"sort"
+)
+
+// ExportSongs выгружает копии песен, поэтому fn вызывается без удержания блокировки хранилища.
+func (s *Storage) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	matched := s.filterSongs(filters)
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID < matched[j].ID
+	})
+
+	for _, song := range matched {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"time"
+)
+
+func (s *Storage) GetInfoCache(ctx context.Context, key string) (*models.InfoCacheEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.infoCache[key]
+	if !ok || !time.Now().Before(entry.ExpiresAt) {
+		return nil, nil
+	}
+
+	entry.Detail.Sources = copySources(entry.Detail.Sources)
+	return &entry, nil
+}
+
+func (s *Storage) SaveInfoCache(ctx context.Context, key string, entry models.InfoCacheEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for cached, stored := range s.infoCache {
+		if !now.Before(stored.ExpiresAt) {
+			delete(s.infoCache, cached)
+		}
+	}
+
+	entry.Detail.Sources = copySources(entry.Detail.Sources)
+	s.infoCache[key] = entry
+
+	return nil
+}
+
+func (s *Storage) DeleteInfoCache(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.infoCache[key]
+	delete(s.infoCache, key)
+
+	return ok, nil
+}
+
+func (s *Storage) ClearInfoCache(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cleared := len(s.infoCache)
+	s.infoCache = make(map[string]models.InfoCacheEntry)
+
+	return cleared, nil
+}
+
+// copySources копирует карту источников, чтобы запись кэша не делила ее с вызывающим.
+func copySources(sources map[string]string) map[string]string {
+	if sources == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(sources))
+	for field, source := range sources {
+		result[field] = source
+	}
+	return result
+}
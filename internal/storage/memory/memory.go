@@ -0,0 +1,515 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage хранит песни в памяти процесса. Подходит для локального запуска и тестов.
+type Storage struct {
+	mu           sync.RWMutex
+	songs        map[int]*models.Song
+	nextID       int
+	artists      map[int]*models.Artist
+	nextArtistID int
+	albums       map[int]*models.Album
+	nextAlbumID  int
+	// tracks хранит трек-листы альбомов: id альбома -> id песен в порядке позиций.
+	tracks map[int][]int
+	// revisions хранит историю изменений песен: id песни -> правки по возрастанию номера.
+	revisions map[int][]*models.SongRevision
+	// outbox хранит очередь обогащения: id задачи -> задача.
+	outbox     map[int]*enrichmentTask
+	nextTaskID int
+	// refreshedAt хранит время последней сверки песен со сведениями; песни без записи ждут сверки.
+	refreshedAt map[int]time.Time
+	// proposals хранит предложения обновления: id предложения -> предложение.
+	proposals      map[int]*refreshProposal
+	nextProposalID int
+	// infoCache хранит кэш ответов источников сведений: ключ -> запись.
+	infoCache map[string]models.InfoCacheEntry
+	// verses хранит разобранные части текстов: id песни -> части.
+	verses map[int]*songVerses
+	log    *slog.Logger
+}
+
+func NewStorage(log *slog.Logger) *Storage {
+	return &Storage{
+		songs:          make(map[int]*models.Song),
+		nextID:         1,
+		artists:        make(map[int]*models.Artist),
+		nextArtistID:   1,
+		albums:         make(map[int]*models.Album),
+		nextAlbumID:    1,
+		tracks:         make(map[int][]int),
+		revisions:      make(map[int][]*models.SongRevision),
+		outbox:         make(map[int]*enrichmentTask),
+		nextTaskID:     1,
+		refreshedAt:    make(map[int]time.Time),
+		proposals:      make(map[int]*refreshProposal),
+		nextProposalID: 1,
+		infoCache:      make(map[string]models.InfoCacheEntry),
+		verses:         make(map[int]*songVerses),
+		log:            log,
+	}
+}
+
+func (s *Storage) AddSong(ctx context.Context, group, song string, detail models.SongDetail) (int, error) {
+	return s.addSong(ctx, group, song, detail, models.EnrichmentEnriched)
+}
+
+// AddSongForEnrichment сохраняет песню без сведений и сразу ставит ее в очередь обогащения.
+func (s *Storage) AddSongForEnrichment(ctx context.Context, group, song string) (int, error) {
+	return s.addSong(ctx, group, song, models.SongDetail{}, models.EnrichmentPending)
+}
+
+func (s *Storage) addSong(ctx context.Context, group, song string, detail models.SongDetail, status string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findLocked(group, song) != nil {
+		s.log.Warn("Attempt to add existing song",
+			slog.String("group", group),
+			slog.String("song", song))
+		return 0, storage.ErrSongExists
+	}
+
+	id := s.nextID
+	s.nextID++
+
+	s.songs[id] = &models.Song{
+		ID:               id,
+		Group:            group,
+		Song:             song,
+		ReleaseDate:      detail.ReleaseDate,
+		Text:             detail.Text,
+		Link:             detail.Link,
+		ArtistID:         s.ensureArtistLocked(group),
+		EnrichmentStatus: status,
+		Sources:          storage.DetailSources(detail),
+		CreatedAt:        time.Now().UTC(),
+	}
+	s.saveVersesLocked(id, detail.Text)
+	s.recordRevisionLocked(ctx, id, models.RevisionCreate, nil, s.songs[id])
+
+	if status == models.EnrichmentPending {
+		s.enqueueEnrichmentLocked(id)
+	} else {
+		s.refreshedAt[id] = time.Now()
+	}
+
+	s.log.Info("Song added successfully",
+		slog.Int("id", id),
+		slog.String("group", group),
+		slog.String("song", song))
+
+	return id, nil
+}
+
+func (s *Storage) GetSong(ctx context.Context, id int) (*models.Song, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.liveLocked(id)
+	if !ok {
+		return nil, storage.ErrSongNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+func (s *Storage) UpdateSong(ctx context.Context, id, version int, group, song *string, releaseDate *string, text *string, link *string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.liveLocked(id)
+	if !ok {
+		return storage.ErrSongNotFound
+	}
+	if version != 0 && stored.Version != version {
+		return storage.ErrSongVersionMismatch
+	}
+
+	// Новое название не должно совпадать с названием другой песни не из корзины
+	if group != nil || song != nil {
+		newGroup, newSong := stored.Group, stored.Song
+		if group != nil {
+			newGroup = *group
+		}
+		if song != nil {
+			newSong = *song
+		}
+		if existing := s.findLocked(newGroup, newSong); existing != nil && existing.ID != id {
+			return storage.ErrSongExists
+		}
+	}
+
+	before := *stored
+	stored.Sources = storage.ManualSources(stored, releaseDate, text, link)
+
+	if group != nil {
+		stored.Group = *group
+		stored.ArtistID = s.ensureArtistLocked(*group)
+	}
+	if song != nil {
+		stored.Song = *song
+	}
+	if releaseDate != nil {
+		stored.ReleaseDate = *releaseDate
+	}
+	if text != nil {
+		stored.Text = *text
+	}
+	if link != nil {
+		stored.Link = *link
+	}
+
+	if stored.Text != before.Text {
+		s.saveVersesLocked(id, stored.Text)
+	}
+	s.recordRevisionLocked(ctx, id, models.RevisionUpdate, &before, stored)
+
+	return nil
+}
+
+func (s *Storage) DeleteSong(ctx context.Context, id, version int) error {
+	const op = "storage.memory.DeleteSong"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.liveLocked(id)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if version != 0 && stored.Version != version {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongVersionMismatch)
+	}
+
+	// Песня переносится в корзину и удаляется окончательно только при очистке
+	s.recordRevisionLocked(ctx, stored.ID, models.RevisionDelete, stored, nil)
+	deletedAt := time.Now().UTC()
+	stored.DeletedAt = &deletedAt
+
+	return nil
+}
+
+func (s *Storage) GetFilteredSongs(ctx context.Context, filters map[string]interface{}, order []storage.SongSort, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.memory.GetFilteredSongs"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, key := range order {
+		if !storage.IsSongListSortKey(key.Key) {
+			return nil, fmt.Errorf("%s: unsupported sort key %q", op, key.Key)
+		}
+	}
+
+	matched := s.filterSongs(filters)
+
+	// Как и в SQL, при равенстве всех ключей песни идут по id в направлении последнего ключа
+	sort.Slice(matched, func(i, j int) bool {
+		desc := false
+		for _, key := range order {
+			desc = key.Desc
+			if c := compareSongs(matched[i], matched[j], key.Key); c != 0 {
+				return c < 0 != desc
+			}
+		}
+		return matched[i].ID < matched[j].ID != desc
+	})
+
+	return paginate(matched, limit, offset), nil
+}
+
+// compareSongs сравнивает песни по полю key и возвращает -1, 0 или 1.
+func compareSongs(a, b *models.Song, key string) int {
+	switch key {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	default:
+		av, _ := storage.SongSortValue(a, key)
+		bv, _ := storage.SongSortValue(b, key)
+		return strings.Compare(av, bv)
+	}
+}
+
+func (s *Storage) GetFilteredSongsAfter(ctx context.Context, filters map[string]interface{}, sortKey string, after *storage.SongCursor, limit int) ([]*models.Song, error) {
+	const op = "storage.memory.GetFilteredSongsAfter"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !storage.IsSongSortKey(sortKey) {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, sortKey)
+	}
+
+	matched := s.filterSongs(filters)
+
+	less := func(a, b *models.Song) bool {
+		if sortKey != "id" {
+			av, _ := storage.SongSortValue(a, sortKey)
+			bv, _ := storage.SongSortValue(b, sortKey)
+			if av != bv {
+				return av < bv
+			}
+		}
+		return a.ID < b.ID
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return less(matched[i], matched[j])
+	})
+
+	start := 0
+	if after != nil {
+		last := &models.Song{ID: after.ID}
+		if sortKey != "id" {
+			setSongField(last, sortKey, after.Value)
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			return less(last, matched[i])
+		})
+	}
+
+	return paginate(matched, limit, start), nil
+}
+
+func (s *Storage) GetID(ctx context.Context, group, song string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.findLocked(group, song)
+	if stored == nil {
+		s.log.Warn("Song not found",
+			slog.String("group", group),
+			slog.String("song", song))
+		return 0, storage.ErrSongNotFound
+	}
+
+	return stored.ID, nil
+}
+
+// SearchSongs ищет песни простым сопоставлением слов без учета регистра. Песня подходит, если
+// каждое слово запроса встречается в названии, группе или тексте. Язык на результат не влияет.
+func (s *Storage) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]*models.SongSearchResult, error) {
+	const op = "storage.memory.SearchSongs"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !storage.IsSearchLanguage(language) {
+		return nil, fmt.Errorf("%s: unsupported search language %q", op, language)
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var results []*models.SongSearchResult
+	for _, song := range s.filterSongs(nil) {
+		rank, ok := searchRank(song, terms)
+		if !ok {
+			continue
+		}
+		results = append(results, &models.SongSearchResult{
+			Song:    *song,
+			Rank:    rank,
+			Snippet: searchSnippet(song.Text, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if offset >= len(results) {
+		return nil, nil
+	}
+
+	end := offset + limit
+	if end > len(results) {
+		end = len(results)
+	}
+
+	return results[offset:end], nil
+}
+
+// searchRank считает релевантность песни с теми же весами полей, что и в PostgreSQL:
+// название важнее группы, группа важнее текста.
+func searchRank(song *models.Song, terms []string) (float64, bool) {
+	title := strings.ToLower(song.Song)
+	group := strings.ToLower(song.Group)
+	text := strings.ToLower(song.Text)
+
+	var rank float64
+	for _, term := range terms {
+		hits := 10*strings.Count(title, term) + 5*strings.Count(group, term) + strings.Count(text, term)
+		if hits == 0 {
+			return 0, false
+		}
+		rank += float64(hits)
+	}
+	return rank, true
+}
+
+// searchSnippet возвращает первую строку текста, содержащую слово запроса, с выделенными совпадениями.
+func searchSnippet(text string, terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	text = strings.ReplaceAll(text, "\\n", "\n")
+	for _, line := range strings.Split(text, "\n") {
+		if re.MatchString(line) {
+			return re.ReplaceAllString(strings.TrimSpace(line), "<b>$0</b>")
+		}
+	}
+
+	lines := strings.SplitN(text, "\n", 2)
+	return strings.TrimSpace(lines[0])
+}
+
+// findLocked ищет песню не из корзины по группе и названию. Вызывающий должен держать блокировку.
+func (s *Storage) findLocked(group, song string) *models.Song {
+	for _, stored := range s.songs {
+		if stored.DeletedAt == nil && stored.Group == group && stored.Song == song {
+			return stored
+		}
+	}
+	return nil
+}
+
+// liveLocked возвращает песню по id, если она есть и не в корзине. Вызывающий должен держать блокировку.
+func (s *Storage) liveLocked(id int) (*models.Song, bool) {
+	stored, ok := s.songs[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, false
+	}
+	return stored, true
+}
+
+// filterSongs возвращает копии песен не из корзины, подходящих под фильтры, в произвольном порядке.
+func (s *Storage) filterSongs(filters map[string]interface{}) []*models.Song {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var inAlbum map[int]bool
+	if albumID, ok := filters["album"]; ok {
+		inAlbum = make(map[int]bool)
+		for _, songID := range s.tracks[albumID.(int)] {
+			inAlbum[songID] = true
+		}
+	}
+
+	var matched []*models.Song
+	for _, stored := range s.songs {
+		if stored.DeletedAt != nil {
+			continue
+		}
+		if inAlbum != nil && !inAlbum[stored.ID] {
+			continue
+		}
+		if matchesFilters(stored, filters) {
+			songCopy := *stored
+			matched = append(matched, &songCopy)
+		}
+	}
+	return matched
+}
+
+func matchesFilters(song *models.Song, filters map[string]interface{}) bool {
+	for field, value := range filters {
+		if field == "id" || field == "album" {
+			continue
+		}
+
+		if filter, ok := storage.SongFilters[field]; ok {
+			if !storage.MatchSongFilter(song, filter, value) {
+				return false
+			}
+			continue
+		}
+
+		actual, ok := storage.SongFieldValue(song, field)
+		if !ok {
+			continue
+		}
+
+		if actual != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// setSongField записывает значение строкового поля песни по его имени в API.
+func setSongField(song *models.Song, field, value string) {
+	switch field {
+	case "group":
+		song.Group = value
+	case "song":
+		song.Song = value
+	case "release_date":
+		song.ReleaseDate = value
+	case "text":
+		song.Text = value
+	case "link":
+		song.Link = value
+	}
+}
+
+func paginate(songs []*models.Song, limit, offset int) []*models.Song {
+	if offset >= len(songs) {
+		return nil
+	}
+
+	end := offset + limit
+	if end > len(songs) {
+		end = len(songs)
+	}
+
+	return songs[offset:end]
+}
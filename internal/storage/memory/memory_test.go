@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func newTestStorage() *Storage {
+	return NewStorage(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Запускайте с -race: хранилище в памяти обслуживает запросы из нескольких горутин.
+func TestStorageConcurrentWrites(t *testing.T) {
+	const (
+		workers = 8
+		songs   = 50
+	)
+
+	ctx := context.Background()
+	s := newTestStorage()
+
+	shared, err := s.AddSong(ctx, "Muse", "Shared", models.SongDetail{})
+	if err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	ids := make([][]int, workers)
+	errs := make(chan error, workers)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			group := fmt.Sprintf("Group %d", w)
+			for i := 0; i < songs; i++ {
+				id, err := s.AddSong(ctx, group, fmt.Sprintf("Song %d", i), models.SongDetail{})
+				if err != nil {
+					errs <- fmt.Errorf("AddSong() error = %v", err)
+					return
+				}
+				ids[w] = append(ids[w], id)
+
+				text := fmt.Sprintf("text %d", i)
+				if err := s.UpdateSong(ctx, id, 0, nil, nil, nil, &text, nil); err != nil {
+					errs <- fmt.Errorf("UpdateSong(%d) error = %v", id, err)
+					return
+				}
+				if err := s.UpdateSong(ctx, shared, 0, nil, nil, nil, &text, nil); err != nil {
+					errs <- fmt.Errorf("UpdateSong(shared) error = %v", err)
+					return
+				}
+				if i%2 == 1 {
+					if err := s.DeleteSong(ctx, id, 0); err != nil {
+						errs <- fmt.Errorf("DeleteSong(%d) error = %v", id, err)
+						return
+					}
+				}
+				if _, err := s.GetFilteredSongs(ctx, map[string]interface{}{"group": group}, nil, 10, 0); err != nil {
+					errs <- fmt.Errorf("GetFilteredSongs() error = %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int]bool)
+	for w := range ids {
+		for i, id := range ids[w] {
+			if seen[id] {
+				t.Fatalf("id %d was given to two songs", id)
+			}
+			seen[id] = true
+
+			song, err := s.GetSong(ctx, id)
+			if i%2 == 1 {
+				if err == nil {
+					t.Errorf("GetSong(%d) = %+v, want the song in the trash", id, song)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("GetSong(%d) error = %v", id, err)
+			}
+			if want := fmt.Sprintf("text %d", i); song.Text != want || song.Version != 2 {
+				t.Errorf("GetSong(%d) = text %q, version %d, want %q, 2", id, song.Text, song.Version, want)
+			}
+		}
+	}
+
+	live, err := s.GetFilteredSongs(ctx, map[string]interface{}{}, nil, workers*songs+1, 0)
+	if err != nil {
+		t.Fatalf("GetFilteredSongs() error = %v", err)
+	}
+	if want := workers*songs/2 + 1; len(live) != want {
+		t.Errorf("GetFilteredSongs() returned %d songs, want %d", len(live), want)
+	}
+
+	// Каждое изменение общей песни дает новую версию, ни одно не теряется
+	song, err := s.GetSong(ctx, shared)
+	if err != nil {
+		t.Fatalf("GetSong(shared) error = %v", err)
+	}
+	if want := workers*songs + 1; song.Version != want {
+		t.Errorf("shared song version = %d, want %d", song.Version, want)
+	}
+}
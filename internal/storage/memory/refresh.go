@@ -0,0 +1,251 @@
+package memory
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"sort"
+	"time"
+)
+
+// refreshProposal — предложение обновления вместе с признаком отклонения.
+type refreshProposal struct {
+	models.RefreshProposal
+	rejected bool
+}
+
+// removeRefreshLocked удаляет состояние сверки и предложения окончательно удаленной песни.
+func (s *Storage) removeRefreshLocked(songID int) {
+	delete(s.refreshedAt, songID)
+	for id, proposal := range s.proposals {
+		if proposal.SongID == songID {
+			delete(s.proposals, id)
+		}
+	}
+}
+
+func (s *Storage) MarkSongStale(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveLocked(id); !ok {
+		return storage.ErrSongNotFound
+	}
+	delete(s.refreshedAt, id)
+
+	return nil
+}
+
+func (s *Storage) ClaimRefreshCandidates(ctx context.Context, maxAge, lease time.Duration, limit int) ([]*models.Song, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	threshold := now.Add(-maxAge)
+
+	var due []*models.Song
+	for id, stored := range s.songs {
+		if stored.DeletedAt != nil || stored.EnrichmentStatus != models.EnrichmentEnriched {
+			continue
+		}
+		if refreshedAt, ok := s.refreshedAt[id]; !ok || refreshedAt.Before(threshold) {
+			due = append(due, stored)
+		}
+	}
+
+	// Устаревшие песни (без времени сверки) идут первыми, затем давно не сверявшиеся
+	sort.Slice(due, func(i, j int) bool {
+		a, aOK := s.refreshedAt[due[i].ID]
+		b, bOK := s.refreshedAt[due[j].ID]
+		if aOK != bOK {
+			return !aOK
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	// Взятая песня снова станет кандидатом через lease, если сверка не завершится
+	songs := make([]*models.Song, 0, len(due))
+	for _, stored := range due {
+		s.refreshedAt[stored.ID] = now.Add(lease - maxAge)
+		song := *stored
+		songs = append(songs, &song)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) CompleteRefresh(ctx context.Context, songID int, applied, proposed []models.FieldChange) (*models.RefreshResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.liveLocked(songID)
+	if !ok {
+		return nil, storage.ErrSongNotFound
+	}
+
+	before := *stored
+	result := &models.RefreshResult{SongID: songID}
+	result.Applied, proposed = storage.MergeRefresh(stored, applied, proposed)
+	s.refreshedAt[songID] = time.Now()
+
+	if stored.Text != before.Text {
+		s.saveVersesLocked(songID, stored.Text)
+	}
+	if len(result.Applied) > 0 {
+		s.recordRevisionLocked(ctx, songID, models.RevisionUpdate, &before, stored)
+	}
+
+	result.Proposed = s.replaceProposalsLocked(songID, proposed)
+
+	return result, nil
+}
+
+// replaceProposalsLocked заменяет ожидающие предложения песни новыми. Предложение с тем же значением
+// сохраняет свой id; предложение, которое редактор уже отклонил с тем же значением, не создается
+// снова. Возвращает ожидающие предложения из proposed. Вызывающий должен держать блокировку на запись.
+func (s *Storage) replaceProposalsLocked(songID int, proposed []models.FieldChange) []models.FieldChange {
+	existing := make(map[string]*refreshProposal)
+	for _, proposal := range s.proposals {
+		if proposal.SongID == songID {
+			existing[proposal.Field] = proposal
+		}
+	}
+
+	var pending []models.FieldChange
+	for _, change := range proposed {
+		previous, ok := existing[change.Field]
+		delete(existing, change.Field)
+
+		if ok && previous.Proposed == change.Proposed {
+			if previous.rejected {
+				continue
+			}
+			previous.Current = change.Current
+			pending = append(pending, change)
+			continue
+		}
+
+		id := s.nextProposalID
+		if ok {
+			id = previous.ID
+		} else {
+			s.nextProposalID++
+		}
+
+		s.proposals[id] = &refreshProposal{RefreshProposal: models.RefreshProposal{
+			ID:          id,
+			SongID:      songID,
+			FieldChange: change,
+			CreatedAt:   time.Now().UTC(),
+		}}
+		pending = append(pending, change)
+	}
+
+	// Ожидающие предложения, которые источники больше не подтверждают, устарели
+	for _, previous := range existing {
+		if !previous.rejected {
+			delete(s.proposals, previous.ID)
+		}
+	}
+
+	return pending
+}
+
+func (s *Storage) GetRefreshProposals(ctx context.Context, limit, offset int) ([]*models.RefreshProposal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending []*models.RefreshProposal
+	for _, proposal := range s.proposals {
+		if proposal.rejected {
+			continue
+		}
+		if _, ok := s.liveLocked(proposal.SongID); !ok {
+			continue
+		}
+		result := proposal.RefreshProposal
+		pending = append(pending, &result)
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+
+	if offset >= len(pending) {
+		return nil, nil
+	}
+	pending = pending[offset:]
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+func (s *Storage) AcceptRefreshProposal(ctx context.Context, id int) (*models.Song, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	proposal, ok := s.proposals[id]
+	if !ok || proposal.rejected {
+		return nil, storage.ErrRefreshProposalNotFound
+	}
+
+	stored, ok := s.liveLocked(proposal.SongID)
+	if !ok {
+		return nil, storage.ErrSongNotFound
+	}
+
+	before := *stored
+	storage.ApplyChange(stored, proposal.FieldChange)
+	if stored.Text != before.Text {
+		s.saveVersesLocked(stored.ID, stored.Text)
+	}
+	s.recordRevisionLocked(ctx, stored.ID, models.RevisionUpdate, &before, stored)
+	delete(s.proposals, id)
+
+	result := *stored
+	return &result, nil
+}
+
+func (s *Storage) RejectRefreshProposal(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	proposal, ok := s.proposals[id]
+	if !ok || proposal.rejected {
+		return storage.ErrRefreshProposalNotFound
+	}
+	proposal.rejected = true
+
+	return nil
+}
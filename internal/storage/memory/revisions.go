@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+func (s *Storage) GetSongRevisions(ctx context.Context, songID int, limit, offset int) ([]*models.SongRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[songID]
+	if _, ok := s.songs[songID]; !ok && len(history) == 0 {
+		return nil, storage.ErrSongNotFound
+	}
+
+	// Новые правки идут первыми
+	var revisions []*models.SongRevision
+	for i := len(history) - 1 - offset; i >= 0 && len(revisions) < limit; i-- {
+		revisions = append(revisions, copyRevision(history[i]))
+	}
+
+	return revisions, nil
+}
+
+func (s *Storage) GetSongRevision(ctx context.Context, songID, revision int) (*models.SongRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[songID]
+	if revision < 1 || revision > len(history) {
+		return nil, storage.ErrRevisionNotFound
+	}
+
+	return copyRevision(history[revision-1]), nil
+}
+
+func (s *Storage) RestoreSongRevision(ctx context.Context, songID, revision int) (*models.Song, error) {
+	const op = "storage.memory.RestoreSongRevision"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.revisions[songID]
+	if revision < 1 || revision > len(history) {
+		return nil, storage.ErrRevisionNotFound
+	}
+
+	target := history[revision-1]
+	snapshot := target.After
+	if snapshot == nil {
+		snapshot = target.Before
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("%s: revision %d has no snapshot", op, revision)
+	}
+
+	if existing := s.findLocked(snapshot.Group, snapshot.Song); existing != nil && existing.ID != songID {
+		return nil, storage.ErrSongExists
+	}
+
+	var before *models.Song
+	if current, ok := s.liveLocked(songID); ok {
+		currentCopy := *current
+		before = &currentCopy
+	}
+	sources := storage.RestoredSources(before, snapshot)
+
+	// Песня из корзины или окончательно удаленная восстанавливается под прежним id
+	restored := *snapshot
+	restored.ID = songID
+	restored.DeletedAt = nil
+	restored.ArtistID = s.ensureArtistLocked(restored.Group)
+	restored.Sources = sources
+	// Песня снова считается добавленной тогда же, когда и впервые
+	if stored, ok := s.songs[songID]; ok {
+		restored.CreatedAt = stored.CreatedAt
+	} else {
+		restored.CreatedAt = history[0].CreatedAt
+	}
+	s.songs[songID] = &restored
+	if before == nil || before.Text != restored.Text {
+		s.saveVersesLocked(songID, restored.Text)
+	}
+
+	s.recordRevisionLocked(ctx, songID, models.RevisionRestore, before, &restored)
+
+	result := restored
+	return &result, nil
+}
+
+// recordRevisionLocked записывает правку песни с автором из контекста. Снимки копируются.
+// Номер правки становится версией песни after, если она не nil. Вызывающий должен держать
+// блокировку на запись.
+func (s *Storage) recordRevisionLocked(ctx context.Context, songID int, action string, before, after *models.Song) {
+	history := s.revisions[songID]
+	if after != nil {
+		after.Version = len(history) + 1
+	}
+	s.revisions[songID] = append(history, &models.SongRevision{
+		SongID:    songID,
+		Revision:  len(history) + 1,
+		Action:    action,
+		Before:    copySnapshot(before),
+		After:     copySnapshot(after),
+		Actor:     storage.ActorFromContext(ctx),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func copyRevision(revision *models.SongRevision) *models.SongRevision {
+	revisionCopy := *revision
+	revisionCopy.Before = copySnapshot(revision.Before)
+	revisionCopy.After = copySnapshot(revision.After)
+	return &revisionCopy
+}
+
+func copySnapshot(song *models.Song) *models.Song {
+	if song == nil {
+		return nil
+	}
+	songCopy := *song
+	return &songCopy
+}
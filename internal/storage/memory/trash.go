@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"sort"
+	"time"
+)
+
+func (s *Storage) GetTrashedSongs(ctx context.Context, limit, offset int) ([]*models.Song, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var trashed []*models.Song
+	for _, stored := range s.songs {
+		if stored.DeletedAt != nil {
+			songCopy := *stored
+			trashed = append(trashed, &songCopy)
+		}
+	}
+	s.mu.RUnlock()
+
+	// Недавно удаленные идут первыми
+	sort.Slice(trashed, func(i, j int) bool {
+		if !trashed[i].DeletedAt.Equal(*trashed[j].DeletedAt) {
+			return trashed[i].DeletedAt.After(*trashed[j].DeletedAt)
+		}
+		return trashed[i].ID < trashed[j].ID
+	})
+
+	return paginate(trashed, limit, offset), nil
+}
+
+func (s *Storage) RestoreSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.memory.RestoreSong"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.songs[id]
+	if !ok || stored.DeletedAt == nil {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	if s.findLocked(stored.Group, stored.Song) != nil {
+		return nil, storage.ErrSongExists
+	}
+
+	stored.DeletedAt = nil
+	s.recordRevisionLocked(ctx, id, models.RevisionRestore, nil, stored)
+
+	restored := *stored
+	return &restored, nil
+}
+
+func (s *Storage) PurgeSongs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, stored := range s.songs {
+		if stored.DeletedAt != nil && stored.DeletedAt.Before(deletedBefore) {
+			delete(s.songs, id)
+			s.removeFromTracksLocked(id)
+			s.removeEnrichmentLocked(id)
+			s.removeRefreshLocked(id)
+			delete(s.verses, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
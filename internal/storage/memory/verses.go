@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"sort"
+)
+
+// songVerses — разобранные части текста песни и версия разборщика, которой они получены.
+type songVerses struct {
+	version int
+	items   []models.Verse
+}
+
+func (s *Storage) GetSongVerses(ctx context.Context, id int, limit, offset int) ([]*models.Verse, int, error) {
+	const op = "storage.memory.GetSongVerses"
+
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.liveLocked(id); !ok {
+		return nil, 0, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	var items []models.Verse
+	if parsed, ok := s.verses[id]; ok {
+		items = parsed.items
+	}
+
+	total := len(items)
+	verses := []*models.Verse{}
+	if offset < total {
+		items = items[offset:]
+		if len(items) > limit {
+			items = items[:limit]
+		}
+		for _, item := range items {
+			verse := item
+			verses = append(verses, &verse)
+		}
+	}
+
+	return verses, total, nil
+}
+
+func (s *Storage) ReparseVerses(ctx context.Context, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var outdated []int
+	for id := range s.songs {
+		if parsed, ok := s.verses[id]; !ok || parsed.version < storage.VerseParserVersion {
+			outdated = append(outdated, id)
+		}
+	}
+
+	sort.Ints(outdated)
+	if len(outdated) > limit {
+		outdated = outdated[:limit]
+	}
+
+	for _, id := range outdated {
+		s.saveVersesLocked(id, s.songs[id].Text)
+	}
+
+	return len(outdated), nil
+}
+
+func (s *Storage) ResetVerses(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, parsed := range s.verses {
+		parsed.version = 0
+	}
+
+	return nil
+}
+
+// saveVersesLocked заменяет части текста песни результатом разбора text. Вызывающий должен держать
+// блокировку на запись.
+func (s *Storage) saveVersesLocked(songID int, text string) {
+	s.verses[songID] = &songVerses{version: storage.VerseParserVersion, items: storage.ParseVerses(text)}
+}
@@ -0,0 +1,321 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+)
+
+// ensureArtist возвращает id исполнителя с указанным именем, создавая его при необходимости.
+func ensureArtist(ctx context.Context, tx *sql.Tx, name string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `
+        INSERT INTO artists (name) VALUES ($1)
+        ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+        RETURNING id
+    `, name).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("ensure artist: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) AddArtist(ctx context.Context, artist *models.Artist) (int, error) {
+	const op = "storage.postgresql.AddArtist"
+
+	links, err := json.Marshal(nonNilLinks(artist.Links))
+	if err != nil {
+		return 0, fmt.Errorf("%s: encode links: %w", op, err)
+	}
+
+	var id int
+	err = s.db.QueryRowContext(ctx, `
+        INSERT INTO artists (name, country, formed_year, description, links)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (name) DO NOTHING
+        RETURNING id
+    `, artist.Name, artist.Country, artist.FormedYear, artist.Description, string(links)).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		s.log.Warn("Attempt to add existing artist",
+			slog.String("name", artist.Name))
+		return 0, storage.ErrArtistExists
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetArtist(ctx context.Context, id int) (*models.Artist, error) {
+	const op = "storage.postgresql.GetArtist"
+
+	artist, err := scanArtist(s.db.QueryRowContext(ctx, `
+        SELECT id, name, country, formed_year, description, links
+        FROM artists
+        WHERE id = $1
+    `, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrArtistNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return artist, nil
+}
+
+func (s *Storage) GetArtists(ctx context.Context, limit, offset int) ([]*models.Artist, error) {
+	const op = "storage.postgresql.GetArtists"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, name, country, formed_year, description, links
+        FROM artists
+        ORDER BY id
+        LIMIT $1 OFFSET $2
+    `, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var artists []*models.Artist
+
+	for rows.Next() {
+		artist, err := scanArtist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		artists = append(artists, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return artists, nil
+}
+
+func (s *Storage) UpdateArtist(ctx context.Context, id int, name, country *string, formedYear *int, description *string, links *[]string) error {
+	const op = "storage.postgresql.UpdateArtist"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM artists WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrArtistNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: lock artist: %w", op, err)
+	}
+
+	if name != nil && *name != current {
+		var taken bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM artists WHERE name = $1)`, *name).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("%s: check artist name: %w", op, err)
+		}
+		if taken {
+			return storage.ErrArtistExists
+		}
+	}
+
+	// lib/pq передает []byte как bytea, поэтому JSON отправляется строкой
+	var encodedLinks *string
+	if links != nil {
+		data, err := json.Marshal(nonNilLinks(*links))
+		if err != nil {
+			return fmt.Errorf("%s: encode links: %w", op, err)
+		}
+		value := string(data)
+		encodedLinks = &value
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE artists
+        SET name = COALESCE($1, name),
+            country = COALESCE($2, country),
+            formed_year = COALESCE($3, formed_year),
+            description = COALESCE($4, description),
+            links = COALESCE($5, links)
+        WHERE id = $6
+    `, name, country, formedYear, description, encodedLinks, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Название группы хранится и в песнях, поэтому переименовываем его везде сразу
+	if name != nil && *name != current {
+		if err := renameArtistSongs(ctx, tx, id, *name); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteArtist(ctx context.Context, id int) error {
+	const op = "storage.postgresql.DeleteArtist"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var hasSongs bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM songs WHERE artist_id = $1)`, id).Scan(&hasSongs)
+	if err != nil {
+		return fmt.Errorf("%s: check songs: %w", op, err)
+	}
+	if hasSongs {
+		return storage.ErrArtistHasSongs
+	}
+
+	var hasAlbums bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM albums WHERE artist_id = $1)`, id).Scan(&hasAlbums)
+	if err != nil {
+		return fmt.Errorf("%s: check albums: %w", op, err)
+	}
+	if hasAlbums {
+		return storage.ErrArtistHasAlbums
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM artists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrArtistNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetArtistSongs(ctx context.Context, id int, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.postgresql.GetArtistSongs"
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM artists WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: check artist: %w", op, err)
+	}
+	if !exists {
+		return nil, storage.ErrArtistNotFound
+	}
+
+	songs, err := s.querySongs(ctx, `
+        SELECT `+songColumns+`
+        FROM songs
+        WHERE artist_id = $1 AND deleted_at IS NULL
+        ORDER BY id
+        LIMIT $2 OFFSET $3
+    `, id, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanArtist(row rowScanner) (*models.Artist, error) {
+	var artist models.Artist
+	var formedYear sql.NullInt64
+	var links []byte
+
+	err := row.Scan(&artist.ID, &artist.Name, &artist.Country, &formedYear, &artist.Description, &links)
+	if err != nil {
+		return nil, err
+	}
+
+	if formedYear.Valid {
+		year := int(formedYear.Int64)
+		artist.FormedYear = &year
+	}
+
+	if err := json.Unmarshal(links, &artist.Links); err != nil {
+		return nil, fmt.Errorf("decode links: %w", err)
+	}
+	artist.Links = nonNilLinks(artist.Links)
+
+	return &artist, nil
+}
+
+func nonNilLinks(links []string) []string {
+	if links == nil {
+		return []string{}
+	}
+	return links
+}
+
+// renameArtistSongs меняет группу у всех песен исполнителя и записывает правку для каждой из них.
+func renameArtistSongs(ctx context.Context, tx *sql.Tx, artistID int, name string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT `+songColumns+` FROM songs WHERE artist_id = $1 FOR UPDATE`, artistID)
+	if err != nil {
+		return fmt.Errorf("rename songs: %w", err)
+	}
+
+	var songs []*models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			rows.Close()
+			return fmt.Errorf("rename songs: %w", err)
+		}
+		songs = append(songs, &song)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rename songs: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET "group" = $1 WHERE artist_id = $2`, name, artistID)
+	if err != nil {
+		return fmt.Errorf("rename songs: %w", err)
+	}
+
+	for _, before := range songs {
+		after := *before
+		after.Group = name
+		if err := recordRevision(ctx, tx, before.ID, models.RevisionUpdate, before, &after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
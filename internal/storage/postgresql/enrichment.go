@@ -0,0 +1,155 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+// enqueueEnrichment создает задачу обогащения песни в транзакции, добавившей песню.
+func enqueueEnrichment(ctx context.Context, tx *sql.Tx, songID int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO enrichment_outbox (song_id) VALUES ($1)`, songID)
+	if err != nil {
+		return fmt.Errorf("enqueue enrichment: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]*models.EnrichmentTask, error) {
+	const op = "storage.postgresql.ClaimEnrichmentTasks"
+
+	// SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать очередь, не мешая друг другу
+	rows, err := s.db.QueryContext(ctx, `
+        UPDATE enrichment_outbox o
+        SET attempts = o.attempts + 1,
+            next_attempt_at = NOW() + make_interval(secs => $2)
+        FROM songs s
+        WHERE s.id = o.song_id
+          AND o.id IN (
+              SELECT id FROM enrichment_outbox
+              WHERE next_attempt_at <= NOW()
+              ORDER BY next_attempt_at, id
+              LIMIT $1
+              FOR UPDATE SKIP LOCKED
+          )
+        RETURNING o.id, o.song_id, s."group", s.song, o.attempts
+    `, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tasks []*models.EnrichmentTask
+
+	for rows.Next() {
+		var task models.EnrichmentTask
+		if err := rows.Scan(&task.ID, &task.SongID, &task.Group, &task.Song, &task.Attempts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tasks = append(tasks, &task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) CompleteEnrichment(ctx context.Context, taskID int, detail models.SongDetail) error {
+	const op = "storage.postgresql.CompleteEnrichment"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Песню могли окончательно удалить вместе с задачей, пока шел запрос к API
+	var before models.Song
+	err = tx.QueryRowContext(ctx, `
+        SELECT `+qualifiedSongColumns+`
+        FROM enrichment_outbox o
+        JOIN songs s ON s.id = o.song_id
+        WHERE o.id = $1
+        FOR UPDATE
+    `, taskID).Scan(songFields(&before)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: load song: %w", op, err)
+	}
+
+	// Поля, заполненные вручную, пока песня ждала обогащения, не перезаписываются
+	after := before
+	after.EnrichmentStatus = models.EnrichmentEnriched
+	storage.ApplyDetail(&after, detail)
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs
+        SET release_date = $2, text = $3, link = $4, enrichment_status = $5, sources = $6, refreshed_at = NOW()
+        WHERE id = $1
+    `, after.ID, after.ReleaseDate, after.Text, after.Link, after.EnrichmentStatus, sources)
+	if err != nil {
+		return fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, after.ID, after.Text); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, before.ID, models.RevisionUpdate, &before, &after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM enrichment_outbox WHERE id = $1`, taskID); err != nil {
+		return fmt.Errorf("%s: delete task: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RetryEnrichment(ctx context.Context, taskID int, nextAttemptAt time.Time, lastError string) error {
+	const op = "storage.postgresql.RetryEnrichment"
+
+	_, err := s.db.ExecContext(ctx, `
+        UPDATE enrichment_outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1
+    `, taskID, nextAttemptAt, lastError)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) FailEnrichment(ctx context.Context, taskID int) error {
+	const op = "storage.postgresql.FailEnrichment"
+
+	_, err := s.db.ExecContext(ctx, `
+        WITH task AS (
+            DELETE FROM enrichment_outbox WHERE id = $1 RETURNING song_id
+        )
+        UPDATE songs SET enrichment_status = $2 WHERE id IN (SELECT song_id FROM task)
+    `, taskID, models.EnrichmentFailed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
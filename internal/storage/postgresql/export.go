@@ -0,0 +1,72 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"strings"
+)
+
+// exportBatchSize — сколько строк за раз читается из серверного курсора при выгрузке.
+const exportBatchSize = 500
+
+func (s *Storage) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	const op = "storage.postgresql.ExportSongs"
+
+	// Курсор живет только внутри транзакции; REPEATABLE READ дает согласованный снимок на всю выгрузку
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	conditions, args := filterConditions(filters)
+	query := `DECLARE song_export NO SCROLL CURSOR FOR SELECT ` + songColumns + ` FROM songs WHERE ` +
+		strings.Join(conditions, " AND ") + ` ORDER BY id`
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: declare cursor: %w", op, err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM song_export`, exportBatchSize)
+	for {
+		n, err := fetchSongs(ctx, tx, fetch, fn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if n < exportBatchSize {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// fetchSongs читает очередную порцию строк курсора, передает их в fn и возвращает их количество.
+func fetchSongs(ctx context.Context, tx *sql.Tx, fetch string, fn func(*models.Song) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			return n, err
+		}
+		n++
+
+		if err := fn(&song); err != nil {
+			return n, err
+		}
+	}
+
+	return n, rows.Err()
+}
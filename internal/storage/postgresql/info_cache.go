@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+)
+
+func (s *Storage) GetInfoCache(ctx context.Context, key string) (*models.InfoCacheEntry, error) {
+	const op = "storage.postgresql.GetInfoCache"
+
+	var entry models.InfoCacheEntry
+
+	err := s.db.QueryRowContext(ctx, `
+        SELECT release_date, text, link, sources, not_found, expires_at
+        FROM song_info_cache
+        WHERE key = $1 AND expires_at > NOW()
+    `, key).Scan(&entry.Detail.ReleaseDate, &entry.Detail.Text, &entry.Detail.Link,
+		storage.SourcesScanner(&entry.Detail.Sources), &entry.NotFound, &entry.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entry, nil
+}
+
+func (s *Storage) SaveInfoCache(ctx context.Context, key string, entry models.InfoCacheEntry) error {
+	const op = "storage.postgresql.SaveInfoCache"
+
+	sources, err := storage.EncodeSources(entry.Detail.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM song_info_cache WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("%s: delete expired: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO song_info_cache (key, release_date, text, link, sources, not_found, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (key) DO UPDATE
+        SET release_date = EXCLUDED.release_date, text = EXCLUDED.text, link = EXCLUDED.link,
+            sources = EXCLUDED.sources, not_found = EXCLUDED.not_found, expires_at = EXCLUDED.expires_at
+    `, key, entry.Detail.ReleaseDate, entry.Detail.Text, entry.Detail.Link, sources, entry.NotFound,
+		entry.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteInfoCache(ctx context.Context, key string) (bool, error) {
+	const op = "storage.postgresql.DeleteInfoCache"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM song_info_cache WHERE key = $1`, key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return affected > 0, nil
+}
+
+func (s *Storage) ClearInfoCache(ctx context.Context) (int, error) {
+	const op = "storage.postgresql.ClearInfoCache"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM song_info_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return int(affected), nil
+}
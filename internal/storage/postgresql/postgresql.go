@@ -1,14 +1,16 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/TakuroBreath/song-library/internal/domain/models"
 	"github.com/TakuroBreath/song-library/internal/storage"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"log/slog"
 	"strings"
+	"time"
 )
 
 type Storage struct {
@@ -35,13 +37,32 @@ func NewStorage(psqlInfo string, log *slog.Logger) (*Storage, error) {
 	}, nil
 }
 
-func (s *Storage) AddSong(group, song, releaseDate, text, link string) (int, error) {
-	const op = "storage.postgresql.AddSong"
+func (s *Storage) AddSong(ctx context.Context, group, song string, detail models.SongDetail) (int, error) {
+	return s.addSong(ctx, "storage.postgresql.AddSong", group, song, detail, models.EnrichmentEnriched)
+}
+
+// AddSongForEnrichment сохраняет песню без сведений и в той же транзакции ставит ее в очередь обогащения.
+func (s *Storage) AddSongForEnrichment(ctx context.Context, group, song string) (int, error) {
+	return s.addSong(ctx, "storage.postgresql.AddSongForEnrichment", group, song, models.SongDetail{}, models.EnrichmentPending)
+}
+
+func (s *Storage) addSong(ctx context.Context, op, group, song string, detail models.SongDetail, status string) (int, error) {
+	sources := storage.DetailSources(detail)
+	encodedSources, err := storage.EncodeSources(sources)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
 
 	var exists bool
-	err := s.db.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
         SELECT EXISTS(
-            SELECT 1 FROM songs WHERE "group" = $1 AND song = $2
+            SELECT 1 FROM songs WHERE "group" = $1 AND song = $2 AND deleted_at IS NULL
         )
     `, group, song).Scan(&exists)
 
@@ -56,17 +77,61 @@ func (s *Storage) AddSong(group, song, releaseDate, text, link string) (int, err
 		return 0, storage.ErrSongExists
 	}
 
+	artistID, err := ensureArtist(ctx, tx, group)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Песня без сведений сверится с источниками после обогащения
+	var refreshedAt *time.Time
+	if status == models.EnrichmentEnriched {
+		now := time.Now()
+		refreshedAt = &now
+	}
+
 	var id int
-	err = s.db.QueryRow(`
-        INSERT INTO songs ("group", song, release_date, text, link) 
-        VALUES ($1, $2, $3, $4, $5)
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO songs ("group", song, release_date, text, link, artist_id, enrichment_status, sources, refreshed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         RETURNING id
-    `, group, song, releaseDate, text, link).Scan(&id)
+    `, group, song, detail.ReleaseDate, detail.Text, detail.Link, artistID, status, encodedSources, refreshedAt).Scan(&id)
 
+	if isSongNameTaken(err) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	created := &models.Song{
+		ID:               id,
+		Group:            group,
+		Song:             song,
+		ReleaseDate:      detail.ReleaseDate,
+		Text:             detail.Text,
+		Link:             detail.Link,
+		ArtistID:         artistID,
+		EnrichmentStatus: status,
+		Sources:          sources,
+	}
+	if err := saveVerses(ctx, tx, id, detail.Text); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionCreate, nil, created); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status == models.EnrichmentPending {
+		if err := enqueueEnrichment(ctx, tx, id); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
 	s.log.Info("Song added successfully",
 		slog.Int("id", id),
 		slog.String("group", group),
@@ -75,118 +140,384 @@ func (s *Storage) AddSong(group, song, releaseDate, text, link string) (int, err
 	return id, nil
 }
 
-func (s *Storage) UpdateSong(id int, group, song *string, releaseDate *string, text *string, link *string) error {
+func (s *Storage) GetSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.postgresql.GetSong"
+
+	var song models.Song
+	err := s.db.QueryRowContext(ctx, `SELECT `+songColumns+` FROM songs WHERE id = $1 AND deleted_at IS NULL`, id).
+		Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrSongNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &song, nil
+}
+
+func (s *Storage) UpdateSong(ctx context.Context, id, version int, group, song *string, releaseDate *string, text *string, link *string) error {
 	const op = "storage.postgresql.UpdateSong"
 
-	_, err := s.db.Exec(`
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSong(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if version != 0 && before.Version != version {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongVersionMismatch)
+	}
+
+	// Новое название не должно совпадать с названием другой песни не из корзины
+	if group != nil || song != nil {
+		newGroup, newSong := before.Group, before.Song
+		if group != nil {
+			newGroup = *group
+		}
+		if song != nil {
+			newSong = *song
+		}
+
+		var taken bool
+		err = tx.QueryRowContext(ctx, `
+            SELECT EXISTS(SELECT 1 FROM songs WHERE "group" = $1 AND song = $2 AND id <> $3 AND deleted_at IS NULL)
+        `, newGroup, newSong, id).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("%s: check song existence: %w", op, err)
+		}
+		if taken {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+		}
+	}
+
+	// Смена группы переносит песню к исполнителю с новым именем
+	var artistID *int
+	if group != nil {
+		groupArtistID, err := ensureArtist(ctx, tx, *group)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		artistID = &groupArtistID
+	}
+
+	sources, err := storage.EncodeSources(storage.ManualSources(before, releaseDate, text, link))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
         UPDATE songs 
         SET "group" = COALESCE($1, "group"), 
             song = COALESCE($2, song),
             release_date = COALESCE($3, release_date), 
             text = COALESCE($4, text),
-            link = COALESCE($5, link)
-        WHERE id = $6
-    `, group, song, releaseDate, text, link, id)
+            link = COALESCE($5, link),
+            artist_id = COALESCE($6, artist_id),
+            sources = $7
+        WHERE id = $8
+    `, group, song, releaseDate, text, link, artistID, sources, id)
+
+	if isSongNameTaken(err) {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
+	after, err := lockSong(ctx, tx, id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, id, after.Text); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionUpdate, before, after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
 	return nil
 }
 
-func (s *Storage) DeleteSong(group, song string) error {
+func (s *Storage) DeleteSong(ctx context.Context, id, version int) error {
 	const op = "storage.postgresql.DeleteSong"
 
-	result, err := s.db.Exec(`
-       DELETE FROM songs 
-       WHERE "group" = $1 AND song = $2
-   `, group, song)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var before models.Song
+	err = tx.QueryRowContext(ctx, `
+       SELECT `+songColumns+`
+       FROM songs 
+       WHERE id = $1 AND deleted_at IS NULL
+       FOR UPDATE
+   `, id).Scan(songFields(&before)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
 
 	if err != nil {
-		return fmt.Errorf("%s: execute delete: %w", op, err)
+		return fmt.Errorf("%s: lock song: %w", op, err)
+	}
+	if version != 0 && before.Version != version {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongVersionMismatch)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// Песня переносится в корзину и удаляется окончательно только при очистке
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET deleted_at = NOW() WHERE id = $1`, before.ID)
 	if err != nil {
-		return fmt.Errorf("%s: rows affected: %w", op, err)
+		return fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, before.ID, models.RevisionDelete, &before, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("%s: song '%s' by '%s' not found", op, song, group)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
 	}
 
 	return nil
 }
 
-func (s *Storage) GetSongWithPagination(group, song string, limit, offset int) ([]string, error) {
-	const op = "storage.postgresql.GetSongWithPagination"
+// songColumns перечисляет колонки песни в порядке, который ожидает songFields.
+const songColumns = `id, "group", song, release_date, text, link, artist_id, enrichment_status, sources, version, created_at`
 
-	var text string
+// qualifiedSongColumns — то же, что songColumns, для запросов, где таблица songs имеет псевдоним s.
+const qualifiedSongColumns = `s.id, s."group", s.song, s.release_date, s.text, s.link, s.artist_id, s.enrichment_status, s.sources, s.version, s.created_at`
 
-	err := s.db.QueryRow(`
-        SELECT text 
-        FROM songs 
-        WHERE "group" = $1 AND song = $2
-    `, group, song).Scan(&text)
+// songFields возвращает указатели на поля песни для rows.Scan в порядке songColumns.
+func songFields(song *models.Song) []interface{} {
+	return []interface{}{&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.ArtistID, &song.EnrichmentStatus, storage.SourcesScanner(&song.Sources), &song.Version, &song.CreatedAt}
+}
+
+// Карта для правильного экранирования имен полей
+var fieldNames = map[string]string{
+	"group":             `"group"`,
+	"song":              "song",
+	"release_date":      "release_date",
+	"text":              "text",
+	"link":              "link",
+	"enrichment_status": "enrichment_status",
+}
+
+// sortColumns содержит поля, по которым допускается сортировка при выборке по курсору.
+// Дата выпуска сравнивается в виде YYYY-MM-DD, как и значение в курсоре.
+var sortColumns = map[string]string{
+	"id":           "id",
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": releaseDateISO,
+}
+
+// listSortColumns содержит поля, по которым допускается сортировка при выборке по смещению.
+// Дата выпуска сравнивается в виде YYYY-MM-DD, иначе даты DD.MM.YYYY упорядочились бы по дню месяца.
+var listSortColumns = map[string]string{
+	"id":           "id",
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": releaseDateISO,
+	"created_at":   "created_at",
+}
+
+// orderClause составляет ORDER BY по ключам сортировки. Последним ключом идет id в направлении
+// предыдущего ключа: порядок однозначен, а сортировку по одному полю обслуживает индекс (поле, id)
+// в прямом или обратном порядке.
+func orderClause(sort []storage.SongSort) (string, error) {
+	terms := make([]string, 0, len(sort)+1)
+	desc := false
+	for _, key := range sort {
+		column, ok := listSortColumns[key.Key]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort key %q", key.Key)
+		}
+
+		desc = key.Desc
+		if desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+
+		// id уникален, следующие ключи на порядок не влияют
+		if key.Key == "id" {
+			return " ORDER BY " + strings.Join(terms, ", "), nil
+		}
+	}
+
+	if desc {
+		terms = append(terms, "id DESC")
+	} else {
+		terms = append(terms, "id")
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+func (s *Storage) GetFilteredSongs(ctx context.Context, filters map[string]interface{}, sort []storage.SongSort, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.postgresql.GetFilteredSongs"
 
+	order, err := orderClause(sort)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Заменяем экранированные переводы строк на реальные
-	text = strings.ReplaceAll(text, "\\n", "\n")
+	query := `SELECT ` + songColumns + ` FROM songs`
+	conditions, args := filterConditions(filters)
+	argIndex := len(args) + 1
 
-	// Разделяем текст на куплеты
-	verses := strings.Split(text, "\n\n")
+	query += " WHERE " + strings.Join(conditions, " AND ")
 
-	// Если получился только один куплет, пробуем разбить по одинарным переводам строк
-	if len(verses) <= 1 {
-		verses = strings.Split(text, "\n")
+	query += order + fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	songs, err := s.querySongs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Очищаем пустые строки и форматируем текст
-	var cleanVerses []string
-	for _, verse := range verses {
-		verse = strings.TrimSpace(verse)
-		if verse != "" {
-			// Заменяем переводы строк на пробелы для форматированного вывода
-			formattedVerse := strings.ReplaceAll(verse, "\n", " ")
-			cleanVerses = append(cleanVerses, formattedVerse)
+	return songs, nil
+}
+
+func (s *Storage) GetFilteredSongsAfter(ctx context.Context, filters map[string]interface{}, sortKey string, after *storage.SongCursor, limit int) ([]*models.Song, error) {
+	const op = "storage.postgresql.GetFilteredSongsAfter"
+
+	sortColumn, ok := sortColumns[sortKey]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, sortKey)
+	}
+
+	query := `SELECT ` + songColumns + ` FROM songs`
+	conditions, args := filterConditions(filters)
+	argIndex := len(args) + 1
+
+	// Продолжаем строго после последней песни предыдущей страницы
+	if after != nil {
+		if sortColumn == "id" {
+			conditions = append(conditions, fmt.Sprintf("id > $%d", argIndex))
+			args = append(args, after.ID)
+			argIndex++
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) > ($%d, $%d)", sortColumn, argIndex, argIndex+1))
+			args = append(args, after.Value, after.ID)
+			argIndex += 2
 		}
 	}
 
-	// Проверяем границы пагинации
-	if offset >= len(cleanVerses) {
-		return []string{}, nil
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	if sortColumn == "id" {
+		query += " ORDER BY id"
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s, id", sortColumn)
 	}
 
-	end := offset + limit
-	if end > len(cleanVerses) {
-		end = len(cleanVerses)
+	query += fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	songs, err := s.querySongs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return cleanVerses[offset:end], nil
+	return songs, nil
 }
 
-func (s *Storage) GetFilteredSongs(filters map[string]interface{}, limit, offset int) ([]*models.Song, error) {
-	const op = "storage.postgresql.GetFilteredSongs"
+// headlineOptions задает параметры ts_headline для фрагментов результатов поиска.
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter= … "
 
-	query := `SELECT id, "group", song, release_date, text, link FROM songs`
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+// searchVector возвращает выражение tsvector для конфигурации поиска. Для конфигурации по умолчанию
+// используется индексируемая колонка search_vector, для остальных вектор строится на лету.
+func searchVector(language string) string {
+	if language == storage.SearchLanguages[0] {
+		return "search_vector"
+	}
+	return fmt.Sprintf(`setweight(to_tsvector('%[1]s', coalesce(song, '')), 'A') ||
+        setweight(to_tsvector('%[1]s', coalesce("group", '')), 'B') ||
+        setweight(to_tsvector('%[1]s', coalesce(text, '')), 'C')`, language)
+}
+
+func (s *Storage) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]*models.SongSearchResult, error) {
+	const op = "storage.postgresql.SearchSongs"
+
+	// Имя конфигурации подставляется в запрос напрямую, поэтому допускаются только известные значения
+	if !storage.IsSearchLanguage(language) {
+		return nil, fmt.Errorf("%s: unsupported search language %q", op, language)
+	}
 
-	// Карта для правильного экранирования имен полей
-	fieldNames := map[string]string{
-		"group":        `"group"`,
-		"song":         "song",
-		"release_date": "release_date",
-		"text":         "text",
-		"link":         "link",
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+        SELECT `+songColumns+`,
+               ts_rank(%[1]s, q.query) AS rank,
+               ts_headline('%[2]s', text, q.query, $2) AS snippet
+        FROM songs, websearch_to_tsquery('%[2]s', $1) AS q(query)
+        WHERE %[1]s @@ q.query AND deleted_at IS NULL
+        ORDER BY rank DESC, id
+        LIMIT $3 OFFSET $4
+    `, searchVector(language), language), query, headlineOptions, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
+
+	var results []*models.SongSearchResult
+
+	for rows.Next() {
+		var result models.SongSearchResult
+		err := rows.Scan(append(songFields(&result.Song), &result.Rank, &result.Snippet)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// filterConditions строит условия WHERE для фильтров по точному совпадению полей
+// и по принадлежности альбому. Песни из корзины исключаются всегда. Плейсхолдеры нумеруются с $1.
+func filterConditions(filters map[string]interface{}) ([]string, []interface{}) {
+	// Песни из корзины не попадают в выборки
+	conditions := []string{`deleted_at IS NULL`}
+	var args []interface{}
+	argIndex := 1
 
 	for field, value := range filters {
+		if field == "album" {
+			conditions = append(conditions, fmt.Sprintf(`id IN (SELECT song_id FROM album_tracks WHERE album_id = $%d)`, argIndex))
+			args = append(args, value)
+			argIndex++
+			continue
+		}
+
+		if filter, ok := storage.SongFilters[field]; ok {
+			condition, filterArgs := filterCondition(filter, value, argIndex)
+			conditions = append(conditions, condition)
+			args = append(args, filterArgs...)
+			argIndex += len(filterArgs)
+			continue
+		}
+
 		if quotedField, ok := fieldNames[field]; ok {
 			conditions = append(conditions, fmt.Sprintf(`%s = $%d`, quotedField, argIndex))
 			args = append(args, value)
@@ -194,16 +525,50 @@ func (s *Storage) GetFilteredSongs(filters map[string]interface{}, limit, offset
 		}
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
+	return conditions, args
+}
 
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
+// releaseDateISO приводит release_date к виду YYYY-MM-DD так же, как storage.ReleaseDateISO.
+const releaseDateISO = `CASE WHEN release_date ~ '^[0-9]{2}\.[0-9]{2}\.[0-9]{4}$'
+    THEN substr(release_date, 7, 4) || '-' || substr(release_date, 4, 2) || '-' || substr(release_date, 1, 2)
+    ELSE release_date END`
+
+// filterCondition составляет условие фильтра из storage.SongFilters с параметрами, начиная с $argIndex.
+func filterCondition(filter storage.SongFilter, value interface{}, argIndex int) (string, []interface{}) {
+	column := fieldNames[filter.Field]
+
+	switch filter.Op {
+	case storage.FilterContains:
+		return fmt.Sprintf(`strpos(lower(%s), lower($%d)) > 0`, column, argIndex), []interface{}{value}
+	case storage.FilterPrefix:
+		return fmt.Sprintf(`strpos(lower(%s), lower($%d)) = 1`, column, argIndex), []interface{}{value}
+	case storage.FilterFrom:
+		return fmt.Sprintf(`release_date <> '' AND %s >= $%d`, releaseDateISO, argIndex), []interface{}{value}
+	case storage.FilterTo:
+		return fmt.Sprintf(`release_date <> '' AND %s <= $%d`, releaseDateISO, argIndex), []interface{}{value}
+	case storage.FilterIn:
+		values := value.([]string)
+		placeholders := make([]string, len(values))
+		args := make([]interface{}, len(values))
+		for i, v := range values {
+			placeholders[i] = fmt.Sprintf("$%d", argIndex+i)
+			args[i] = v
+		}
+		return fmt.Sprintf(`%s IN (%s)`, column, strings.Join(placeholders, ", ")), args
+	case storage.FilterPresent:
+		if value.(bool) {
+			return fmt.Sprintf(`%s <> ''`, column), nil
+		}
+		return fmt.Sprintf(`%s = ''`, column), nil
+	default:
+		return "FALSE", nil
+	}
+}
 
-	rows, err := s.db.Query(query, args...)
+func (s *Storage) querySongs(ctx context.Context, query string, args ...interface{}) ([]*models.Song, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -211,24 +576,29 @@ func (s *Storage) GetFilteredSongs(filters map[string]interface{}, limit, offset
 
 	for rows.Next() {
 		var songDetail models.Song
-		err := rows.Scan(&songDetail.ID, &songDetail.Group, &songDetail.Song, &songDetail.ReleaseDate, &songDetail.Text, &songDetail.Link)
+		err := rows.Scan(songFields(&songDetail)...)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+			return nil, err
 		}
 		songs = append(songs, &songDetail)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return songs, nil
 }
-func (s *Storage) GetID(group, song string) (int, error) {
+
+func (s *Storage) GetID(ctx context.Context, group, song string) (int, error) {
 	const op = "storage.postgresql.GetID"
 
 	var id int
 
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
         SELECT id 
         FROM songs 
-        WHERE "group" = $1 AND song = $2
+        WHERE "group" = $1 AND song = $2 AND deleted_at IS NULL
     `, group, song).Scan(&id)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -248,3 +618,10 @@ func (s *Storage) GetID(group, song string) (int, error) {
 
 	return id, nil
 }
+
+// isSongNameTaken сообщает, что запись нарушила уникальность группы и названия песни вне корзины.
+// Проверка перед записью не видит песню, которую параллельная транзакция добавила после нее.
+func isSongNameTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "songs_group_song_live_idx"
+}
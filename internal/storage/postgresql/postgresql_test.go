@@ -0,0 +1,31 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestIsSongNameTaken(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "song name index", err: &pq.Error{Code: "23505", Constraint: "songs_group_song_live_idx"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", &pq.Error{Code: "23505", Constraint: "songs_group_song_live_idx"}), want: true},
+		{name: "another unique constraint", err: &pq.Error{Code: "23505", Constraint: "artists_name_key"}},
+		{name: "another error code", err: &pq.Error{Code: "23503", Constraint: "songs_group_song_live_idx"}},
+		{name: "not a database error", err: errors.New("connection refused")},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSongNameTaken(tt.err); got != tt.want {
+				t.Errorf("isSongNameTaken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
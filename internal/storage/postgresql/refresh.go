@@ -0,0 +1,337 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+// Состояния предложений обновления.
+const (
+	proposalPending  = "pending"
+	proposalRejected = "rejected"
+)
+
+func (s *Storage) MarkSongStale(ctx context.Context, id int) error {
+	const op = "storage.postgresql.MarkSongStale"
+
+	result, err := s.db.ExecContext(ctx, `UPDATE songs SET refreshed_at = NULL WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrSongNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) ClaimRefreshCandidates(ctx context.Context, maxAge, lease time.Duration, limit int) ([]*models.Song, error) {
+	const op = "storage.postgresql.ClaimRefreshCandidates"
+
+	// Устаревшие песни (refreshed_at IS NULL) идут первыми, затем давно не сверявшиеся.
+	// Взятая песня снова станет кандидатом через lease, если сверка не завершится
+	rows, err := s.db.QueryContext(ctx, `
+        UPDATE songs
+        SET refreshed_at = NOW() - make_interval(secs => $2) + make_interval(secs => $3)
+        WHERE id IN (
+            SELECT id FROM songs
+            WHERE deleted_at IS NULL AND enrichment_status = $1
+              AND (refreshed_at IS NULL OR refreshed_at < NOW() - make_interval(secs => $2))
+            ORDER BY refreshed_at NULLS FIRST, id
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+songColumns, models.EnrichmentEnriched, maxAge.Seconds(), lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var songs []*models.Song
+
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, &song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) CompleteRefresh(ctx context.Context, songID int, applied, proposed []models.FieldChange) (*models.RefreshResult, error) {
+	const op = "storage.postgresql.CompleteRefresh"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockSong(ctx, tx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return nil, storage.ErrSongNotFound
+	}
+
+	after := *before
+	result := &models.RefreshResult{SongID: songID}
+	result.Applied, proposed = storage.MergeRefresh(&after, applied, proposed)
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs SET release_date = $2, text = $3, link = $4, sources = $5, refreshed_at = NOW()
+        WHERE id = $1
+    `, songID, after.ReleaseDate, after.Text, after.Link, sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, songID, after.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if len(result.Applied) > 0 {
+		if err := recordRevision(ctx, tx, songID, models.RevisionUpdate, before, &after); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	result.Proposed, err = replaceProposals(ctx, tx, songID, proposed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// replaceProposals заменяет ожидающие предложения песни новыми. Предложение с тем же значением
+// сохраняет свой id; предложение, которое редактор уже отклонил с тем же значением, не создается
+// снова. Возвращает ожидающие предложения из proposed.
+func replaceProposals(ctx context.Context, tx *sql.Tx, songID int, proposed []models.FieldChange) ([]models.FieldChange, error) {
+	rows, err := tx.QueryContext(ctx, `
+        SELECT field, proposed_value, status FROM song_refresh_proposals WHERE song_id = $1
+    `, songID)
+	if err != nil {
+		return nil, fmt.Errorf("load proposals: %w", err)
+	}
+
+	type existingProposal struct{ proposed, status string }
+	existing := make(map[string]existingProposal)
+
+	for rows.Next() {
+		var field string
+		var proposal existingProposal
+		if err := rows.Scan(&field, &proposal.proposed, &proposal.status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("load proposals: %w", err)
+		}
+		existing[field] = proposal
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load proposals: %w", err)
+	}
+
+	var pending []models.FieldChange
+
+	for _, change := range proposed {
+		previous, ok := existing[change.Field]
+		delete(existing, change.Field)
+
+		if ok && previous.proposed == change.Proposed {
+			if previous.status == proposalRejected {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+                UPDATE song_refresh_proposals SET current_value = $1 WHERE song_id = $2 AND field = $3
+            `, change.Current, songID, change.Field); err != nil {
+				return nil, fmt.Errorf("save proposal: %w", err)
+			}
+			pending = append(pending, change)
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO song_refresh_proposals (song_id, field, current_value, proposed_value, source, status)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            ON CONFLICT (song_id, field) DO UPDATE
+            SET current_value = EXCLUDED.current_value, proposed_value = EXCLUDED.proposed_value,
+                source = EXCLUDED.source, status = EXCLUDED.status, created_at = NOW()
+        `, songID, change.Field, change.Current, change.Proposed, change.Source, proposalPending)
+		if err != nil {
+			return nil, fmt.Errorf("save proposal: %w", err)
+		}
+		pending = append(pending, change)
+	}
+
+	// Ожидающие предложения, которые источники больше не подтверждают, устарели
+	for field, previous := range existing {
+		if previous.status != proposalPending {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+            DELETE FROM song_refresh_proposals WHERE song_id = $1 AND field = $2
+        `, songID, field); err != nil {
+			return nil, fmt.Errorf("delete proposal: %w", err)
+		}
+	}
+
+	return pending, nil
+}
+
+// proposalColumns перечисляет колонки предложения в порядке, который ожидает proposalFields.
+const proposalColumns = `p.id, p.song_id, p.field, p.current_value, p.proposed_value, p.source, p.created_at`
+
+func proposalFields(proposal *models.RefreshProposal) []interface{} {
+	return []interface{}{&proposal.ID, &proposal.SongID, &proposal.Field, &proposal.Current,
+		&proposal.Proposed, &proposal.Source, &proposal.CreatedAt}
+}
+
+func (s *Storage) GetRefreshProposals(ctx context.Context, limit, offset int) ([]*models.RefreshProposal, error) {
+	const op = "storage.postgresql.GetRefreshProposals"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+proposalColumns+`
+        FROM song_refresh_proposals p
+        JOIN songs s ON s.id = p.song_id
+        WHERE p.status = $1 AND s.deleted_at IS NULL
+        ORDER BY p.id
+        LIMIT $2 OFFSET $3
+    `, proposalPending, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var proposals []*models.RefreshProposal
+
+	for rows.Next() {
+		var proposal models.RefreshProposal
+		if err := rows.Scan(proposalFields(&proposal)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		proposals = append(proposals, &proposal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) AcceptRefreshProposal(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.postgresql.AcceptRefreshProposal"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var proposal models.RefreshProposal
+	err = tx.QueryRowContext(ctx, `
+        SELECT `+proposalColumns+` FROM song_refresh_proposals p WHERE p.id = $1 AND p.status = $2 FOR UPDATE
+    `, id, proposalPending).Scan(proposalFields(&proposal)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRefreshProposalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: load proposal: %w", op, err)
+	}
+
+	before, err := lockSong(ctx, tx, proposal.SongID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return nil, storage.ErrSongNotFound
+	}
+
+	after := *before
+	if !storage.ApplyChange(&after, proposal.FieldChange) {
+		return nil, fmt.Errorf("%s: unknown field %q", op, proposal.Field)
+	}
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs SET release_date = $2, text = $3, link = $4, sources = $5 WHERE id = $1
+    `, after.ID, after.ReleaseDate, after.Text, after.Link, sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, after.ID, after.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, after.ID, models.RevisionUpdate, before, &after); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM song_refresh_proposals WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("%s: delete proposal: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &after, nil
+}
+
+func (s *Storage) RejectRefreshProposal(ctx context.Context, id int) error {
+	const op = "storage.postgresql.RejectRefreshProposal"
+
+	result, err := s.db.ExecContext(ctx, `
+        UPDATE song_refresh_proposals SET status = $1 WHERE id = $2 AND status = $3
+    `, proposalRejected, id, proposalPending)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrRefreshProposalNotFound
+	}
+
+	return nil
+}
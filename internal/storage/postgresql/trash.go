@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+func (s *Storage) GetTrashedSongs(ctx context.Context, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.postgresql.GetTrashedSongs"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+songColumns+`, deleted_at
+        FROM songs
+        WHERE deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC, id
+        LIMIT $1 OFFSET $2
+    `, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var songs []*models.Song
+
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(append(songFields(&song), &song.DeletedAt)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, &song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) RestoreSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.postgresql.RestoreSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var song models.Song
+	err = tx.QueryRowContext(ctx, `
+        SELECT `+songColumns+`
+        FROM songs
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        FOR UPDATE
+    `, id).Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: lock song: %w", op, err)
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM songs WHERE "group" = $1 AND song = $2 AND deleted_at IS NULL)
+    `, song.Group, song.Song).Scan(&taken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: check song existence: %w", op, err)
+	}
+	if taken {
+		return nil, storage.ErrSongExists
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET deleted_at = NULL WHERE id = $1`, id)
+	if isSongNameTaken(err) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionRestore, nil, &song); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &song, nil
+}
+
+func (s *Storage) PurgeSongs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	const op = "storage.postgresql.PurgeSongs"
+
+	result, err := s.db.ExecContext(ctx, `
+        DELETE FROM songs
+        WHERE deleted_at IS NOT NULL AND deleted_at < $1
+    `, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return int(purged), nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+)
+
+// ApplyDetail заполняет пустые поля песни сведениями и отмечает их источники.
+// Непустые поля, например исправленные вручную, остаются как есть. Карта Sources
+// песни не меняется на месте, а заменяется копией: ее могут разделять другие копии песни.
+func ApplyDetail(song *models.Song, detail models.SongDetail) {
+	sources := make(map[string]string, len(song.Sources)+3)
+	for field, source := range song.Sources {
+		sources[field] = source
+	}
+
+	fill := func(field string, dst *string, value string) {
+		if *dst != "" || value == "" {
+			return
+		}
+		*dst = value
+		if source := detail.Sources[field]; source != "" {
+			sources[field] = source
+		}
+	}
+
+	fill(models.FieldReleaseDate, &song.ReleaseDate, detail.ReleaseDate)
+	fill(models.FieldText, &song.Text, detail.Text)
+	fill(models.FieldLink, &song.Link, detail.Link)
+
+	if len(sources) == 0 {
+		sources = nil
+	}
+	song.Sources = sources
+}
+
+// DetailSources возвращает источники только тех полей сведений, которые заполнены.
+func DetailSources(detail models.SongDetail) map[string]string {
+	sources := make(map[string]string)
+	for field, value := range map[string]string{
+		models.FieldReleaseDate: detail.ReleaseDate,
+		models.FieldText:        detail.Text,
+		models.FieldLink:        detail.Link,
+	} {
+		if source := detail.Sources[field]; value != "" && source != "" {
+			sources[field] = source
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
+
+// EncodeSources кодирует источники полей для колонки sources; пустой набор хранится как {}.
+func EncodeSources(sources map[string]string) (string, error) {
+	if len(sources) == 0 {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return "", fmt.Errorf("encode sources: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// SourcesScanner возвращает sql.Scanner, который читает колонку sources в dst.
+func SourcesScanner(dst *map[string]string) sql.Scanner {
+	return sourcesScanner{dst: dst}
+}
+
+type sourcesScanner struct {
+	dst *map[string]string
+}
+
+func (s sourcesScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s.dst = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("scan sources: unsupported type %T", src)
+	}
+
+	var sources map[string]string
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return fmt.Errorf("scan sources: %w", err)
+	}
+	if len(sources) == 0 {
+		sources = nil
+	}
+
+	*s.dst = sources
+	return nil
+}
+
+// DetailField возвращает указатель на поле сведений песни по имени или nil для другого имени.
+func DetailField(song *models.Song, field string) *string {
+	switch field {
+	case models.FieldReleaseDate:
+		return &song.ReleaseDate
+	case models.FieldText:
+		return &song.Text
+	case models.FieldLink:
+		return &song.Link
+	}
+	return nil
+}
+
+// ManualSources возвращает источники полей песни после правки редактора: поля сведений,
+// чье значение меняется, получают источник manual. Переданные nil поля не меняются.
+func ManualSources(song *models.Song, releaseDate, text, link *string) map[string]string {
+	sources := make(map[string]string, len(song.Sources)+3)
+	for field, source := range song.Sources {
+		sources[field] = source
+	}
+
+	for field, value := range map[string]*string{
+		models.FieldReleaseDate: releaseDate,
+		models.FieldText:        text,
+		models.FieldLink:        link,
+	} {
+		if value != nil && *value != *DetailField(song, field) {
+			sources[field] = models.SourceManual
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
+
+// MergeRefresh применяет к песне изменения applied, если поле не исправлено вручную и не изменилось
+// с момента сравнения; иначе изменение становится предложением. Из proposed отбрасываются уже
+// совпадающие значения. Возвращает фактически примененные изменения и предложения.
+func MergeRefresh(song *models.Song, applied, proposed []models.FieldChange) ([]models.FieldChange, []models.FieldChange) {
+	sources := make(map[string]string, len(song.Sources)+len(applied))
+	for field, source := range song.Sources {
+		sources[field] = source
+	}
+
+	var done, queued []models.FieldChange
+	for _, change := range applied {
+		value := DetailField(song, change.Field)
+		if value == nil {
+			continue
+		}
+		if *value != change.Current || sources[change.Field] == models.SourceManual {
+			proposed = append(proposed, change)
+			continue
+		}
+		*value = change.Proposed
+		sources[change.Field] = change.Source
+		done = append(done, change)
+	}
+
+	for _, change := range proposed {
+		value := DetailField(song, change.Field)
+		if value == nil || *value == change.Proposed {
+			continue
+		}
+		change.Current = *value
+		queued = append(queued, change)
+	}
+
+	if len(sources) == 0 {
+		sources = nil
+	}
+	song.Sources = sources
+
+	return done, queued
+}
+
+// RestoredSources возвращает источники полей песни, возвращенной к снимку правки. Восстановление —
+// решение редактора, поэтому поля, которые оно меняет, получают источник manual. Если текущей
+// песни нет, источники берутся из снимка.
+func RestoredSources(current, snapshot *models.Song) map[string]string {
+	if current == nil {
+		return snapshot.Sources
+	}
+	return ManualSources(current, &snapshot.ReleaseDate, &snapshot.Text, &snapshot.Link)
+}
+
+// ApplyChange записывает в поле песни предложенное значение и его источник. Карта Sources
+// заменяется копией. Возвращает false, если поле не относится к сведениям.
+func ApplyChange(song *models.Song, change models.FieldChange) bool {
+	value := DetailField(song, change.Field)
+	if value == nil {
+		return false
+	}
+	*value = change.Proposed
+
+	sources := make(map[string]string, len(song.Sources)+1)
+	for field, source := range song.Sources {
+		sources[field] = source
+	}
+	sources[change.Field] = change.Source
+	song.Sources = sources
+
+	return true
+}
@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+)
+
+func (s *Storage) AddAlbum(ctx context.Context, album *models.Album, songIDs []int) (int, error) {
+	const op = "storage.sqlite.AddAlbum"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkAlbumUnique(ctx, tx, album.ArtistID, album.Title, 0); err != nil {
+		if errors.Is(err, storage.ErrAlbumExists) {
+			s.log.Warn("Attempt to add existing album",
+				slog.Int("artist_id", album.ArtistID),
+				slog.String("title", album.Title))
+		}
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO albums (title, artist_id, release_date, cover_link)
+        VALUES (?, ?, ?, ?)
+        RETURNING id
+    `, album.Title, album.ArtistID, album.ReleaseDate, album.CoverLink).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertTracks(ctx, tx, id, songIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetAlbum(ctx context.Context, id int) (*models.Album, error) {
+	const op = "storage.sqlite.GetAlbum"
+
+	var album models.Album
+	err := s.db.QueryRowContext(ctx, `
+        SELECT a.id, a.title, a.artist_id, ar.name, a.release_date, a.cover_link
+        FROM albums a
+        JOIN artists ar ON ar.id = a.artist_id
+        WHERE a.id = ?
+    `, id).Scan(&album.ID, &album.Title, &album.ArtistID, &album.Artist, &album.ReleaseDate, &album.CoverLink)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrAlbumNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT ROW_NUMBER() OVER (ORDER BY t.position), t.song_id, s.song
+        FROM album_tracks t
+        JOIN songs s ON s.id = t.song_id
+        WHERE t.album_id = ? AND s.deleted_at IS NULL
+        ORDER BY t.position
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: tracks: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var track models.AlbumTrack
+		if err := rows.Scan(&track.Position, &track.SongID, &track.Song); err != nil {
+			return nil, fmt.Errorf("%s: tracks: %w", op, err)
+		}
+		album.Tracks = append(album.Tracks, track)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: tracks: %w", op, err)
+	}
+
+	return &album, nil
+}
+
+func (s *Storage) GetAlbums(ctx context.Context, artistID int, limit, offset int) ([]*models.Album, error) {
+	const op = "storage.sqlite.GetAlbums"
+
+	// artistID = 0 означает альбомы всех исполнителей
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT a.id, a.title, a.artist_id, ar.name, a.release_date, a.cover_link
+        FROM albums a
+        JOIN artists ar ON ar.id = a.artist_id
+        WHERE ? = 0 OR a.artist_id = ?
+        ORDER BY a.id
+        LIMIT ? OFFSET ?
+    `, artistID, artistID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var albums []*models.Album
+
+	for rows.Next() {
+		var album models.Album
+		err := rows.Scan(&album.ID, &album.Title, &album.ArtistID, &album.Artist, &album.ReleaseDate, &album.CoverLink)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		albums = append(albums, &album)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return albums, nil
+}
+
+func (s *Storage) UpdateAlbum(ctx context.Context, id int, title *string, artistID *int, releaseDate, coverLink *string) error {
+	const op = "storage.sqlite.UpdateAlbum"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var currentTitle string
+	var currentArtistID int
+	err = tx.QueryRowContext(ctx, `SELECT title, artist_id FROM albums WHERE id = ?`, id).
+		Scan(&currentTitle, &currentArtistID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrAlbumNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: lock album: %w", op, err)
+	}
+
+	newTitle, newArtistID := currentTitle, currentArtistID
+	if title != nil {
+		newTitle = *title
+	}
+	if artistID != nil {
+		newArtistID = *artistID
+	}
+
+	if newTitle != currentTitle || newArtistID != currentArtistID {
+		if err := checkAlbumUnique(ctx, tx, newArtistID, newTitle, id); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE albums
+        SET title = ?,
+            artist_id = ?,
+            release_date = COALESCE(?, release_date),
+            cover_link = COALESCE(?, cover_link)
+        WHERE id = ?
+    `, newTitle, newArtistID, releaseDate, coverLink, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SetAlbumTracks(ctx context.Context, id int, songIDs []int) error {
+	const op = "storage.sqlite.SetAlbumTracks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM albums WHERE id = ?)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: check album: %w", op, err)
+	}
+	if !exists {
+		return storage.ErrAlbumNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM album_tracks WHERE album_id = ?`, id); err != nil {
+		return fmt.Errorf("%s: clear tracks: %w", op, err)
+	}
+
+	if err := insertTracks(ctx, tx, id, songIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteAlbum(ctx context.Context, id int) error {
+	const op = "storage.sqlite.DeleteAlbum"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM albums WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrAlbumNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) GetAlbumSongs(ctx context.Context, id int) ([]*models.AlbumSong, error) {
+	const op = "storage.sqlite.GetAlbumSongs"
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM albums WHERE id = ?)`, id).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%s: check album: %w", op, err)
+	}
+	if !exists {
+		return nil, storage.ErrAlbumNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT ROW_NUMBER() OVER (ORDER BY t.position), `+songColumns+`
+        FROM album_tracks t
+        JOIN songs ON songs.id = t.song_id
+        WHERE t.album_id = ? AND songs.deleted_at IS NULL
+        ORDER BY t.position
+    `, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var songs []*models.AlbumSong
+
+	for rows.Next() {
+		var albumSong models.AlbumSong
+		err := rows.Scan(append([]interface{}{&albumSong.Position}, songFields(&albumSong.Song)...)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, &albumSong)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+// checkAlbumUnique проверяет, что исполнитель существует и у него нет другого альбома с таким названием.
+// exceptID исключает из проверки редактируемый альбом.
+func checkAlbumUnique(ctx context.Context, tx *sql.Tx, artistID int, title string, exceptID int) error {
+	var artistExists, albumExists bool
+	err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM artists WHERE id = ?),
+               EXISTS(SELECT 1 FROM albums WHERE artist_id = ? AND title = ? AND id <> ?)
+    `, artistID, artistID, title, exceptID).Scan(&artistExists, &albumExists)
+	if err != nil {
+		return fmt.Errorf("check album: %w", err)
+	}
+
+	if !artistExists {
+		return storage.ErrArtistNotFound
+	}
+	if albumExists {
+		return storage.ErrAlbumExists
+	}
+
+	return nil
+}
+
+// insertTracks записывает трек-лист альбома. Если какой-то песни нет, возвращает ErrSongNotFound.
+func insertTracks(ctx context.Context, tx *sql.Tx, albumID int, songIDs []int) error {
+	for i, songID := range songIDs {
+		result, err := tx.ExecContext(ctx, `
+            INSERT INTO album_tracks (album_id, song_id, position)
+            SELECT ?, id, ? FROM songs WHERE id = ? AND deleted_at IS NULL
+        `, albumID, i+1, songID)
+		if err != nil {
+			return fmt.Errorf("insert track: %w", err)
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("insert track: rows affected: %w", err)
+		}
+		if inserted == 0 {
+			return fmt.Errorf("song %d: %w", songID, storage.ErrSongNotFound)
+		}
+	}
+
+	return nil
+}
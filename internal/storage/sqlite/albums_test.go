@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"reflect"
+	"testing"
+)
+
+// trackIDs возвращает id песен трек-листа по порядку.
+func trackIDs(album *models.Album) []int {
+	ids := make([]int, 0, len(album.Tracks))
+	for _, track := range album.Tracks {
+		ids = append(ids, track.SongID)
+	}
+	return ids
+}
+
+func TestAlbums(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	ids := addSongs(t, s, []models.Song{
+		{Group: "Muse", Song: "Hysteria"},
+		{Group: "Muse", Song: "Time Is Running Out"},
+		{Group: "Muse", Song: "Butterflies and Hurricanes"},
+		{Group: "Blur", Song: "Song 2"},
+	})
+	song, err := s.GetSong(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	muse := song.ArtistID
+
+	album, err := s.AddAlbum(ctx, &models.Album{Title: "Absolution", ArtistID: muse, ReleaseDate: "2003-09-15"}, []int{ids[1], ids[0]})
+	if err != nil {
+		t.Fatalf("AddAlbum() error = %v", err)
+	}
+	if _, err := s.AddAlbum(ctx, &models.Album{Title: "Absolution", ArtistID: muse}, nil); !errors.Is(err, storage.ErrAlbumExists) {
+		t.Errorf("AddAlbum() of an existing title error = %v, want %v", err, storage.ErrAlbumExists)
+	}
+	if _, err := s.AddAlbum(ctx, &models.Album{Title: "Showbiz", ArtistID: 100}, nil); !errors.Is(err, storage.ErrArtistNotFound) {
+		t.Errorf("AddAlbum() of an unknown artist error = %v, want %v", err, storage.ErrArtistNotFound)
+	}
+	if _, err := s.AddAlbum(ctx, &models.Album{Title: "Showbiz", ArtistID: muse}, []int{ids[0], 100}); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("AddAlbum() with an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+	// Альбом с неизвестной песней не сохраняется частично
+	if albums, err := s.GetAlbums(ctx, muse, 10, 0); err != nil || len(albums) != 1 {
+		t.Errorf("GetAlbums() = %v, %v, want only Absolution", albums, err)
+	}
+
+	got, err := s.GetAlbum(ctx, album)
+	if err != nil {
+		t.Fatalf("GetAlbum() error = %v", err)
+	}
+	if got.Title != "Absolution" || got.Artist != "Muse" || got.ReleaseDate != "2003-09-15" {
+		t.Errorf("GetAlbum() = %+v", got)
+	}
+	if want := []models.AlbumTrack{{Position: 1, SongID: ids[1], Song: "Time Is Running Out"}, {Position: 2, SongID: ids[0], Song: "Hysteria"}}; !reflect.DeepEqual(got.Tracks, want) {
+		t.Errorf("GetAlbum() tracks = %+v, want %+v", got.Tracks, want)
+	}
+
+	// Новый трек-лист заменяет прежний целиком и задает порядок
+	if err := s.SetAlbumTracks(ctx, album, []int{ids[0], ids[2], ids[1]}); err != nil {
+		t.Fatalf("SetAlbumTracks() error = %v", err)
+	}
+	if got, _ := s.GetAlbum(ctx, album); !reflect.DeepEqual(trackIDs(got), []int{ids[0], ids[2], ids[1]}) {
+		t.Errorf("tracks after SetAlbumTracks() = %v", trackIDs(got))
+	}
+	if err := s.SetAlbumTracks(ctx, album, []int{ids[3], 100}); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("SetAlbumTracks() with an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+	if got, _ := s.GetAlbum(ctx, album); len(got.Tracks) != 3 {
+		t.Errorf("tracks after a failed SetAlbumTracks() = %v, want the previous list", trackIDs(got))
+	}
+	if err := s.SetAlbumTracks(ctx, 100, nil); !errors.Is(err, storage.ErrAlbumNotFound) {
+		t.Errorf("SetAlbumTracks() of an unknown album error = %v, want %v", err, storage.ErrAlbumNotFound)
+	}
+
+	// Песня из корзины пропадает из трек-листа, а позиции остаются сплошными
+	if err := s.DeleteSong(ctx, ids[2], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+	songs, err := s.GetAlbumSongs(ctx, album)
+	if err != nil || len(songs) != 2 {
+		t.Fatalf("GetAlbumSongs() = %v, %v, want two songs", songs, err)
+	}
+	if songs[0].Position != 1 || songs[0].Song.ID != ids[0] || songs[1].Position != 2 || songs[1].Song.ID != ids[1] {
+		t.Errorf("GetAlbumSongs() = [%d:%d %d:%d], want [1:%d 2:%d]",
+			songs[0].Position, songs[0].Song.ID, songs[1].Position, songs[1].Song.ID, ids[0], ids[1])
+	}
+
+	filtered, err := s.GetFilteredSongs(ctx, map[string]interface{}{"album": album}, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("GetFilteredSongs() error = %v", err)
+	}
+	var filteredIDs []int
+	for _, song := range filtered {
+		filteredIDs = append(filteredIDs, song.ID)
+	}
+	if !reflect.DeepEqual(filteredIDs, []int{ids[0], ids[1]}) {
+		t.Errorf("GetFilteredSongs(album) = %v, want %v", filteredIDs, []int{ids[0], ids[1]})
+	}
+
+	blurSong, _ := s.GetSong(ctx, ids[3])
+	title := "Absolution (Remastered)"
+	if err := s.UpdateAlbum(ctx, album, &title, &blurSong.ArtistID, nil, nil); err != nil {
+		t.Fatalf("UpdateAlbum() error = %v", err)
+	}
+	if got, _ := s.GetAlbum(ctx, album); got.Title != title || got.Artist != "Blur" || got.ReleaseDate != "2003-09-15" {
+		t.Errorf("GetAlbum() after update = %+v", got)
+	}
+	if albums, err := s.GetAlbums(ctx, muse, 10, 0); err != nil || len(albums) != 0 {
+		t.Errorf("GetAlbums(muse) after moving the album = %v, %v, want none", albums, err)
+	}
+
+	other, err := s.AddAlbum(ctx, &models.Album{Title: "Parklife", ArtistID: blurSong.ArtistID}, nil)
+	if err != nil {
+		t.Fatalf("AddAlbum() error = %v", err)
+	}
+	if err := s.UpdateAlbum(ctx, other, &title, nil, nil, nil); !errors.Is(err, storage.ErrAlbumExists) {
+		t.Errorf("UpdateAlbum() to an existing title error = %v, want %v", err, storage.ErrAlbumExists)
+	}
+	if err := s.UpdateAlbum(ctx, 100, &title, nil, nil, nil); !errors.Is(err, storage.ErrAlbumNotFound) {
+		t.Errorf("UpdateAlbum() of an unknown album error = %v, want %v", err, storage.ErrAlbumNotFound)
+	}
+
+	if err := s.DeleteAlbum(ctx, album); err != nil {
+		t.Fatalf("DeleteAlbum() error = %v", err)
+	}
+	if _, err := s.GetAlbum(ctx, album); !errors.Is(err, storage.ErrAlbumNotFound) {
+		t.Errorf("GetAlbum() after delete error = %v, want %v", err, storage.ErrAlbumNotFound)
+	}
+	if _, err := s.GetAlbumSongs(ctx, album); !errors.Is(err, storage.ErrAlbumNotFound) {
+		t.Errorf("GetAlbumSongs() after delete error = %v, want %v", err, storage.ErrAlbumNotFound)
+	}
+	if err := s.DeleteAlbum(ctx, album); !errors.Is(err, storage.ErrAlbumNotFound) {
+		t.Errorf("DeleteAlbum() twice error = %v, want %v", err, storage.ErrAlbumNotFound)
+	}
+	// Песни удаленного альбома остаются в библиотеке
+	if _, err := s.GetSong(ctx, ids[0]); err != nil {
+		t.Errorf("GetSong() of a track of a deleted album error = %v", err)
+	}
+}
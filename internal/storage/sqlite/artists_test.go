@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"reflect"
+	"testing"
+)
+
+func TestArtists(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	year := 1985
+	blur, err := s.AddArtist(ctx, &models.Artist{Name: "Blur", Country: "UK", FormedYear: &year})
+	if err != nil {
+		t.Fatalf("AddArtist() error = %v", err)
+	}
+	if _, err := s.AddArtist(ctx, &models.Artist{Name: "Blur"}); !errors.Is(err, storage.ErrArtistExists) {
+		t.Errorf("AddArtist() of an existing name error = %v, want %v", err, storage.ErrArtistExists)
+	}
+
+	artist, err := s.GetArtist(ctx, blur)
+	if err != nil {
+		t.Fatalf("GetArtist() error = %v", err)
+	}
+	want := &models.Artist{ID: blur, Name: "Blur", Country: "UK", FormedYear: &year, Links: []string{}}
+	if !reflect.DeepEqual(artist, want) {
+		t.Errorf("GetArtist() = %+v, want %+v", artist, want)
+	}
+
+	// Песня с новой группой создает исполнителя
+	ids := addSongs(t, s, []models.Song{{Group: "Muse", Song: "Hysteria"}, {Group: "Muse", Song: "Starlight"}})
+	song, err := s.GetSong(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	muse := song.ArtistID
+
+	artists, err := s.GetArtists(ctx, 10, 0)
+	if err != nil || len(artists) != 2 || artists[0].ID != blur || artists[1].ID != muse {
+		t.Fatalf("GetArtists() = %v, %v, want Blur and Muse", artists, err)
+	}
+
+	links := []string{"https://muse.mu"}
+	description := "Teignmouth"
+	if err := s.UpdateArtist(ctx, muse, nil, nil, nil, &description, &links); err != nil {
+		t.Fatalf("UpdateArtist() error = %v", err)
+	}
+	if artist, _ := s.GetArtist(ctx, muse); artist.Description != description || !reflect.DeepEqual(artist.Links, links) || artist.Name != "Muse" {
+		t.Errorf("GetArtist() after update = %+v", artist)
+	}
+
+	name := "Blur"
+	if err := s.UpdateArtist(ctx, muse, &name, nil, nil, nil, nil); !errors.Is(err, storage.ErrArtistExists) {
+		t.Errorf("UpdateArtist() to an existing name error = %v, want %v", err, storage.ErrArtistExists)
+	}
+	if err := s.UpdateArtist(ctx, 100, nil, &name, nil, nil, nil); !errors.Is(err, storage.ErrArtistNotFound) {
+		t.Errorf("UpdateArtist() of an unknown artist error = %v, want %v", err, storage.ErrArtistNotFound)
+	}
+
+	// Переименование исполнителя переименовывает группу у его песен и попадает в их историю
+	name = "MUSE"
+	if err := s.UpdateArtist(ctx, muse, &name, nil, nil, nil, nil); err != nil {
+		t.Fatalf("UpdateArtist() rename error = %v", err)
+	}
+	songs, err := s.GetArtistSongs(ctx, muse, 10, 0)
+	if err != nil || len(songs) != 2 {
+		t.Fatalf("GetArtistSongs() = %v, %v, want two songs", songs, err)
+	}
+	for _, song := range songs {
+		if song.Group != "MUSE" {
+			t.Errorf("song %d group = %q, want MUSE", song.ID, song.Group)
+		}
+	}
+	revisions, err := s.GetSongRevisions(ctx, ids[0], 1, 0)
+	if err != nil || len(revisions) != 1 || revisions[0].Before.Group != "Muse" || revisions[0].After.Group != "MUSE" {
+		t.Errorf("latest revision = %+v, %v, want the rename", revisions, err)
+	}
+
+	if err := s.DeleteSong(ctx, ids[1], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+	songs, err = s.GetArtistSongs(ctx, muse, 10, 0)
+	if err != nil || len(songs) != 1 || songs[0].ID != ids[0] {
+		t.Errorf("GetArtistSongs() after delete = %v, %v, want only song %d", songs, err, ids[0])
+	}
+	if _, err := s.GetArtistSongs(ctx, 100, 10, 0); !errors.Is(err, storage.ErrArtistNotFound) {
+		t.Errorf("GetArtistSongs() of an unknown artist error = %v, want %v", err, storage.ErrArtistNotFound)
+	}
+
+	if err := s.DeleteArtist(ctx, muse); !errors.Is(err, storage.ErrArtistHasSongs) {
+		t.Errorf("DeleteArtist() with songs error = %v, want %v", err, storage.ErrArtistHasSongs)
+	}
+	if _, err := s.AddAlbum(ctx, &models.Album{Title: "Parklife", ArtistID: blur}, nil); err != nil {
+		t.Fatalf("AddAlbum() error = %v", err)
+	}
+	if err := s.DeleteArtist(ctx, blur); !errors.Is(err, storage.ErrArtistHasAlbums) {
+		t.Errorf("DeleteArtist() with albums error = %v, want %v", err, storage.ErrArtistHasAlbums)
+	}
+
+	radiohead, err := s.AddArtist(ctx, &models.Artist{Name: "Radiohead"})
+	if err != nil {
+		t.Fatalf("AddArtist() error = %v", err)
+	}
+	if err := s.DeleteArtist(ctx, radiohead); err != nil {
+		t.Fatalf("DeleteArtist() error = %v", err)
+	}
+	if _, err := s.GetArtist(ctx, radiohead); !errors.Is(err, storage.ErrArtistNotFound) {
+		t.Errorf("GetArtist() after delete error = %v, want %v", err, storage.ErrArtistNotFound)
+	}
+	if err := s.DeleteArtist(ctx, radiohead); !errors.Is(err, storage.ErrArtistNotFound) {
+		t.Errorf("DeleteArtist() twice error = %v, want %v", err, storage.ErrArtistNotFound)
+	}
+}
@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+// enqueueEnrichment создает задачу обогащения песни в транзакции, добавившей песню.
+func enqueueEnrichment(ctx context.Context, tx *sql.Tx, songID int) error {
+	now := formatTime(time.Now())
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO enrichment_outbox (song_id, next_attempt_at, created_at) VALUES (?, ?, ?)
+    `, songID, now, now)
+	if err != nil {
+		return fmt.Errorf("enqueue enrichment: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]*models.EnrichmentTask, error) {
+	const op = "storage.sqlite.ClaimEnrichmentTasks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	rows, err := tx.QueryContext(ctx, `
+        SELECT o.id, o.song_id, s."group", s.song, o.attempts + 1
+        FROM enrichment_outbox o
+        JOIN songs s ON s.id = o.song_id
+        WHERE o.next_attempt_at <= ?
+        ORDER BY o.next_attempt_at, o.id
+        LIMIT ?
+    `, formatTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var tasks []*models.EnrichmentTask
+
+	for rows.Next() {
+		var task models.EnrichmentTask
+		if err := rows.Scan(&task.ID, &task.SongID, &task.Group, &task.Song, &task.Attempts); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tasks = append(tasks, &task)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	leaseUntil := formatTime(now.Add(lease))
+	for _, task := range tasks {
+		_, err := tx.ExecContext(ctx, `
+            UPDATE enrichment_outbox SET attempts = ?, next_attempt_at = ? WHERE id = ?
+        `, task.Attempts, leaseUntil, task.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: claim task: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) CompleteEnrichment(ctx context.Context, taskID int, detail models.SongDetail) error {
+	const op = "storage.sqlite.CompleteEnrichment"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Песню могли окончательно удалить вместе с задачей, пока шел запрос к API
+	var before models.Song
+	err = tx.QueryRowContext(ctx, `
+        SELECT `+songColumns+`
+        FROM enrichment_outbox o
+        JOIN songs ON songs.id = o.song_id
+        WHERE o.id = ?
+    `, taskID).Scan(songFields(&before)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: load song: %w", op, err)
+	}
+
+	// Поля, заполненные вручную, пока песня ждала обогащения, не перезаписываются
+	after := before
+	after.EnrichmentStatus = models.EnrichmentEnriched
+	storage.ApplyDetail(&after, detail)
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs
+        SET release_date = ?, text = ?, link = ?, enrichment_status = ?, sources = ?, refreshed_at = ?
+        WHERE id = ?
+    `, after.ReleaseDate, after.Text, after.Link, after.EnrichmentStatus, sources, formatTime(time.Now()), after.ID)
+	if err != nil {
+		return fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, after.ID, after.Text); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, before.ID, models.RevisionUpdate, &before, &after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM enrichment_outbox WHERE id = ?`, taskID); err != nil {
+		return fmt.Errorf("%s: delete task: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RetryEnrichment(ctx context.Context, taskID int, nextAttemptAt time.Time, lastError string) error {
+	const op = "storage.sqlite.RetryEnrichment"
+
+	_, err := s.db.ExecContext(ctx, `
+        UPDATE enrichment_outbox SET next_attempt_at = ?, last_error = ? WHERE id = ?
+    `, formatTime(nextAttemptAt), lastError, taskID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) FailEnrichment(ctx context.Context, taskID int) error {
+	const op = "storage.sqlite.FailEnrichment"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs SET enrichment_status = ?
+        WHERE id = (SELECT song_id FROM enrichment_outbox WHERE id = ?)
+    `, models.EnrichmentFailed, taskID)
+	if err != nil {
+		return fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM enrichment_outbox WHERE id = ?`, taskID); err != nil {
+		return fmt.Errorf("%s: delete task: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestEnrichmentOutbox(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	ids := make(map[string]int)
+	for _, name := range []string{"Hysteria", "Starlight", "Uprising"} {
+		id, err := s.AddSongForEnrichment(ctx, "Muse", name)
+		if err != nil {
+			t.Fatalf("AddSongForEnrichment() error = %v", err)
+		}
+		ids[name] = id
+	}
+
+	// Задачи выдаются по очереди не больше limit за раз, взятая задача скрыта на срок аренды
+	first, err := s.ClaimEnrichmentTasks(ctx, 2, time.Hour)
+	if err != nil || len(first) != 2 || first[0].Song != "Hysteria" || first[1].Song != "Starlight" || first[0].Attempts != 1 {
+		t.Fatalf("ClaimEnrichmentTasks() = %+v, %v, want Hysteria and Starlight at attempt 1", first, err)
+	}
+	uprising, err := s.ClaimEnrichmentTasks(ctx, 10, time.Millisecond)
+	if err != nil || len(uprising) != 1 || uprising[0].Song != "Uprising" {
+		t.Fatalf("ClaimEnrichmentTasks() = %+v, %v, want only Uprising", uprising, err)
+	}
+
+	// По истечении аренды задача возвращается, и попытка засчитывается снова
+	time.Sleep(5 * time.Millisecond)
+	again, err := s.ClaimEnrichmentTasks(ctx, 10, time.Hour)
+	if err != nil || len(again) != 1 || again[0].Song != "Uprising" || again[0].Attempts != 2 {
+		t.Fatalf("ClaimEnrichmentTasks() after the lease = %+v, %v, want Uprising at attempt 2", again, err)
+	}
+
+	// Отложенная задача ждет своего срока
+	if err := s.RetryEnrichment(ctx, again[0].ID, time.Now().Add(time.Hour), "unavailable"); err != nil {
+		t.Fatalf("RetryEnrichment() error = %v", err)
+	}
+	if tasks, err := s.ClaimEnrichmentTasks(ctx, 10, time.Hour); err != nil || len(tasks) != 0 {
+		t.Errorf("ClaimEnrichmentTasks() before the retry = %+v, %v, want none", tasks, err)
+	}
+	if err := s.RetryEnrichment(ctx, again[0].ID, time.Now().Add(-time.Second), "unavailable"); err != nil {
+		t.Fatalf("RetryEnrichment() error = %v", err)
+	}
+	if tasks, err := s.ClaimEnrichmentTasks(ctx, 10, time.Hour); err != nil || len(tasks) != 1 || tasks[0].Attempts != 3 {
+		t.Errorf("ClaimEnrichmentTasks() after the retry = %+v, %v, want Uprising at attempt 3", tasks, err)
+	}
+
+	// Сведения дополняют только пустые поля
+	text := "edited by hand"
+	if err := s.UpdateSong(ctx, ids["Hysteria"], 0, nil, nil, nil, &text, nil); err != nil {
+		t.Fatalf("UpdateSong() error = %v", err)
+	}
+	detail := models.SongDetail{ReleaseDate: "16.07.2006", Text: "It's bugging me", Link: "https://example.com/1"}
+	if err := s.CompleteEnrichment(ctx, first[0].ID, detail); err != nil {
+		t.Fatalf("CompleteEnrichment() error = %v", err)
+	}
+	song, err := s.GetSong(ctx, ids["Hysteria"])
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.EnrichmentStatus != models.EnrichmentEnriched || song.Text != text || song.ReleaseDate != detail.ReleaseDate || song.Link != detail.Link {
+		t.Errorf("enriched song = %+v, want the manual text and the fetched date and link", song)
+	}
+	// Повторное завершение удаленной задачи ничего не делает
+	if err := s.CompleteEnrichment(ctx, first[0].ID, models.SongDetail{Text: "again"}); err != nil {
+		t.Errorf("CompleteEnrichment() of a finished task error = %v", err)
+	}
+
+	if err := s.FailEnrichment(ctx, first[1].ID); err != nil {
+		t.Fatalf("FailEnrichment() error = %v", err)
+	}
+	song, err = s.GetSong(ctx, ids["Starlight"])
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.EnrichmentStatus != models.EnrichmentFailed {
+		t.Errorf("failed song status = %s, want %s", song.EnrichmentStatus, models.EnrichmentFailed)
+	}
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"strings"
+)
+
+// ExportSongs читает строки по мере обхода результата запроса; SQLite не материализует выборку целиком.
+func (s *Storage) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	const op = "storage.sqlite.ExportSongs"
+
+	conditions, args := filterConditions(filters)
+	query := `SELECT ` + songColumns + ` FROM songs WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(&song); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"modernc.org/sqlite"
+	"strings"
+)
+
+// Функции регистрируются для всех соединений, открытых после регистрации, поэтому до NewStorage.
+func init() {
+	// unicode_lower переводит строку в нижний регистр во всех алфавитах: встроенная lower
+	// без расширения ICU меняет только латиницу
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			switch v := args[0].(type) {
+			case string:
+				return strings.ToLower(v), nil
+			case []byte:
+				return strings.ToLower(string(v)), nil
+			default:
+				return v, nil
+			}
+		})
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+func (s *Storage) GetInfoCache(ctx context.Context, key string) (*models.InfoCacheEntry, error) {
+	const op = "storage.sqlite.GetInfoCache"
+
+	var entry models.InfoCacheEntry
+	var expiresAt string
+
+	err := s.db.QueryRowContext(ctx, `
+        SELECT release_date, text, link, sources, not_found, expires_at
+        FROM song_info_cache
+        WHERE key = ? AND expires_at > ?
+    `, key, formatTime(time.Now())).Scan(&entry.Detail.ReleaseDate, &entry.Detail.Text, &entry.Detail.Link,
+		storage.SourcesScanner(&entry.Detail.Sources), &entry.NotFound, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if entry.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entry, nil
+}
+
+func (s *Storage) SaveInfoCache(ctx context.Context, key string, entry models.InfoCacheEntry) error {
+	const op = "storage.sqlite.SaveInfoCache"
+
+	sources, err := storage.EncodeSources(entry.Detail.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM song_info_cache WHERE expires_at <= ?`, formatTime(time.Now())); err != nil {
+		return fmt.Errorf("%s: delete expired: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO song_info_cache (key, release_date, text, link, sources, not_found, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (key) DO UPDATE
+        SET release_date = excluded.release_date, text = excluded.text, link = excluded.link,
+            sources = excluded.sources, not_found = excluded.not_found, expires_at = excluded.expires_at
+    `, key, entry.Detail.ReleaseDate, entry.Detail.Text, entry.Detail.Link, sources, entry.NotFound,
+		formatTime(entry.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteInfoCache(ctx context.Context, key string) (bool, error) {
+	const op = "storage.sqlite.DeleteInfoCache"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM song_info_cache WHERE key = ?`, key)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return affected > 0, nil
+}
+
+func (s *Storage) ClearInfoCache(ctx context.Context) (int, error) {
+	const op = "storage.sqlite.ClearInfoCache"
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM song_info_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInfoCache(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	found := models.InfoCacheEntry{
+		Detail:    models.SongDetail{ReleaseDate: "15.12.2003", Text: "It's bugging me", Sources: map[string]string{"text": "info"}},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := s.SaveInfoCache(ctx, "Muse/Hysteria", found); err != nil {
+		t.Fatalf("SaveInfoCache() error = %v", err)
+	}
+	if err := s.SaveInfoCache(ctx, "Muse/Unknown", models.InfoCacheEntry{NotFound: true, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveInfoCache() error = %v", err)
+	}
+
+	got, err := s.GetInfoCache(ctx, "Muse/Hysteria")
+	if err != nil || got == nil {
+		t.Fatalf("GetInfoCache() = %+v, %v, want the entry", got, err)
+	}
+	if !reflect.DeepEqual(got.Detail, found.Detail) || got.NotFound || got.ExpiresAt.Sub(found.ExpiresAt).Abs() > time.Millisecond {
+		t.Errorf("GetInfoCache() = %+v, want %+v", got, found)
+	}
+	if got, err := s.GetInfoCache(ctx, "Muse/Unknown"); err != nil || got == nil || !got.NotFound {
+		t.Errorf("GetInfoCache() of a not found answer = %+v, %v, want not_found", got, err)
+	}
+	if got, err := s.GetInfoCache(ctx, "Muse/Starlight"); err != nil || got != nil {
+		t.Errorf("GetInfoCache() of a missing key = %+v, %v, want nil", got, err)
+	}
+
+	// Запись с истекшим сроком не отдается и удаляется при следующем сохранении
+	found.ExpiresAt = time.Now().Add(-time.Second)
+	if err := s.SaveInfoCache(ctx, "Muse/Hysteria", found); err != nil {
+		t.Fatalf("SaveInfoCache() error = %v", err)
+	}
+	if got, err := s.GetInfoCache(ctx, "Muse/Hysteria"); err != nil || got != nil {
+		t.Errorf("GetInfoCache() of an expired entry = %+v, %v, want nil", got, err)
+	}
+	if err := s.SaveInfoCache(ctx, "Muse/Starlight", models.InfoCacheEntry{ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveInfoCache() error = %v", err)
+	}
+	if removed, err := s.DeleteInfoCache(ctx, "Muse/Hysteria"); err != nil || removed {
+		t.Errorf("DeleteInfoCache() of an expired entry = %v, %v, want it already removed", removed, err)
+	}
+
+	if removed, err := s.DeleteInfoCache(ctx, "Muse/Unknown"); err != nil || !removed {
+		t.Errorf("DeleteInfoCache() = %v, %v, want true", removed, err)
+	}
+	if cleared, err := s.ClearInfoCache(ctx); err != nil || cleared != 1 {
+		t.Errorf("ClearInfoCache() = %d, %v, want 1", cleared, err)
+	}
+}
@@ -0,0 +1,365 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+// Состояния предложений обновления.
+const (
+	proposalPending  = "pending"
+	proposalRejected = "rejected"
+)
+
+func (s *Storage) MarkSongStale(ctx context.Context, id int) error {
+	const op = "storage.sqlite.MarkSongStale"
+
+	result, err := s.db.ExecContext(ctx, `UPDATE songs SET refreshed_at = NULL WHERE id = ? AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrSongNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) ClaimRefreshCandidates(ctx context.Context, maxAge, lease time.Duration, limit int) ([]*models.Song, error) {
+	const op = "storage.sqlite.ClaimRefreshCandidates"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	// Устаревшие песни (refreshed_at IS NULL) идут первыми, затем давно не сверявшиеся
+	rows, err := tx.QueryContext(ctx, `
+        SELECT `+songColumns+`
+        FROM songs
+        WHERE deleted_at IS NULL AND enrichment_status = ?
+          AND (refreshed_at IS NULL OR refreshed_at < ?)
+        ORDER BY refreshed_at IS NOT NULL, refreshed_at, id
+        LIMIT ?
+    `, models.EnrichmentEnriched, formatTime(now.Add(-maxAge)), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var songs []*models.Song
+
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, &song)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Взятая песня снова станет кандидатом через lease, если сверка не завершится
+	leased := formatTime(now.Add(lease - maxAge))
+	for _, song := range songs {
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET refreshed_at = ? WHERE id = ?`, leased, song.ID); err != nil {
+			return nil, fmt.Errorf("%s: lease song: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) CompleteRefresh(ctx context.Context, songID int, applied, proposed []models.FieldChange) (*models.RefreshResult, error) {
+	const op = "storage.sqlite.CompleteRefresh"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSong(ctx, tx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return nil, storage.ErrSongNotFound
+	}
+
+	after := *before
+	result := &models.RefreshResult{SongID: songID}
+	result.Applied, proposed = storage.MergeRefresh(&after, applied, proposed)
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs SET release_date = ?, text = ?, link = ?, sources = ?, refreshed_at = ?
+        WHERE id = ?
+    `, after.ReleaseDate, after.Text, after.Link, sources, formatTime(time.Now()), songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, songID, after.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if len(result.Applied) > 0 {
+		if err := recordRevision(ctx, tx, songID, models.RevisionUpdate, before, &after); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	result.Proposed, err = replaceProposals(ctx, tx, songID, proposed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// replaceProposals заменяет ожидающие предложения песни новыми. Предложение с тем же значением
+// сохраняет свой id; предложение, которое редактор уже отклонил с тем же значением, не создается
+// снова. Возвращает ожидающие предложения из proposed.
+func replaceProposals(ctx context.Context, tx *sql.Tx, songID int, proposed []models.FieldChange) ([]models.FieldChange, error) {
+	rows, err := tx.QueryContext(ctx, `
+        SELECT field, proposed_value, status FROM song_refresh_proposals WHERE song_id = ?
+    `, songID)
+	if err != nil {
+		return nil, fmt.Errorf("load proposals: %w", err)
+	}
+
+	type existingProposal struct{ proposed, status string }
+	existing := make(map[string]existingProposal)
+
+	for rows.Next() {
+		var field string
+		var proposal existingProposal
+		if err := rows.Scan(&field, &proposal.proposed, &proposal.status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("load proposals: %w", err)
+		}
+		existing[field] = proposal
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load proposals: %w", err)
+	}
+
+	now := formatTime(time.Now())
+	var pending []models.FieldChange
+
+	for _, change := range proposed {
+		previous, ok := existing[change.Field]
+		delete(existing, change.Field)
+
+		if ok && previous.proposed == change.Proposed {
+			if previous.status == proposalRejected {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+                UPDATE song_refresh_proposals SET current_value = ? WHERE song_id = ? AND field = ?
+            `, change.Current, songID, change.Field); err != nil {
+				return nil, fmt.Errorf("save proposal: %w", err)
+			}
+			pending = append(pending, change)
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO song_refresh_proposals (song_id, field, current_value, proposed_value, source, status, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?)
+            ON CONFLICT (song_id, field) DO UPDATE
+            SET current_value = excluded.current_value, proposed_value = excluded.proposed_value,
+                source = excluded.source, status = excluded.status, created_at = excluded.created_at
+        `, songID, change.Field, change.Current, change.Proposed, change.Source, proposalPending, now)
+		if err != nil {
+			return nil, fmt.Errorf("save proposal: %w", err)
+		}
+		pending = append(pending, change)
+	}
+
+	// Ожидающие предложения, которые источники больше не подтверждают, устарели
+	for field, previous := range existing {
+		if previous.status != proposalPending {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+            DELETE FROM song_refresh_proposals WHERE song_id = ? AND field = ?
+        `, songID, field); err != nil {
+			return nil, fmt.Errorf("delete proposal: %w", err)
+		}
+	}
+
+	return pending, nil
+}
+
+// proposalColumns перечисляет колонки предложения в порядке, который ожидает scanProposal.
+const proposalColumns = `p.id, p.song_id, p.field, p.current_value, p.proposed_value, p.source, p.created_at`
+
+func scanProposal(row interface{ Scan(...interface{}) error }) (*models.RefreshProposal, error) {
+	var proposal models.RefreshProposal
+	var createdAt string
+
+	err := row.Scan(&proposal.ID, &proposal.SongID, &proposal.Field, &proposal.Current,
+		&proposal.Proposed, &proposal.Source, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+func (s *Storage) GetRefreshProposals(ctx context.Context, limit, offset int) ([]*models.RefreshProposal, error) {
+	const op = "storage.sqlite.GetRefreshProposals"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+proposalColumns+`
+        FROM song_refresh_proposals p
+        JOIN songs ON songs.id = p.song_id
+        WHERE p.status = ? AND songs.deleted_at IS NULL
+        ORDER BY p.id
+        LIMIT ? OFFSET ?
+    `, proposalPending, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var proposals []*models.RefreshProposal
+
+	for rows.Next() {
+		proposal, err := scanProposal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) AcceptRefreshProposal(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.sqlite.AcceptRefreshProposal"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	proposal, err := scanProposal(tx.QueryRowContext(ctx, `
+        SELECT `+proposalColumns+` FROM song_refresh_proposals p WHERE p.id = ? AND p.status = ?
+    `, id, proposalPending))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRefreshProposalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: load proposal: %w", op, err)
+	}
+
+	before, err := loadSong(ctx, tx, proposal.SongID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return nil, storage.ErrSongNotFound
+	}
+
+	after := *before
+	if !storage.ApplyChange(&after, proposal.FieldChange) {
+		return nil, fmt.Errorf("%s: unknown field %q", op, proposal.Field)
+	}
+
+	sources, err := storage.EncodeSources(after.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs SET release_date = ?, text = ?, link = ?, sources = ? WHERE id = ?
+    `, after.ReleaseDate, after.Text, after.Link, sources, after.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: update song: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, after.ID, after.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, after.ID, models.RevisionUpdate, before, &after); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM song_refresh_proposals WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("%s: delete proposal: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &after, nil
+}
+
+func (s *Storage) RejectRefreshProposal(ctx context.Context, id int) error {
+	const op = "storage.sqlite.RejectRefreshProposal"
+
+	result, err := s.db.ExecContext(ctx, `
+        UPDATE song_refresh_proposals SET status = ? WHERE id = ? AND status = ?
+    `, proposalRejected, id, proposalPending)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrRefreshProposalNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	id, err := s.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{
+		ReleaseDate: "01.01.2003",
+		Text:        "old text",
+		Sources:     map[string]string{models.FieldReleaseDate: "info", models.FieldText: "info"},
+	})
+	if err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	// Только что добавленную песню сверять рано, устаревшая берется один раз на срок аренды
+	if songs, err := s.ClaimRefreshCandidates(ctx, time.Hour, time.Hour, 10); err != nil || len(songs) != 0 {
+		t.Fatalf("ClaimRefreshCandidates() of a new song = %+v, %v, want none", songs, err)
+	}
+	if err := s.MarkSongStale(ctx, id); err != nil {
+		t.Fatalf("MarkSongStale() error = %v", err)
+	}
+	if songs, err := s.ClaimRefreshCandidates(ctx, time.Hour, time.Hour, 10); err != nil || len(songs) != 1 || songs[0].ID != id {
+		t.Fatalf("ClaimRefreshCandidates() of a stale song = %+v, %v, want song %d", songs, err, id)
+	}
+	if songs, err := s.ClaimRefreshCandidates(ctx, time.Hour, time.Hour, 10); err != nil || len(songs) != 0 {
+		t.Errorf("ClaimRefreshCandidates() of a claimed song = %+v, %v, want none", songs, err)
+	}
+	if err := s.MarkSongStale(ctx, 100); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("MarkSongStale() of an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+
+	date := models.FieldChange{Field: models.FieldReleaseDate, Current: "01.01.2003", Proposed: "15.12.2003", Source: "info"}
+	text := models.FieldChange{Field: models.FieldText, Current: "old text", Proposed: "It's bugging me", Source: "info"}
+	link := models.FieldChange{Field: models.FieldLink, Proposed: "https://example.com/hysteria", Source: "info"}
+
+	result, err := s.CompleteRefresh(storage.WithActor(ctx, "refresh"), id, []models.FieldChange{date}, []models.FieldChange{text, link})
+	if err != nil {
+		t.Fatalf("CompleteRefresh() error = %v", err)
+	}
+	if len(result.Applied) != 1 || len(result.Proposed) != 2 {
+		t.Fatalf("CompleteRefresh() = %+v, want 1 applied and 2 proposed", result)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if song.ReleaseDate != date.Proposed || song.Text != "old text" {
+		t.Errorf("song = %+v, want only the date applied", song)
+	}
+
+	proposals, err := s.GetRefreshProposals(ctx, 10, 0)
+	if err != nil || len(proposals) != 2 {
+		t.Fatalf("GetRefreshProposals() = %+v, %v, want 2 proposals", proposals, err)
+	}
+	byField := make(map[string]int)
+	for _, proposal := range proposals {
+		byField[proposal.Field] = proposal.ID
+	}
+
+	song, err = s.AcceptRefreshProposal(storage.WithActor(ctx, "alice"), byField[models.FieldText])
+	if err != nil {
+		t.Fatalf("AcceptRefreshProposal() error = %v", err)
+	}
+	if song.Text != text.Proposed || song.Sources[models.FieldText] != "info" {
+		t.Errorf("song after accept = %+v, want the proposed text from info", song)
+	}
+	revisions, err := s.GetSongRevisions(ctx, id, 1, 0)
+	if err != nil || len(revisions) != 1 || revisions[0].Actor != "alice" {
+		t.Errorf("latest revision = %+v, %v, want one by alice", revisions, err)
+	}
+
+	if err := s.RejectRefreshProposal(ctx, byField[models.FieldLink]); err != nil {
+		t.Fatalf("RejectRefreshProposal() error = %v", err)
+	}
+
+	// Отклоненное значение не предлагается снова
+	result, err = s.CompleteRefresh(ctx, id, nil, []models.FieldChange{link})
+	if err != nil || len(result.Proposed) != 0 {
+		t.Errorf("CompleteRefresh() with the rejected value = %+v, %v, want no proposals", result, err)
+	}
+
+	// Принятое и отклоненное предложения больше не ждут решения
+	for _, proposalID := range []int{byField[models.FieldText], byField[models.FieldLink], 100} {
+		if _, err := s.AcceptRefreshProposal(ctx, proposalID); !errors.Is(err, storage.ErrRefreshProposalNotFound) {
+			t.Errorf("AcceptRefreshProposal(%d) error = %v, want %v", proposalID, err, storage.ErrRefreshProposalNotFound)
+		}
+	}
+	if err := s.RejectRefreshProposal(ctx, byField[models.FieldText]); !errors.Is(err, storage.ErrRefreshProposalNotFound) {
+		t.Errorf("RejectRefreshProposal() of an accepted proposal error = %v, want %v", err, storage.ErrRefreshProposalNotFound)
+	}
+
+	// Новое значение того же поля предлагается снова
+	link.Proposed = "https://example.com/hysteria-live"
+	result, err = s.CompleteRefresh(ctx, id, nil, []models.FieldChange{link})
+	if err != nil || len(result.Proposed) != 1 {
+		t.Errorf("CompleteRefresh() with a new value = %+v, %v, want 1 proposal", result, err)
+	}
+}
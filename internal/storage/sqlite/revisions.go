@@ -0,0 +1,300 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+const revisionColumns = `song_id, revision, action, before, after, actor, created_at`
+
+func (s *Storage) GetSongRevisions(ctx context.Context, songID int, limit, offset int) ([]*models.SongRevision, error) {
+	const op = "storage.sqlite.GetSongRevisions"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+revisionColumns+`
+        FROM song_revisions
+        WHERE song_id = ?
+        ORDER BY revision DESC
+        LIMIT ? OFFSET ?
+    `, songID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.SongRevision
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Пустая история допустима для песен, добавленных до появления правок
+	if len(revisions) == 0 {
+		var known bool
+		err := s.db.QueryRowContext(ctx, `
+            SELECT EXISTS(SELECT 1 FROM songs WHERE id = ?)
+                OR EXISTS(SELECT 1 FROM song_revisions WHERE song_id = ?)
+        `, songID, songID).Scan(&known)
+		if err != nil {
+			return nil, fmt.Errorf("%s: check song: %w", op, err)
+		}
+		if !known {
+			return nil, storage.ErrSongNotFound
+		}
+	}
+
+	return revisions, nil
+}
+
+func (s *Storage) GetSongRevision(ctx context.Context, songID, revision int) (*models.SongRevision, error) {
+	const op = "storage.sqlite.GetSongRevision"
+
+	result, err := scanRevision(s.db.QueryRowContext(ctx, `
+        SELECT `+revisionColumns+`
+        FROM song_revisions
+        WHERE song_id = ? AND revision = ?
+    `, songID, revision))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+func (s *Storage) RestoreSongRevision(ctx context.Context, songID, revision int) (*models.Song, error) {
+	const op = "storage.sqlite.RestoreSongRevision"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	target, err := scanRevision(tx.QueryRowContext(ctx, `
+        SELECT `+revisionColumns+`
+        FROM song_revisions
+        WHERE song_id = ? AND revision = ?
+    `, songID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: load revision: %w", op, err)
+	}
+
+	snapshot := target.After
+	if snapshot == nil {
+		snapshot = target.Before
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("%s: revision %d has no snapshot", op, revision)
+	}
+
+	current, err := loadSong(ctx, tx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM songs WHERE "group" = ? AND song = ? AND id <> ? AND deleted_at IS NULL)
+    `, snapshot.Group, snapshot.Song, songID).Scan(&taken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: check song existence: %w", op, err)
+	}
+	if taken {
+		return nil, storage.ErrSongExists
+	}
+
+	// Песня из корзины восстанавливается на месте, а окончательно удаленная — под прежним id
+	var trashed bool
+	if current == nil {
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM songs WHERE id = ?)`, songID).Scan(&trashed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: check trash: %w", op, err)
+		}
+	}
+
+	artistID, err := ensureArtist(ctx, tx, snapshot.Group)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	restored := *snapshot
+	restored.ID = songID
+	restored.ArtistID = artistID
+	restored.Sources = storage.RestoredSources(current, snapshot)
+
+	sources, err := storage.EncodeSources(restored.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current == nil && !trashed {
+		// Песня снова считается добавленной тогда же, когда и впервые
+		err = tx.QueryRowContext(ctx, `
+            INSERT INTO songs (id, "group", song, release_date, text, link, artist_id, sources, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(
+                (SELECT strftime('%Y-%m-%dT%H:%M:%S.000000000Z', MIN(created_at)) FROM song_revisions WHERE song_id = ?), ?))
+            RETURNING created_at
+        `, songID, snapshot.Group, snapshot.Song, snapshot.ReleaseDate, snapshot.Text, snapshot.Link, artistID, sources,
+			songID, formatTime(time.Now())).Scan(timeScanner{dst: &restored.CreatedAt})
+	} else {
+		err = tx.QueryRowContext(ctx, `
+            UPDATE songs
+            SET "group" = ?, song = ?, release_date = ?, text = ?, link = ?, artist_id = ?, sources = ?, deleted_at = NULL
+            WHERE id = ?
+            RETURNING created_at
+        `, snapshot.Group, snapshot.Song, snapshot.ReleaseDate, snapshot.Text, snapshot.Link, artistID, sources, songID).Scan(timeScanner{dst: &restored.CreatedAt})
+	}
+	if isSongNameTaken(err) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current == nil || current.Text != restored.Text {
+		if err := saveVerses(ctx, tx, songID, restored.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, songID, models.RevisionRestore, current, &restored); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &restored, nil
+}
+
+// loadSong читает песню внутри транзакции. Если песни нет или она в корзине, возвращает nil.
+func loadSong(ctx context.Context, tx *sql.Tx, id int) (*models.Song, error) {
+	var song models.Song
+	err := tx.QueryRowContext(ctx, `SELECT `+songColumns+` FROM songs WHERE id = ? AND deleted_at IS NULL`, id).
+		Scan(songFields(&song)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load song: %w", err)
+	}
+
+	return &song, nil
+}
+
+// recordRevision записывает правку песни с автором из контекста. Номер правки следует
+// за последним номером этой песни и становится версией песни, если after не nil: версия
+// записывается и в after, и в таблицу songs.
+func recordRevision(ctx context.Context, tx *sql.Tx, songID int, action string, before, after *models.Song) error {
+	var revision int
+	err := tx.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(revision), 0) + 1 FROM song_revisions WHERE song_id = ?
+    `, songID).Scan(&revision)
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+
+	if after != nil {
+		after.Version = revision
+	}
+
+	encodedBefore, err := encodeSnapshot(before)
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+	encodedAfter, err := encodeSnapshot(after)
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO song_revisions (song_id, revision, action, before, after, actor, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, songID, revision, action, encodedBefore, encodedAfter, storage.ActorFromContext(ctx), time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+
+	if after != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET version = ? WHERE id = ?`, revision, songID); err != nil {
+			return fmt.Errorf("record revision: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// encodeSnapshot кодирует снимок песни в JSON. Отсутствующий снимок записывается как NULL.
+func encodeSnapshot(song *models.Song) (*string, error) {
+	if song == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(song)
+	if err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	value := string(data)
+	return &value, nil
+}
+
+func scanRevision(row rowScanner) (*models.SongRevision, error) {
+	var revision models.SongRevision
+	var before, after []byte
+	var createdAt string
+
+	err := row.Scan(&revision.SongID, &revision.Revision, &revision.Action, &before, &after, &revision.Actor, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("parse created_at: %w", err)
+	}
+
+	if revision.Before, err = decodeSnapshot(before); err != nil {
+		return nil, err
+	}
+	if revision.After, err = decodeSnapshot(after); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+func decodeSnapshot(data []byte) (*models.Song, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var song models.Song
+	if err := json.Unmarshal(data, &song); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	return &song, nil
+}
@@ -0,0 +1,627 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"log/slog"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strings"
+	"time"
+)
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewStorage(path string, log *slog.Logger) (*Storage, error) {
+	const op = "storage.sqlite.NewStorage"
+
+	// Транзакции сразу берут блокировку на запись: иначе параллельные записи
+	// получают SQLITE_BUSY при повышении блокировки, и busy_timeout не помогает
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{
+		db:  db,
+		log: log,
+	}, nil
+}
+
+func (s *Storage) AddSong(ctx context.Context, group, song string, detail models.SongDetail) (int, error) {
+	return s.addSong(ctx, "storage.sqlite.AddSong", group, song, detail, models.EnrichmentEnriched)
+}
+
+// AddSongForEnrichment сохраняет песню без сведений и в той же транзакции ставит ее в очередь обогащения.
+func (s *Storage) AddSongForEnrichment(ctx context.Context, group, song string) (int, error) {
+	return s.addSong(ctx, "storage.sqlite.AddSongForEnrichment", group, song, models.SongDetail{}, models.EnrichmentPending)
+}
+
+func (s *Storage) addSong(ctx context.Context, op, group, song string, detail models.SongDetail, status string) (int, error) {
+	sources := storage.DetailSources(detail)
+	encodedSources, err := storage.EncodeSources(sources)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT EXISTS(
+            SELECT 1 FROM songs WHERE "group" = ? AND song = ? AND deleted_at IS NULL
+        )
+    `, group, song).Scan(&exists)
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: check song existence: %w", op, err)
+	}
+
+	if exists {
+		s.log.Warn("Attempt to add existing song",
+			slog.String("group", group),
+			slog.String("song", song))
+		return 0, storage.ErrSongExists
+	}
+
+	artistID, err := ensureArtist(ctx, tx, group)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := formatTime(time.Now())
+
+	// Песня без сведений сверится с источниками после обогащения
+	var refreshedAt *string
+	if status == models.EnrichmentEnriched {
+		refreshedAt = &now
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO songs ("group", song, release_date, text, link, artist_id, enrichment_status, sources, refreshed_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING id
+    `, group, song, detail.ReleaseDate, detail.Text, detail.Link, artistID, status, encodedSources, refreshedAt, now).Scan(&id)
+
+	if isSongNameTaken(err) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created := &models.Song{
+		ID:               id,
+		Group:            group,
+		Song:             song,
+		ReleaseDate:      detail.ReleaseDate,
+		Text:             detail.Text,
+		Link:             detail.Link,
+		ArtistID:         artistID,
+		EnrichmentStatus: status,
+		Sources:          sources,
+	}
+	if err := saveVerses(ctx, tx, id, detail.Text); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionCreate, nil, created); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status == models.EnrichmentPending {
+		if err := enqueueEnrichment(ctx, tx, id); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	s.log.Info("Song added successfully",
+		slog.Int("id", id),
+		slog.String("group", group),
+		slog.String("song", song))
+
+	return id, nil
+}
+
+func (s *Storage) GetSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.sqlite.GetSong"
+
+	var song models.Song
+	err := s.db.QueryRowContext(ctx, `SELECT `+songColumns+` FROM songs WHERE id = ? AND deleted_at IS NULL`, id).
+		Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrSongNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &song, nil
+}
+
+func (s *Storage) UpdateSong(ctx context.Context, id, version int, group, song *string, releaseDate *string, text *string, link *string) error {
+	const op = "storage.sqlite.UpdateSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadSong(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if before == nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if version != 0 && before.Version != version {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongVersionMismatch)
+	}
+
+	// Новое название не должно совпадать с названием другой песни не из корзины
+	if group != nil || song != nil {
+		newGroup, newSong := before.Group, before.Song
+		if group != nil {
+			newGroup = *group
+		}
+		if song != nil {
+			newSong = *song
+		}
+
+		var taken bool
+		err = tx.QueryRowContext(ctx, `
+            SELECT EXISTS(SELECT 1 FROM songs WHERE "group" = ? AND song = ? AND id <> ? AND deleted_at IS NULL)
+        `, newGroup, newSong, id).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("%s: check song existence: %w", op, err)
+		}
+		if taken {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+		}
+	}
+
+	// Смена группы переносит песню к исполнителю с новым именем
+	var artistID *int
+	if group != nil {
+		groupArtistID, err := ensureArtist(ctx, tx, *group)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		artistID = &groupArtistID
+	}
+
+	sources, err := storage.EncodeSources(storage.ManualSources(before, releaseDate, text, link))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE songs 
+        SET "group" = COALESCE(?, "group"), 
+            song = COALESCE(?, song),
+            release_date = COALESCE(?, release_date), 
+            text = COALESCE(?, text),
+            link = COALESCE(?, link),
+            artist_id = COALESCE(?, artist_id),
+            sources = ?
+        WHERE id = ?
+    `, group, song, releaseDate, text, link, artistID, sources, id)
+
+	if isSongNameTaken(err) {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	after, err := loadSong(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if after.Text != before.Text {
+		if err := saveVerses(ctx, tx, id, after.Text); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionUpdate, before, after); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteSong(ctx context.Context, id, version int) error {
+	const op = "storage.sqlite.DeleteSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var before models.Song
+	err = tx.QueryRowContext(ctx, `
+       SELECT `+songColumns+`
+       FROM songs 
+       WHERE id = ? AND deleted_at IS NULL
+   `, id).Scan(songFields(&before)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: load song: %w", op, err)
+	}
+	if version != 0 && before.Version != version {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongVersionMismatch)
+	}
+
+	// Песня переносится в корзину и удаляется окончательно только при очистке
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET deleted_at = ? WHERE id = ?`, formatTime(time.Now()), before.ID)
+	if err != nil {
+		return fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, before.ID, models.RevisionDelete, &before, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// songColumns перечисляет колонки песни в порядке, который ожидает songFields. Имена уточнены
+// таблицей, так как в запросах поиска те же имена есть у индекса FTS5.
+const songColumns = `songs.id, songs."group", songs.song, songs.release_date, songs.text, songs.link, COALESCE(songs.artist_id, 0), songs.enrichment_status, songs.sources, songs.version, songs.created_at`
+
+// songFields возвращает указатели на поля песни для rows.Scan в порядке songColumns.
+func songFields(song *models.Song) []interface{} {
+	return []interface{}{&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.ArtistID, &song.EnrichmentStatus, storage.SourcesScanner(&song.Sources), &song.Version, timeScanner{dst: &song.CreatedAt}}
+}
+
+// Карта для правильного экранирования имен полей
+var fieldNames = map[string]string{
+	"group":             `"group"`,
+	"song":              "song",
+	"release_date":      "release_date",
+	"text":              "text",
+	"link":              "link",
+	"enrichment_status": "enrichment_status",
+}
+
+// sortColumns содержит поля, по которым допускается сортировка при выборке по курсору.
+// Дата выпуска сравнивается в виде YYYY-MM-DD, как и значение в курсоре.
+var sortColumns = map[string]string{
+	"id":           "id",
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": releaseDateISO,
+}
+
+// listSortColumns содержит поля, по которым допускается сортировка при выборке по смещению.
+// Дата выпуска сравнивается в виде YYYY-MM-DD, иначе даты DD.MM.YYYY упорядочились бы по дню месяца.
+var listSortColumns = map[string]string{
+	"id":           "id",
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": releaseDateISO,
+	"created_at":   "created_at",
+}
+
+// orderClause составляет ORDER BY по ключам сортировки. Последним ключом идет id в направлении
+// предыдущего ключа: порядок однозначен, а сортировку по одному полю обслуживает индекс (поле, id)
+// в прямом или обратном порядке.
+func orderClause(sort []storage.SongSort) (string, error) {
+	terms := make([]string, 0, len(sort)+1)
+	desc := false
+	for _, key := range sort {
+		column, ok := listSortColumns[key.Key]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort key %q", key.Key)
+		}
+
+		desc = key.Desc
+		if desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+
+		// id уникален, следующие ключи на порядок не влияют
+		if key.Key == "id" {
+			return " ORDER BY " + strings.Join(terms, ", "), nil
+		}
+	}
+
+	if desc {
+		terms = append(terms, "id DESC")
+	} else {
+		terms = append(terms, "id")
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+func (s *Storage) GetFilteredSongs(ctx context.Context, filters map[string]interface{}, sort []storage.SongSort, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.sqlite.GetFilteredSongs"
+
+	order, err := orderClause(sort)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `SELECT ` + songColumns + ` FROM songs`
+	conditions, args := filterConditions(filters)
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	query += order + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	songs, err := s.querySongs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) GetFilteredSongsAfter(ctx context.Context, filters map[string]interface{}, sortKey string, after *storage.SongCursor, limit int) ([]*models.Song, error) {
+	const op = "storage.sqlite.GetFilteredSongsAfter"
+
+	sortColumn, ok := sortColumns[sortKey]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, sortKey)
+	}
+
+	query := `SELECT ` + songColumns + ` FROM songs`
+	conditions, args := filterConditions(filters)
+
+	// Продолжаем строго после последней песни предыдущей страницы
+	if after != nil {
+		if sortColumn == "id" {
+			conditions = append(conditions, "id > ?")
+			args = append(args, after.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) > (?, ?)", sortColumn))
+			args = append(args, after.Value, after.ID)
+		}
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	if sortColumn == "id" {
+		query += " ORDER BY id"
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s, id", sortColumn)
+	}
+
+	query += " LIMIT ?"
+	args = append(args, limit)
+
+	songs, err := s.querySongs(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+// SearchSongs ищет песни через индекс FTS5. Токенизатор unicode61 не зависит от языка,
+// поэтому language проверяется, но на результат не влияет.
+func (s *Storage) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]*models.SongSearchResult, error) {
+	const op = "storage.sqlite.SearchSongs"
+
+	if !storage.IsSearchLanguage(language) {
+		return nil, fmt.Errorf("%s: unsupported search language %q", op, language)
+	}
+
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	// Веса колонок соответствуют весам A/B/C в PostgreSQL: название, группа, текст
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+songColumns+`,
+               -bm25(songs_fts, 10.0, 5.0, 1.0) AS rank,
+               snippet(songs_fts, 2, '<b>', '</b>', ' … ', 20) AS snippet
+        FROM songs_fts
+        JOIN songs ON songs.id = songs_fts.rowid
+        WHERE songs_fts MATCH ? AND songs.deleted_at IS NULL
+        ORDER BY rank DESC, songs.id
+        LIMIT ? OFFSET ?
+    `, match, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var results []*models.SongSearchResult
+
+	for rows.Next() {
+		var result models.SongSearchResult
+		err := rows.Scan(append(songFields(&result.Song), &result.Rank, &result.Snippet)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// ftsQuery превращает пользовательский запрос в выражение MATCH: каждое слово берется в кавычки,
+// чтобы спецсимволы синтаксиса FTS5 не ломали запрос, а слова объединяются через AND.
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// filterConditions строит условия WHERE для фильтров по точному совпадению полей
+// и по принадлежности альбому. Песни из корзины исключаются всегда.
+func filterConditions(filters map[string]interface{}) ([]string, []interface{}) {
+	// Песни из корзины не попадают в выборки
+	conditions := []string{`songs.deleted_at IS NULL`}
+	var args []interface{}
+
+	for field, value := range filters {
+		if field == "album" {
+			conditions = append(conditions, `songs.id IN (SELECT song_id FROM album_tracks WHERE album_id = ?)`)
+			args = append(args, value)
+			continue
+		}
+
+		if filter, ok := storage.SongFilters[field]; ok {
+			condition, filterArgs := filterCondition(filter, value)
+			conditions = append(conditions, condition)
+			args = append(args, filterArgs...)
+			continue
+		}
+
+		if quotedField, ok := fieldNames[field]; ok {
+			conditions = append(conditions, fmt.Sprintf(`%s = ?`, quotedField))
+			args = append(args, value)
+		}
+	}
+
+	return conditions, args
+}
+
+// releaseDateISO приводит release_date к виду YYYY-MM-DD так же, как storage.ReleaseDateISO.
+const releaseDateISO = `CASE WHEN songs.release_date GLOB '[0-9][0-9].[0-9][0-9].[0-9][0-9][0-9][0-9]'
+    THEN substr(songs.release_date, 7, 4) || '-' || substr(songs.release_date, 4, 2) || '-' || substr(songs.release_date, 1, 2)
+    ELSE songs.release_date END`
+
+// filterCondition составляет условие фильтра из storage.SongFilters. Встроенная функция lower в SQLite
+// меняет регистр только латиницы, поэтому строки сравниваются через unicode_lower.
+func filterCondition(filter storage.SongFilter, value interface{}) (string, []interface{}) {
+	column := "songs." + fieldNames[filter.Field]
+
+	switch filter.Op {
+	case storage.FilterContains:
+		return fmt.Sprintf(`instr(unicode_lower(%s), ?) > 0`, column), []interface{}{strings.ToLower(value.(string))}
+	case storage.FilterPrefix:
+		return fmt.Sprintf(`instr(unicode_lower(%s), ?) = 1`, column), []interface{}{strings.ToLower(value.(string))}
+	case storage.FilterFrom:
+		return fmt.Sprintf(`songs.release_date <> '' AND %s >= ?`, releaseDateISO), []interface{}{value}
+	case storage.FilterTo:
+		return fmt.Sprintf(`songs.release_date <> '' AND %s <= ?`, releaseDateISO), []interface{}{value}
+	case storage.FilterIn:
+		values := value.([]string)
+		placeholders := make([]string, len(values))
+		args := make([]interface{}, len(values))
+		for i, v := range values {
+			placeholders[i] = "?"
+			args[i] = v
+		}
+		return fmt.Sprintf(`%s IN (%s)`, column, strings.Join(placeholders, ", ")), args
+	case storage.FilterPresent:
+		if value.(bool) {
+			return fmt.Sprintf(`%s <> ''`, column), nil
+		}
+		return fmt.Sprintf(`%s = ''`, column), nil
+	default:
+		return "FALSE", nil
+	}
+}
+
+func (s *Storage) querySongs(ctx context.Context, query string, args ...interface{}) ([]*models.Song, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []*models.Song
+
+	for rows.Next() {
+		var songDetail models.Song
+		err := rows.Scan(songFields(&songDetail)...)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, &songDetail)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) GetID(ctx context.Context, group, song string) (int, error) {
+	const op = "storage.sqlite.GetID"
+
+	var id int
+
+	err := s.db.QueryRowContext(ctx, `
+        SELECT id 
+        FROM songs 
+        WHERE "group" = ? AND song = ? AND deleted_at IS NULL
+    `, group, song).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		s.log.Warn("Song not found",
+			slog.String("group", group),
+			slog.String("song", song))
+		return 0, storage.ErrSongNotFound
+	}
+
+	if err != nil {
+		s.log.Error("Failed to get song ID",
+			slog.String("group", group),
+			slog.String("song", song),
+			slog.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// isSongNameTaken сообщает, что запись нарушила уникальность группы и названия песни вне корзины
+// (индекс songs_group_song_live_idx). SQLite не передает имя индекса, поэтому сверяются его колонки.
+func isSongNameTaken(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), "songs.group, songs.song")
+}
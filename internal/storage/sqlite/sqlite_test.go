@@ -0,0 +1,416 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"github.com/TakuroBreath/song-library/pkg/migrator"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStorage создает хранилище во временном файле и применяет к нему миграции из migrations/sqlite.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "songs.db")
+
+	// Миграции читаются по пути относительно корня репозитория
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err := os.Chdir(filepath.Join(wd, "..", "..", "..")); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	err = migrator.MigrateSQLite(path, log)
+	if chdirErr := os.Chdir(wd); chdirErr != nil {
+		t.Fatalf("Chdir() error = %v", chdirErr)
+	}
+	if err != nil {
+		t.Fatalf("MigrateSQLite() error = %v", err)
+	}
+
+	s, err := NewStorage(path, log)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.db.Close() })
+
+	return s
+}
+
+func TestIsSongNameTaken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	if _, err := s.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{}); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	if _, err := s.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{}); !errors.Is(err, storage.ErrSongExists) {
+		t.Errorf("AddSong() of an existing song error = %v, want %v", err, storage.ErrSongExists)
+	}
+
+	// Запись в обход проверки наталкивается на уникальный индекс
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO songs ("group", song, release_date, text, link, artist_id, sources, created_at)
+        SELECT "group", song, release_date, text, link, artist_id, sources, created_at FROM songs WHERE id = 1
+    `)
+	if !isSongNameTaken(err) {
+		t.Errorf("isSongNameTaken(%v) = false, want true", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO artists (name) SELECT name FROM artists LIMIT 1`)
+	if err == nil || isSongNameTaken(err) {
+		t.Errorf("isSongNameTaken(%v) = true, want false for another unique constraint", err)
+	}
+}
+
+// addSongs добавляет песни в указанном порядке и возвращает их id.
+func addSongs(t *testing.T, s *Storage, songs []models.Song) []int {
+	t.Helper()
+
+	ids := make([]int, 0, len(songs))
+	for _, song := range songs {
+		id, err := s.AddSong(context.Background(), song.Group, song.Song, models.SongDetail{
+			ReleaseDate: song.ReleaseDate,
+			Text:        song.Text,
+			Link:        song.Link,
+		})
+		if err != nil {
+			t.Fatalf("AddSong(%s - %s) error = %v", song.Group, song.Song, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestMigrations(t *testing.T) {
+	s := newTestStorage(t)
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "sqlite", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Glob() = %v, %v, want migration files", files, err)
+	}
+	latest := 0
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.SplitN(filepath.Base(file), "_", 2)[0])
+		if err != nil {
+			t.Fatalf("migration %s has no version: %v", file, err)
+		}
+		latest = max(latest, version)
+	}
+
+	var version int
+	var dirty bool
+	if err := s.db.QueryRow(`SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty); err != nil {
+		t.Fatalf("read schema_migrations: %v", err)
+	}
+	if version != latest || dirty {
+		t.Errorf("schema version = %d (dirty %v), want %d", version, dirty, latest)
+	}
+
+	for _, name := range []string{"songs", "songs_fts", "song_revisions", "artists", "albums", "songs_release_date_iso_id_idx"} {
+		var found bool
+		err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = ?)`, name).Scan(&found)
+		if err != nil {
+			t.Fatalf("read sqlite_master: %v", err)
+		}
+		if !found {
+			t.Errorf("schema object %s is missing", name)
+		}
+	}
+}
+
+func TestGetFilteredSongsAfter(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	ids := addSongs(t, s, []models.Song{
+		{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003"},
+		{Group: "Blur", Song: "Song 2", ReleaseDate: "07.04.1997"},
+		{Group: "Muse", Song: "Starlight", ReleaseDate: "04.09.2006"},
+		{Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07"},
+		{Group: "Blur", Song: "Parklife", ReleaseDate: "01.12.2003"},
+		{Group: "Muse", Song: "Madness"},
+	})
+	if err := s.DeleteSong(ctx, ids[3], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		sortKey string
+		want    []int
+	}{
+		{name: "id", sortKey: "id", want: []int{ids[0], ids[1], ids[2], ids[4], ids[5]}},
+		{name: "group", sortKey: "group", want: []int{ids[1], ids[4], ids[0], ids[2], ids[5]}},
+		{name: "song", sortKey: "song", want: []int{ids[0], ids[5], ids[4], ids[1], ids[2]}},
+		{
+			name:    "release date compares as YYYY-MM-DD",
+			sortKey: "release_date",
+			want:    []int{ids[5], ids[1], ids[0], ids[4], ids[2]},
+		},
+		{
+			name:    "filtered",
+			filters: map[string]interface{}{"group": "Muse"},
+			sortKey: "release_date",
+			want:    []int{ids[5], ids[0], ids[2]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			var after *storage.SongCursor
+
+			for page := 0; page < 10; page++ {
+				songs, err := s.GetFilteredSongsAfter(ctx, tt.filters, tt.sortKey, after, 2)
+				if err != nil {
+					t.Fatalf("GetFilteredSongsAfter() error = %v", err)
+				}
+				if len(songs) == 0 {
+					break
+				}
+				for _, song := range songs {
+					got = append(got, song.ID)
+				}
+
+				last := songs[len(songs)-1]
+				value, _ := storage.SongSortValue(last, tt.sortKey)
+				after = &storage.SongCursor{SortKey: tt.sortKey, Value: value, ID: last.ID}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.GetFilteredSongsAfter(ctx, nil, "text", nil, 2); err == nil {
+		t.Error("GetFilteredSongsAfter() by text error = nil, want an error")
+	}
+}
+
+func TestSearchSongs(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	ids := addSongs(t, s, []models.Song{
+		{Group: "Muse", Song: "Starlight", Text: "Far away ship"},
+		{Group: "Starlight Orchestra", Song: "Overture", Text: "Instrumental version of the opening theme"},
+		{Group: "Blur", Song: "Song 2", Text: "Woo-hoo! When I feel heavy metal, starlight shines"},
+		{Group: "Кино", Song: "Звезда по имени Солнце", Text: "Белый снег, серый лед"},
+		{Group: "Muse", Song: "Hysteria", Text: "It's bugging me, starlight"},
+	})
+	if err := s.DeleteSong(ctx, ids[4], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "title before group before text", query: "starlight", want: []int{ids[0], ids[1], ids[2]}},
+		{name: "all words must match", query: "far ship", want: []int{ids[0]}},
+		{name: "case insensitive cyrillic", query: "СНЕГ", want: []int{ids[3]}},
+		{name: "fts syntax is quoted", query: `woo-hoo! "heavy`, want: []int{ids[2]}},
+		{name: "no match", query: "resistance", want: nil},
+		{name: "blank query", query: "   ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.SearchSongs(ctx, tt.query, "simple", 10, 0)
+			if err != nil {
+				t.Fatalf("SearchSongs() error = %v", err)
+			}
+
+			var got []int
+			for _, result := range results {
+				got = append(got, result.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchSongs(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	results, err := s.SearchSongs(ctx, "far", "simple", 10, 0)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("SearchSongs() = %v, %v, want one result", results, err)
+	}
+	if !strings.Contains(results[0].Snippet, "<b>Far</b>") {
+		t.Errorf("Snippet = %q, want the match highlighted", results[0].Snippet)
+	}
+
+	if _, err := s.SearchSongs(ctx, "far", "klingon", 10, 0); err == nil {
+		t.Error("SearchSongs() with an unknown language error = nil, want an error")
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	ids := addSongs(t, s, []models.Song{
+		{Group: "Muse", Song: "Hysteria", Text: "It's bugging me"},
+		{Group: "Muse", Song: "Starlight"},
+	})
+
+	if err := s.DeleteSong(ctx, ids[0], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+	if _, err := s.GetSong(ctx, ids[0]); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetSong() of a trashed song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+	if err := s.DeleteSong(ctx, ids[0], 0); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("DeleteSong() of a trashed song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+
+	trashed, err := s.GetTrashedSongs(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTrashedSongs() error = %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != ids[0] || trashed[0].DeletedAt == nil {
+		t.Fatalf("GetTrashedSongs() = %+v, want song %d with deleted_at", trashed, ids[0])
+	}
+
+	// Пока песня в корзине, ее название свободно
+	replacement, err := s.AddSong(ctx, "Muse", "Hysteria", models.SongDetail{})
+	if err != nil {
+		t.Fatalf("AddSong() of a trashed name error = %v", err)
+	}
+	if _, err := s.RestoreSong(ctx, ids[0]); !errors.Is(err, storage.ErrSongExists) {
+		t.Errorf("RestoreSong() over a live song error = %v, want %v", err, storage.ErrSongExists)
+	}
+	if err := s.DeleteSong(ctx, replacement, 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+
+	restored, err := s.RestoreSong(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("RestoreSong() error = %v", err)
+	}
+	if restored.Text != "It's bugging me" {
+		t.Errorf("RestoreSong() text = %q, want %q", restored.Text, "It's bugging me")
+	}
+	if _, err := s.GetSong(ctx, ids[0]); err != nil {
+		t.Errorf("GetSong() after restore error = %v", err)
+	}
+	if _, err := s.RestoreSong(ctx, ids[1]); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("RestoreSong() of a live song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+
+	// Очистка затрагивает только песни, удаленные раньше границы
+	if err := s.DeleteSong(ctx, ids[1], 0); err != nil {
+		t.Fatalf("DeleteSong() error = %v", err)
+	}
+	purged, err := s.PurgeSongs(ctx, time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Errorf("PurgeSongs(an hour ago) = %d, %v, want 0", purged, err)
+	}
+	purged, err = s.PurgeSongs(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged != 2 {
+		t.Errorf("PurgeSongs(in an hour) = %d, %v, want 2", purged, err)
+	}
+
+	trashed, err = s.GetTrashedSongs(ctx, 10, 0)
+	if err != nil || len(trashed) != 0 {
+		t.Errorf("GetTrashedSongs() after purge = %v, %v, want none", trashed, err)
+	}
+	if _, err := s.GetSong(ctx, ids[0]); err != nil {
+		t.Errorf("GetSong() of a restored song after purge error = %v", err)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	id := addSongs(t, s, []models.Song{{Group: "Muse", Song: "Hysteria", Text: "first"}})[0]
+
+	text := "second"
+	if err := s.UpdateSong(ctx, id, 0, nil, nil, nil, &text, nil); err != nil {
+		t.Fatalf("UpdateSong() error = %v", err)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if err := s.UpdateSong(ctx, id, song.Version-1, nil, nil, nil, &text, nil); !errors.Is(err, storage.ErrSongVersionMismatch) {
+		t.Errorf("UpdateSong() with a stale version error = %v, want %v", err, storage.ErrSongVersionMismatch)
+	}
+
+	title := "Hysteria (Live)"
+	if err := s.UpdateSong(ctx, id, song.Version, nil, &title, nil, nil, nil); err != nil {
+		t.Fatalf("UpdateSong() with the current version error = %v", err)
+	}
+
+	revisions, err := s.GetSongRevisions(ctx, id, 10, 0)
+	if err != nil {
+		t.Fatalf("GetSongRevisions() error = %v", err)
+	}
+	var actions []string
+	for _, r := range revisions {
+		actions = append(actions, strconv.Itoa(r.Revision)+":"+r.Action)
+	}
+	if want := []string{"3:update", "2:update", "1:create"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("GetSongRevisions() = %v, want %v", actions, want)
+	}
+	if revisions[1].Before.Text != "first" || revisions[1].After.Text != "second" {
+		t.Errorf("revision 2 = %q -> %q, want first -> second", revisions[1].Before.Text, revisions[1].After.Text)
+	}
+	if revisions[2].Before != nil {
+		t.Errorf("revision 1 before = %+v, want nil", revisions[2].Before)
+	}
+
+	page, err := s.GetSongRevisions(ctx, id, 1, 1)
+	if err != nil || len(page) != 1 || page[0].Revision != 2 {
+		t.Errorf("GetSongRevisions(limit 1, offset 1) = %v, %v, want revision 2", page, err)
+	}
+
+	restored, err := s.RestoreSongRevision(ctx, id, 1)
+	if err != nil {
+		t.Fatalf("RestoreSongRevision() error = %v", err)
+	}
+	if restored.Song != "Hysteria" || restored.Text != "first" {
+		t.Errorf("RestoreSongRevision() = %s / %q, want Hysteria / first", restored.Song, restored.Text)
+	}
+	after, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong() error = %v", err)
+	}
+	if after.Version <= song.Version+1 {
+		t.Errorf("version after restore = %d, want more than %d", after.Version, song.Version+1)
+	}
+
+	latest, err := s.GetSongRevision(ctx, id, 4)
+	if err != nil {
+		t.Fatalf("GetSongRevision(4) error = %v", err)
+	}
+	if latest.Before.Song != title || latest.After.Text != "first" {
+		t.Errorf("revision 4 = %+v -> %+v, want the restore from %q", latest.Before, latest.After, title)
+	}
+
+	if _, err := s.GetSongRevision(ctx, id, 10); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("GetSongRevision(10) error = %v, want %v", err, storage.ErrRevisionNotFound)
+	}
+	if _, err := s.RestoreSongRevision(ctx, id, 10); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("RestoreSongRevision(10) error = %v, want %v", err, storage.ErrRevisionNotFound)
+	}
+	if _, err := s.GetSongRevisions(ctx, id+100, 10, 0); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetSongRevisions() of an unknown song error = %v, want %v", err, storage.ErrSongNotFound)
+	}
+}
@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+	"time"
+)
+
+func (s *Storage) GetTrashedSongs(ctx context.Context, limit, offset int) ([]*models.Song, error) {
+	const op = "storage.sqlite.GetTrashedSongs"
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT `+songColumns+`, songs.deleted_at
+        FROM songs
+        WHERE deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC, id
+        LIMIT ? OFFSET ?
+    `, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var songs []*models.Song
+
+	for rows.Next() {
+		var song models.Song
+		var deletedAt string
+		if err := rows.Scan(append(songFields(&song), &deletedAt)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		deleted, err := parseTime(deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		song.DeletedAt = &deleted
+
+		songs = append(songs, &song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+func (s *Storage) RestoreSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "storage.sqlite.RestoreSong"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var song models.Song
+	err = tx.QueryRowContext(ctx, `
+        SELECT `+songColumns+`
+        FROM songs
+        WHERE id = ? AND deleted_at IS NOT NULL
+    `, id).Scan(songFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: lock song: %w", op, err)
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM songs WHERE "group" = ? AND song = ? AND deleted_at IS NULL)
+    `, song.Group, song.Song).Scan(&taken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: check song existence: %w", op, err)
+	}
+	if taken {
+		return nil, storage.ErrSongExists
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET deleted_at = NULL WHERE id = ?`, id)
+	if isSongNameTaken(err) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, id, models.RevisionRestore, nil, &song); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &song, nil
+}
+
+func (s *Storage) PurgeSongs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	const op = "storage.sqlite.PurgeSongs"
+
+	result, err := s.db.ExecContext(ctx, `
+        DELETE FROM songs
+        WHERE deleted_at IS NOT NULL AND deleted_at < ?
+    `, formatTime(deletedBefore))
+	if err != nil {
+		return 0, fmt.Errorf("%s: execute delete: %w", op, err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+
+	return int(purged), nil
+}
+
+// timeLayout — формат отметок времени в SQLite. Ширина всех полей фиксирована,
+// поэтому строки можно сравнивать в запросах как даты.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time: %w", err)
+	}
+	return t, nil
+}
+
+// timeScanner читает в dst отметку времени, записанную formatTime.
+type timeScanner struct {
+	dst *time.Time
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s.dst = time.Time{}
+		return nil
+	case string:
+		t, err := parseTime(v)
+		*s.dst = t
+		return err
+	case []byte:
+		t, err := parseTime(string(v))
+		*s.dst = t
+		return err
+	default:
+		return fmt.Errorf("scan time: unsupported type %T", src)
+	}
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"github.com/TakuroBreath/song-library/internal/storage"
+)
+
+func (s *Storage) GetSongVerses(ctx context.Context, id int, limit, offset int) ([]*models.Verse, int, error) {
+	const op = "storage.sqlite.GetSongVerses"
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `
+        SELECT (SELECT COUNT(*) FROM verses WHERE song_id = songs.id)
+        FROM songs
+        WHERE id = ? AND deleted_at IS NULL
+    `, id).Scan(&total)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT position, kind, text, start_offset, end_offset
+        FROM verses
+        WHERE song_id = ?
+        ORDER BY position
+        LIMIT ? OFFSET ?
+    `, id, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	verses := []*models.Verse{}
+
+	for rows.Next() {
+		var verse models.Verse
+		if err := rows.Scan(&verse.Position, &verse.Kind, &verse.Text, &verse.Start, &verse.End); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		verses = append(verses, &verse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return verses, total, nil
+}
+
+func (s *Storage) ReparseVerses(ctx context.Context, limit int) (int, error) {
+	const op = "storage.sqlite.ReparseVerses"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+        SELECT id, text FROM songs WHERE verses_version < ? ORDER BY id LIMIT ?
+    `, storage.VerseParserVersion, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	texts := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		texts[id] = text
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for id, text := range texts {
+		if err := saveVerses(ctx, tx, id, text); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return len(texts), nil
+}
+
+func (s *Storage) ResetVerses(ctx context.Context) error {
+	const op = "storage.sqlite.ResetVerses"
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE songs SET verses_version = 0`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// saveVerses заменяет части текста песни результатом разбора text и отмечает версию разборщика.
+func saveVerses(ctx context.Context, tx *sql.Tx, songID int, text string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM verses WHERE song_id = ?`, songID); err != nil {
+		return fmt.Errorf("delete verses: %w", err)
+	}
+
+	for _, verse := range storage.ParseVerses(text) {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO verses (song_id, position, kind, text, start_offset, end_offset)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `, songID, verse.Position, verse.Kind, verse.Text, verse.Start, verse.End)
+		if err != nil {
+			return fmt.Errorf("save verse: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE songs SET verses_version = ? WHERE id = ?`, storage.VerseParserVersion, songID); err != nil {
+		return fmt.Errorf("save verses version: %w", err)
+	}
+
+	return nil
+}
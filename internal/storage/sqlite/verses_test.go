@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"reflect"
+	"testing"
+)
+
+func TestReparseVerses(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	for _, name := range []string{"Hysteria", "Starlight", "Uprising"} {
+		if _, err := s.AddSong(ctx, "Muse", name, models.SongDetail{Text: "[Chorus]\nla la\n\nverse"}); err != nil {
+			t.Fatalf("AddSong() error = %v", err)
+		}
+	}
+
+	// Так выглядят песни, сохраненные до разбора текстов на части
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM verses`); err != nil {
+		t.Fatalf("delete verses: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE songs SET verses_version = 0`); err != nil {
+		t.Fatalf("reset verses version: %v", err)
+	}
+	if verses, total, err := s.GetSongVerses(ctx, 1, 10, 0); err != nil || total != 0 || len(verses) != 0 {
+		t.Fatalf("GetSongVerses() before reparse = %+v (%d), %v, want none", verses, total, err)
+	}
+
+	for _, want := range []int{2, 1, 0} {
+		if n, err := s.ReparseVerses(ctx, 2); err != nil || n != want {
+			t.Fatalf("ReparseVerses() = %d, %v, want %d", n, err, want)
+		}
+	}
+
+	verses, total, err := s.GetSongVerses(ctx, 3, 10, 0)
+	if err != nil {
+		t.Fatalf("GetSongVerses() error = %v", err)
+	}
+	want := []*models.Verse{
+		{Position: 1, Kind: models.VerseKindChorus, Text: "la la", Start: 9, End: 14},
+		{Position: 2, Kind: models.VerseKindVerse, Text: "verse", Start: 16, End: 21},
+	}
+	if total != len(want) || !reflect.DeepEqual(verses, want) {
+		t.Errorf("GetSongVerses() = %+v (%d), want %+v", verses, total, want)
+	}
+
+	// После сброса разбираются все песни, и до разбора части не теряются
+	if err := s.ResetVerses(ctx); err != nil {
+		t.Fatalf("ResetVerses() error = %v", err)
+	}
+	if verses, _, err := s.GetSongVerses(ctx, 3, 10, 0); err != nil || !reflect.DeepEqual(verses, want) {
+		t.Errorf("GetSongVerses() after reset = %+v, %v, want %+v", verses, err, want)
+	}
+	if n, err := s.ReparseVerses(ctx, 10); err != nil || n != 3 {
+		t.Errorf("ReparseVerses() after reset = %d, %v, want 3", n, err)
+	}
+}
@@ -1,8 +1,258 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"strconv"
+	"time"
+)
+
+// Базовые виды ошибок хранилища. Конкретные ошибки оборачивают их,
+// поэтому вызывающий код может проверять вид ошибки через errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
 
 var (
-	ErrSongExists   = errors.New("song already exists")
-	ErrSongNotFound = errors.New("song not found")
+	ErrSongExists   = fmt.Errorf("song %w", ErrAlreadyExists)
+	ErrSongNotFound = fmt.Errorf("song %w", ErrNotFound)
+
+	ErrRevisionNotFound = fmt.Errorf("revision %w", ErrNotFound)
+
+	ErrRefreshProposalNotFound = fmt.Errorf("refresh proposal %w", ErrNotFound)
+
+	ErrArtistExists   = fmt.Errorf("artist %w", ErrAlreadyExists)
+	ErrArtistNotFound = fmt.Errorf("artist %w", ErrNotFound)
+
+	ErrAlbumExists   = fmt.Errorf("album %w", ErrAlreadyExists)
+	ErrAlbumNotFound = fmt.Errorf("album %w", ErrNotFound)
+
+	// ErrArtistHasSongs означает, что исполнителя нельзя удалить, пока у него есть песни.
+	ErrArtistHasSongs = errors.New("artist has songs")
+	// ErrArtistHasAlbums означает, что исполнителя нельзя удалить, пока у него есть альбомы.
+	ErrArtistHasAlbums = errors.New("artist has albums")
+
+	// ErrSongVersionMismatch означает, что песня изменилась после того, как клиент получил ее версию.
+	ErrSongVersionMismatch = errors.New("song version mismatch")
 )
+
+// SongSortKeys перечисляет поля, по которым можно упорядочить список песен при постраничной выборке по курсору.
+var SongSortKeys = []string{"id", "group", "song", "release_date"}
+
+// SongListSortKeys перечисляет поля, по которым можно упорядочить список песен при постраничной выборке по смещению.
+var SongListSortKeys = []string{"id", "group", "song", "release_date", "created_at"}
+
+// SongSort — ключ сортировки списка песен: поле из SongListSortKeys и направление.
+type SongSort struct {
+	Key  string
+	Desc bool
+}
+
+// SongCursor указывает позицию в списке песен, упорядоченном по SortKey и id (keyset pagination).
+// Value хранит значение SongSortValue у последней песни предыдущей страницы.
+type SongCursor struct {
+	SortKey string
+	Value   string
+	ID      int
+}
+
+// IsSongSortKey сообщает, можно ли упорядочить список песен по указанному полю.
+func IsSongSortKey(key string) bool {
+	for _, k := range SongSortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSongListSortKey сообщает, можно ли упорядочить список песен по указанному полю при выборке по смещению.
+func IsSongListSortKey(key string) bool {
+	for _, k := range SongListSortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SongFieldValue возвращает значение поля песни по его имени в API.
+func SongFieldValue(song *models.Song, field string) (string, bool) {
+	switch field {
+	case "id":
+		return strconv.Itoa(song.ID), true
+	case "group":
+		return song.Group, true
+	case "song":
+		return song.Song, true
+	case "release_date":
+		return song.ReleaseDate, true
+	case "text":
+		return song.Text, true
+	case "link":
+		return song.Link, true
+	case "enrichment_status":
+		return song.EnrichmentStatus, true
+	default:
+		return "", false
+	}
+}
+
+// SongSortValue возвращает значение поля песни, по которому упорядочивается список: дата выпуска
+// приводится к виду YYYY-MM-DD, остальные поля совпадают с SongFieldValue.
+func SongSortValue(song *models.Song, field string) (string, bool) {
+	if field == "release_date" {
+		return ReleaseDateISO(song.ReleaseDate), true
+	}
+	return SongFieldValue(song, field)
+}
+
+// SearchLanguages перечисляет поддерживаемые конфигурации полнотекстового поиска.
+// Первая конфигурация используется по умолчанию.
+var SearchLanguages = []string{"simple", "english", "russian"}
+
+// IsSearchLanguage сообщает, поддерживается ли конфигурация полнотекстового поиска.
+func IsSearchLanguage(language string) bool {
+	for _, l := range SearchLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// SongRepository описывает хранилище песен, с которым работает сервисный слой.
+// Создание, изменение и удаление песни записываются в историю изменений в той же транзакции.
+// Удаление мягкое: песня попадает в корзину и скрывается из всех выборок, кроме корзины.
+type SongRepository interface {
+	AddSong(ctx context.Context, group, song string, detail models.SongDetail) (int, error)
+	// AddSongForEnrichment сохраняет песню без сведений в состоянии pending_enrichment
+	// и в той же транзакции создает для нее задачу в очереди обогащения.
+	AddSongForEnrichment(ctx context.Context, group, song string) (int, error)
+	// GetSong возвращает песню по id; песни в корзине не возвращаются.
+	GetSong(ctx context.Context, id int) (*models.Song, error)
+	// UpdateSong меняет переданные поля песни: nil оставляет поле как есть, пустая строка очищает его.
+	// Пустое значение означает отсутствие сведений, как у песни, которая их не получала, поэтому
+	// колонки даты выпуска, текста и ссылки не допускают NULL. Измененные дата выпуска, текст и ссылка
+	// получают источник manual (см. ManualSources). Если песни нет, возвращается ErrSongNotFound,
+	// если новые группа и название заняты другой песней не из корзины — ErrSongExists.
+	// Ненулевая version должна совпадать с текущей версией песни, иначе возвращается
+	// ErrSongVersionMismatch; версия песни — номер ее последней правки.
+	UpdateSong(ctx context.Context, id, version int, group, song, releaseDate, text, link *string) error
+	// DeleteSong переносит песню в корзину. Если песни нет, возвращается ErrSongNotFound.
+	// Ненулевая version проверяется так же, как в UpdateSong.
+	DeleteSong(ctx context.Context, id, version int) error
+	// GetSongVerses возвращает части текста песни по порядку и общее число частей.
+	// Текст разбирается на части (см. ParseVerses) при каждой записи песни, которая его меняет.
+	GetSongVerses(ctx context.Context, id int, limit, offset int) ([]*models.Verse, int, error)
+	// ReparseVerses заново разбирает на части тексты до limit песен, разобранных старой версией
+	// разборщика (см. VerseParserVersion), включая песни в корзине, и возвращает их количество.
+	ReparseVerses(ctx context.Context, limit int) (int, error)
+	// ResetVerses помечает тексты всех песен для повторного разбора через ReparseVerses.
+	ResetVerses(ctx context.Context) error
+	// GetFilteredSongs возвращает страницу песен, упорядоченных по ключам sort, а при равенстве — по id.
+	// Пустой sort означает порядок по id; ключ не из SongListSortKeys является ошибкой.
+	GetFilteredSongs(ctx context.Context, filters map[string]interface{}, sort []SongSort, limit, offset int) ([]*models.Song, error)
+	GetFilteredSongsAfter(ctx context.Context, filters map[string]interface{}, sortKey string, after *SongCursor, limit int) ([]*models.Song, error)
+	// ExportSongs передает в fn по одной все песни, подходящие под фильтры GetFilteredSongs, в порядке id,
+	// не загружая выборку в память целиком. Ошибка fn прерывает выгрузку.
+	ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error
+	GetID(ctx context.Context, group, song string) (int, error)
+	SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]*models.SongSearchResult, error)
+	GetSongRevisions(ctx context.Context, songID int, limit, offset int) ([]*models.SongRevision, error)
+	GetSongRevision(ctx context.Context, songID, revision int) (*models.SongRevision, error)
+	// RestoreSongRevision возвращает песню к состоянию после указанной правки
+	// (для удаления — к состоянию до него) и записывает это как новую правку.
+	RestoreSongRevision(ctx context.Context, songID, revision int) (*models.Song, error)
+	GetTrashedSongs(ctx context.Context, limit, offset int) ([]*models.Song, error)
+	// RestoreSong возвращает песню из корзины. Если песня с такими же названием и группой
+	// уже добавлена заново, возвращается ErrSongExists.
+	RestoreSong(ctx context.Context, id int) (*models.Song, error)
+	// PurgeSongs окончательно удаляет песни, попавшие в корзину раньше deletedBefore,
+	// и возвращает их количество.
+	PurgeSongs(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
+// ArtistRepository описывает хранилище исполнителей. Название группы у песен
+// совпадает с именем исполнителя, поэтому переименование исполнителя меняет и его песни.
+type ArtistRepository interface {
+	AddArtist(ctx context.Context, artist *models.Artist) (int, error)
+	GetArtist(ctx context.Context, id int) (*models.Artist, error)
+	GetArtists(ctx context.Context, limit, offset int) ([]*models.Artist, error)
+	UpdateArtist(ctx context.Context, id int, name, country *string, formedYear *int, description *string, links *[]string) error
+	DeleteArtist(ctx context.Context, id int) error
+	GetArtistSongs(ctx context.Context, id int, limit, offset int) ([]*models.Song, error)
+}
+
+// AlbumRepository описывает хранилище альбомов и их трек-листов. Трек-лист задается
+// упорядоченным списком id песен; позиция песни равна ее индексу в списке плюс один.
+type AlbumRepository interface {
+	AddAlbum(ctx context.Context, album *models.Album, songIDs []int) (int, error)
+	GetAlbum(ctx context.Context, id int) (*models.Album, error)
+	GetAlbums(ctx context.Context, artistID int, limit, offset int) ([]*models.Album, error)
+	UpdateAlbum(ctx context.Context, id int, title *string, artistID *int, releaseDate, coverLink *string) error
+	SetAlbumTracks(ctx context.Context, id int, songIDs []int) error
+	DeleteAlbum(ctx context.Context, id int) error
+	GetAlbumSongs(ctx context.Context, id int) ([]*models.AlbumSong, error)
+}
+
+// EnrichmentRepository описывает очередь обогащения песен сведениями из внешнего API (transactional outbox).
+// Задача удаляется, когда песня обогащена или попытки окончательно исчерпаны.
+type EnrichmentRepository interface {
+	// ClaimEnrichmentTasks выбирает до limit задач, чей срок наступил, увеличивает их счетчик попыток
+	// и откладывает их на lease, чтобы задачу не взял другой обработчик, пока идет запрос к API.
+	ClaimEnrichmentTasks(ctx context.Context, limit int, lease time.Duration) ([]*models.EnrichmentTask, error)
+	// CompleteEnrichment заполняет пустые поля песни полученными сведениями (см. ApplyDetail), переводит ее
+	// в состояние enriched, записывает правку и удаляет задачу. Поля, уже заполненные вручную, не меняются.
+	CompleteEnrichment(ctx context.Context, taskID int, detail models.SongDetail) error
+	// RetryEnrichment откладывает задачу до nextAttemptAt и запоминает последнюю ошибку.
+	RetryEnrichment(ctx context.Context, taskID int, nextAttemptAt time.Time, lastError string) error
+	// FailEnrichment переводит песню в состояние enrichment_failed и удаляет задачу.
+	FailEnrichment(ctx context.Context, taskID int) error
+}
+
+// RefreshRepository описывает повторную сверку сведений песен с источниками. Песня ждет сверки,
+// если она помечена устаревшей или сверялась давно; изменения, которые нельзя применить сразу,
+// ждут решения редактора в виде предложений. Отклоненное предложение не создается повторно,
+// пока источник не предложит другое значение.
+type RefreshRepository interface {
+	// MarkSongStale помечает песню устаревшей, чтобы фоновое обновление взяло ее при ближайшем проходе.
+	MarkSongStale(ctx context.Context, id int) error
+	// ClaimRefreshCandidates выбирает до limit песен со сведениями, помеченных устаревшими или не сверявшихся
+	// дольше maxAge, и откладывает их на lease, чтобы песню не взял другой обработчик, пока идет запрос.
+	ClaimRefreshCandidates(ctx context.Context, maxAge, lease time.Duration, limit int) ([]*models.Song, error)
+	// CompleteRefresh применяет изменения applied (см. MergeRefresh), заменяет ожидающие предложения песни
+	// на proposed, записывает правку, если поля изменились, и отмечает время сверки.
+	CompleteRefresh(ctx context.Context, songID int, applied, proposed []models.FieldChange) (*models.RefreshResult, error)
+	// GetRefreshProposals возвращает ожидающие предложения, начиная с самых старых.
+	GetRefreshProposals(ctx context.Context, limit, offset int) ([]*models.RefreshProposal, error)
+	// AcceptRefreshProposal применяет предложение к песне, записывает правку и удаляет предложение.
+	AcceptRefreshProposal(ctx context.Context, id int) (*models.Song, error)
+	// RejectRefreshProposal отклоняет предложение.
+	RejectRefreshProposal(ctx context.Context, id int) error
+}
+
+// InfoCacheRepository хранит общий для экземпляров сервиса кэш ответов источников сведений о песнях.
+type InfoCacheRepository interface {
+	// GetInfoCache возвращает запись по ключу или nil, если ее нет или срок ее хранения истек.
+	GetInfoCache(ctx context.Context, key string) (*models.InfoCacheEntry, error)
+	// SaveInfoCache сохраняет или заменяет запись и удаляет записи с истекшим сроком.
+	SaveInfoCache(ctx context.Context, key string, entry models.InfoCacheEntry) error
+	// DeleteInfoCache удаляет запись и сообщает, была ли она.
+	DeleteInfoCache(ctx context.Context, key string) (bool, error)
+	// ClearInfoCache удаляет все записи и возвращает их количество.
+	ClearInfoCache(ctx context.Context) (int, error)
+}
+
+// Repository объединяет все хранилища приложения; его реализует каждый бэкенд.
+type Repository interface {
+	SongRepository
+	ArtistRepository
+	AlbumRepository
+	EnrichmentRepository
+	RefreshRepository
+	InfoCacheRepository
+}
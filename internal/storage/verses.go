@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// VerseParserVersion — версия разбора текста на части. Песни, разобранные более старой версией,
+// разбираются заново; значение увеличивается при каждом изменении ParseVerses.
+const VerseParserVersion = 1
+
+// sectionHeader распознает заголовки частей: "[Chorus]", "(Verse 2)", "Bridge:", "[Припев]".
+var sectionHeader = regexp.MustCompile(`(?i)^(?:[\[(]\s*([\p{L}-]+)[^\])]*[\])]\s*:?|([\p{L}-]+)(?:\s*\d+)?\s*:)$`)
+
+// sectionKinds сопоставляет названия частей в заголовках с видами частей.
+var sectionKinds = map[string]string{
+	"verse":      models.VerseKindVerse,
+	"куплет":     models.VerseKindVerse,
+	"chorus":     models.VerseKindChorus,
+	"refrain":    models.VerseKindChorus,
+	"hook":       models.VerseKindChorus,
+	"припев":     models.VerseKindChorus,
+	"bridge":     models.VerseKindBridge,
+	"pre-chorus": models.VerseKindBridge,
+	"бридж":      models.VerseKindBridge,
+	"intro":      models.VerseKindIntro,
+	"вступление": models.VerseKindIntro,
+	"outro":      models.VerseKindOutro,
+	"концовка":   models.VerseKindOutro,
+}
+
+// verseLine — строка текста без пробелов по краям и ее границы в исходном тексте в символах.
+type verseLine struct {
+	text       string
+	start, end int
+}
+
+// ParseVerses разбивает текст песни на части. Части разделяются пустыми строками; если пустых строк
+// нет, частью считается каждая строка. Переводами строк считаются "\n", "\r\n" и экранированное "\\n".
+// Заголовки вроде "[Chorus]" задают вид следующей части и в ее текст не входят; часть без заголовка,
+// которая повторяется в песне, считается припевом, остальные — куплетами.
+func ParseVerses(text string) []models.Verse {
+	var verses []models.Verse
+	var current *models.Verse
+	pending := ""
+	headers := false
+
+	flush := func() {
+		if current != nil {
+			verses = append(verses, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range splitLines(text) {
+		if line.text == "" {
+			flush()
+			continue
+		}
+
+		if kind, ok := headerKind(line.text); ok {
+			flush()
+			pending = kind
+			headers = true
+			continue
+		}
+
+		if current == nil {
+			current = &models.Verse{Kind: pending, Text: line.text, Start: line.start, End: line.end}
+			pending = ""
+			continue
+		}
+		current.Text += "\n" + line.text
+		current.End = line.end
+	}
+	flush()
+
+	// Текст без пустых строк и заголовков разбивается построчно
+	if len(verses) == 1 && !headers && strings.Contains(verses[0].Text, "\n") {
+		verses = verses[:0]
+		for _, line := range splitLines(text) {
+			if line.text != "" {
+				verses = append(verses, models.Verse{Text: line.text, Start: line.start, End: line.end})
+			}
+		}
+	}
+
+	repeats := make(map[string]int, len(verses))
+	for _, verse := range verses {
+		repeats[verse.Text]++
+	}
+
+	for i := range verses {
+		verses[i].Position = i + 1
+		if verses[i].Kind == "" {
+			verses[i].Kind = models.VerseKindVerse
+			if repeats[verses[i].Text] > 1 {
+				verses[i].Kind = models.VerseKindChorus
+			}
+		}
+	}
+
+	return verses
+}
+
+// splitLines делит текст на строки и запоминает их границы в символах исходного текста.
+func splitLines(text string) []verseLine {
+	runes := []rune(text)
+	var lines []verseLine
+
+	start := 0
+	for i := 0; i < len(runes); {
+		width := 0
+		switch {
+		case runes[i] == '\n':
+			width = 1
+		case runes[i] == '\r' && i+1 < len(runes) && runes[i+1] == '\n':
+			width = 2
+		case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == 'n':
+			width = 2
+		}
+
+		if width == 0 {
+			i++
+			continue
+		}
+
+		lines = append(lines, trimLine(runes, start, i))
+		i += width
+		start = i
+	}
+
+	return append(lines, trimLine(runes, start, len(runes)))
+}
+
+func trimLine(runes []rune, start, end int) verseLine {
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	for end > start && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+	return verseLine{text: string(runes[start:end]), start: start, end: end}
+}
+
+// headerKind сообщает, является ли строка заголовком части, и возвращает вид части.
+func headerKind(line string) (string, bool) {
+	match := sectionHeader.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+
+	name := match[1]
+	if name == "" {
+		name = match[2]
+	}
+
+	kind, ok := sectionKinds[strings.ToLower(name)]
+	return kind, ok
+}
+
+// FlattenVerse приводит часть песни к одной строке, заменяя переводы строк пробелами.
+func FlattenVerse(verse models.Verse) string {
+	return strings.ReplaceAll(verse.Text, "\n", " ")
+}
+
+// StructureVerse возвращает часть песни со строками, как они записаны в тексте.
+func StructureVerse(verse models.Verse) models.StructuredVerse {
+	return models.StructuredVerse{
+		Index: verse.Position - 1,
+		Kind:  verse.Kind,
+		Lines: strings.Split(verse.Text, "\n"),
+		Start: verse.Start,
+		End:   verse.End,
+	}
+}
+
+// SplitVerses разбивает текст песни на части и приводит каждую к одной строке.
+func SplitVerses(text string) []string {
+	var verses []string
+	for _, verse := range ParseVerses(text) {
+		verses = append(verses, FlattenVerse(verse))
+	}
+	return verses
+}
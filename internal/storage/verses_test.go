@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"github.com/TakuroBreath/song-library/internal/domain/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVerses(t *testing.T) {
+	verse := func(position int, kind, text string, start, end int) models.Verse {
+		return models.Verse{Position: position, Kind: kind, Text: text, Start: start, End: end}
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []models.Verse
+	}{
+		{name: "empty", text: "", want: nil},
+		{
+			name: "blank lines separate verses",
+			text: "a\nb\n\nc",
+			want: []models.Verse{verse(1, "verse", "a\nb", 0, 3), verse(2, "verse", "c", 5, 6)},
+		},
+		{
+			name: "crlf",
+			text: "a\r\nb\r\n\r\nc\r\n",
+			want: []models.Verse{verse(1, "verse", "a\nb", 0, 4), verse(2, "verse", "c", 8, 9)},
+		},
+		{
+			name: "escaped newlines",
+			text: `a\nb\n\nc`,
+			want: []models.Verse{verse(1, "verse", "a\nb", 0, 4), verse(2, "verse", "c", 8, 9)},
+		},
+		{
+			name: "multiple and whitespace-only blank lines",
+			text: "a\n\n\n\nb\n \n\t\nc",
+			want: []models.Verse{verse(1, "verse", "a", 0, 1), verse(2, "verse", "b", 5, 6), verse(3, "verse", "c", 11, 12)},
+		},
+		{
+			name: "leading and trailing blank lines do not separate",
+			text: "\n\n  a  \nb\n\n",
+			want: []models.Verse{verse(1, "verse", "a", 4, 5), verse(2, "verse", "b", 8, 9)},
+		},
+		{
+			name: "no blank lines split by line",
+			text: "x\ny\nz",
+			want: []models.Verse{verse(1, "verse", "x", 0, 1), verse(2, "verse", "y", 2, 3), verse(3, "verse", "z", 4, 5)},
+		},
+		{
+			name: "chorus marker",
+			text: "[Chorus]\nla la\n\nverse one",
+			want: []models.Verse{verse(1, "chorus", "la la", 9, 14), verse(2, "verse", "verse one", 16, 25)},
+		},
+		{
+			name: "headers without blank lines",
+			text: "Verse 1:\nfoo\n(Припев)\nbar\nBridge:\nbaz",
+			want: []models.Verse{verse(1, "verse", "foo", 9, 12), verse(2, "chorus", "bar", 22, 25), verse(3, "bridge", "baz", 34, 37)},
+		},
+		{
+			name: "unknown bracket line is text",
+			text: "[Guitar solo]\nfoo",
+			want: []models.Verse{verse(1, "verse", "[Guitar solo]", 0, 13), verse(2, "verse", "foo", 14, 17)},
+		},
+		{
+			name: "repeated verse is chorus",
+			text: "la la\n\nverse one\n\nla la",
+			want: []models.Verse{verse(1, "chorus", "la la", 0, 5), verse(2, "verse", "verse one", 7, 16), verse(3, "chorus", "la la", 18, 23)},
+		},
+		{
+			name: "non-ascii offsets in characters",
+			text: "Кино\nгруппа\n\nКровь ☆ 𝄞\n",
+			want: []models.Verse{verse(1, "verse", "Кино\nгруппа", 0, 11), verse(2, "verse", "Кровь ☆ 𝄞", 13, 22)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseVerses(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseVerses(%q) =\n%+v\nwant\n%+v", tt.text, got, tt.want)
+			}
+
+			// Границы части указывают на ее первую и последнюю строки в исходном тексте
+			runes := []rune(tt.text)
+			for _, v := range got {
+				span := string(runes[v.Start:v.End])
+				lines := strings.Split(v.Text, "\n")
+				if !strings.HasPrefix(span, lines[0]) || !strings.HasSuffix(span, lines[len(lines)-1]) {
+					t.Errorf("verse %d span %q does not match text %q", v.Position, span, v.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenAndStructureVerse(t *testing.T) {
+	v := models.Verse{Position: 2, Kind: models.VerseKindChorus, Text: "la la\nна-на", Start: 9, End: 20}
+
+	if got, want := FlattenVerse(v), "la la на-на"; got != want {
+		t.Errorf("FlattenVerse() = %q, want %q", got, want)
+	}
+
+	want := models.StructuredVerse{Index: 1, Kind: models.VerseKindChorus, Lines: []string{"la la", "на-на"}, Start: 9, End: 20}
+	if got := StructureVerse(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("StructureVerse() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_refresh_proposals;
+DROP INDEX IF EXISTS songs_refreshed_at_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS refreshed_at;
@@ -0,0 +1,22 @@
+-- Время последней сверки сведений песни с источниками; NULL означает, что песня помечена устаревшей
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS refreshed_at TIMESTAMPTZ;
+
+-- Существующие песни считаются сверенными сейчас, чтобы не запрашивать их все разом
+UPDATE songs SET refreshed_at = NOW() WHERE refreshed_at IS NULL AND enrichment_status = 'enriched';
+
+CREATE INDEX IF NOT EXISTS songs_refreshed_at_idx ON songs (refreshed_at) WHERE deleted_at IS NULL;
+
+-- Изменения из источников, ожидающие решения редактора; по одному на поле песни
+CREATE TABLE IF NOT EXISTS song_refresh_proposals (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    field VARCHAR(20) NOT NULL,
+    current_value TEXT NOT NULL,
+    proposed_value TEXT NOT NULL,
+    source VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (song_id, field)
+);
+
+CREATE INDEX IF NOT EXISTS song_refresh_proposals_status_idx ON song_refresh_proposals (status, id);
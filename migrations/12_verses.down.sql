@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_verses_version_idx;
+DROP TABLE IF EXISTS verses;
+ALTER TABLE songs DROP COLUMN IF EXISTS verses_version;
@@ -0,0 +1,6 @@
+-- Версия песни — номер последней правки, после которой песня существует. Служит для ETag
+-- и проверки If-Match; у существующих песен версия берется из истории изменений
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+UPDATE songs
+SET version = COALESCE((SELECT MAX(revision) FROM song_revisions WHERE song_revisions.song_id = songs.id), 1);
@@ -0,0 +1,15 @@
+-- Время добавления песни. У существующих песен берется из первой правки в истории изменений
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE songs
+SET created_at = first_revision.created_at
+FROM (
+    SELECT song_id, MIN(created_at) AS created_at
+    FROM song_revisions
+    GROUP BY song_id
+) AS first_revision
+WHERE first_revision.song_id = songs.id;
+
+-- Для сортировки списка по времени добавления; по group, song и release_date сортировку
+-- обслуживают индексы из 2_songs_keyset_indexes, по убыванию они читаются в обратном порядке
+CREATE INDEX IF NOT EXISTS songs_created_at_id_idx ON songs (created_at, id);
@@ -0,0 +1,21 @@
+-- Группа и название однозначно определяют песню вне корзины. Дубликаты, появившиеся из-за
+-- переименования до этой проверки, миграция не трогает: она прерывается, и их нужно переименовать
+-- или перенести в корзину вручную (см. README, раздел Database Migration)
+DO $$
+DECLARE
+    duplicate RECORD;
+BEGIN
+    SELECT "group", song, COUNT(*) AS songs INTO duplicate
+    FROM songs
+    WHERE deleted_at IS NULL
+    GROUP BY "group", song
+    HAVING COUNT(*) > 1
+    LIMIT 1;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'songs has % songs named (%, %) outside the trash; rename them or move them to the trash before this migration',
+            duplicate.songs, duplicate."group", duplicate.song;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_group_song_live_idx ON songs ("group", song) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- По дате выпуска список упорядочивается в виде YYYY-MM-DD, поэтому индексируется то же выражение,
+-- что и в запросах; индекс (release_date, id) из 2_songs_keyset_indexes остается для точного фильтра по дате
+CREATE INDEX IF NOT EXISTS songs_release_date_iso_id_idx ON songs ((
+    CASE WHEN release_date ~ '^[0-9]{2}\.[0-9]{2}\.[0-9]{4}$'
+    THEN substr(release_date, 7, 4) || '-' || substr(release_date, 4, 2) || '-' || substr(release_date, 1, 2)
+    ELSE release_date END
+), id);
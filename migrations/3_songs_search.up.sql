@@ -0,0 +1,8 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(song, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce("group", '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(text, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS songs_search_vector_idx ON songs USING GIN (search_vector);
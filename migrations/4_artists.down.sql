@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_artist_id_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS artist_id;
+DROP TABLE IF EXISTS artists;
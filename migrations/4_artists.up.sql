@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS artists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    country VARCHAR(100) NOT NULL DEFAULT '',
+    formed_year INTEGER,
+    description TEXT NOT NULL DEFAULT '',
+    links JSONB NOT NULL DEFAULT '[]'
+);
+
+INSERT INTO artists (name)
+SELECT DISTINCT "group" FROM songs
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS artist_id INTEGER REFERENCES artists (id);
+
+UPDATE songs s
+SET artist_id = a.id
+FROM artists a
+WHERE a.name = s."group";
+
+ALTER TABLE songs ALTER COLUMN artist_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS songs_artist_id_idx ON songs (artist_id, id);
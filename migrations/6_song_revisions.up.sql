@@ -0,0 +1,12 @@
+-- Правки переживают удаление песни, поэтому внешнего ключа на songs нет
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    before JSONB,
+    after JSONB,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (song_id, revision)
+);
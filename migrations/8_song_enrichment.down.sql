@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS enrichment_outbox;
+ALTER TABLE songs DROP COLUMN IF EXISTS enrichment_status;
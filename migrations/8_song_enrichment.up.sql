@@ -0,0 +1,13 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(30) NOT NULL DEFAULT 'enriched';
+
+-- Очередь обогащения (outbox): задача создается в одной транзакции с песней
+CREATE TABLE IF NOT EXISTS enrichment_outbox (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL UNIQUE REFERENCES songs(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_outbox_next_attempt_idx ON enrichment_outbox (next_attempt_at);
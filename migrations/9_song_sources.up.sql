@@ -0,0 +1,2 @@
+-- Источник каждого поля сведений песни: {"release_date": "info-api", "text": "lyrics-dir", ...}
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS sources JSONB NOT NULL DEFAULT '{}';
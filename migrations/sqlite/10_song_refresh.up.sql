@@ -0,0 +1,23 @@
+-- Время последней сверки сведений песни с источниками; NULL означает, что песня помечена устаревшей
+ALTER TABLE songs ADD COLUMN refreshed_at TEXT;
+
+-- Существующие песни считаются сверенными сейчас, чтобы не запрашивать их все разом
+UPDATE songs SET refreshed_at = strftime('%Y-%m-%dT%H:%M:%S.000000000Z', 'now')
+WHERE refreshed_at IS NULL AND enrichment_status = 'enriched';
+
+CREATE INDEX IF NOT EXISTS songs_refreshed_at_idx ON songs (refreshed_at);
+
+-- Изменения из источников, ожидающие решения редактора; по одному на поле песни
+CREATE TABLE IF NOT EXISTS song_refresh_proposals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    current_value TEXT NOT NULL,
+    proposed_value TEXT NOT NULL,
+    source TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TEXT NOT NULL,
+    UNIQUE (song_id, field)
+);
+
+CREATE INDEX IF NOT EXISTS song_refresh_proposals_status_idx ON song_refresh_proposals (status, id);
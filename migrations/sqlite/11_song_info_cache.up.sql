@@ -0,0 +1,12 @@
+-- Общий для экземпляров сервиса кэш ответов источников сведений о песнях
+CREATE TABLE IF NOT EXISTS song_info_cache (
+    key TEXT PRIMARY KEY,
+    release_date TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    link TEXT NOT NULL DEFAULT '',
+    sources TEXT NOT NULL DEFAULT '{}',
+    not_found INTEGER NOT NULL DEFAULT 0,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS song_info_cache_expires_at_idx ON song_info_cache (expires_at);
@@ -0,0 +1,15 @@
+-- Части текста песни, разобранные при записи. verses_version — версия разборщика, которой разобран
+-- текст; песни с устаревшей версией (0 — еще не разбирались) разбираются заново при запуске сервиса
+ALTER TABLE songs ADD COLUMN verses_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS verses (
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    text TEXT NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS songs_verses_version_idx ON songs (verses_version);
@@ -0,0 +1,13 @@
+-- Время добавления песни. У существующих песен берется из первой правки в истории изменений
+-- и приводится к формату остальных отметок времени
+ALTER TABLE songs ADD COLUMN created_at TEXT;
+
+UPDATE songs
+SET created_at = COALESCE(
+    (SELECT strftime('%Y-%m-%dT%H:%M:%S.000000000Z', MIN(created_at)) FROM song_revisions WHERE song_revisions.song_id = songs.id),
+    strftime('%Y-%m-%dT%H:%M:%S.000000000Z', 'now')
+);
+
+-- Для сортировки списка по времени добавления; по group, song и release_date сортировку
+-- обслуживают индексы из 2_songs_keyset_indexes, по убыванию они читаются в обратном порядке
+CREATE INDEX IF NOT EXISTS songs_created_at_id_idx ON songs (created_at, id);
@@ -0,0 +1,16 @@
+-- Группа и название однозначно определяют песню вне корзины. Дубликаты, появившиеся из-за
+-- переименования до этой проверки, миграция не трогает: она прерывается, и их нужно переименовать
+-- или перенести в корзину вручную (см. README, раздел Database Migration).
+-- RAISE доступен только в триггерах, поэтому проверка выполняется триггером временной таблицы
+CREATE TEMP TABLE songs_unique_name_check (checked INTEGER);
+
+CREATE TEMP TRIGGER songs_unique_name_check BEFORE INSERT ON songs_unique_name_check
+WHEN EXISTS (SELECT 1 FROM songs WHERE deleted_at IS NULL GROUP BY "group", song HAVING COUNT(*) > 1)
+BEGIN
+    SELECT RAISE(ABORT, 'songs has several songs with the same group and name outside the trash; rename them or move them to the trash before this migration');
+END;
+
+INSERT INTO songs_unique_name_check VALUES (1);
+DROP TABLE songs_unique_name_check;
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_group_song_live_idx ON songs ("group", song) WHERE deleted_at IS NULL;
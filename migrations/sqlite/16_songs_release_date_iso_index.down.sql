@@ -0,0 +1 @@
+DROP INDEX IF EXISTS songs_release_date_iso_id_idx;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group" TEXT NOT NULL,
+    song TEXT NOT NULL,
+    release_date TEXT NOT NULL,
+    text TEXT NOT NULL,
+    link TEXT NOT NULL
+);
@@ -0,0 +1,23 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS songs_fts USING fts5(
+    song,
+    "group",
+    text,
+    content = 'songs',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO songs_fts(songs_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts(rowid, song, "group", text) VALUES (new.id, new.song, new."group", new.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_delete AFTER DELETE ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, song, "group", text) VALUES ('delete', old.id, old.song, old."group", old.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_update AFTER UPDATE ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, song, "group", text) VALUES ('delete', old.id, old.song, old."group", old.text);
+    INSERT INTO songs_fts(rowid, song, "group", text) VALUES (new.id, new.song, new."group", new.text);
+END;
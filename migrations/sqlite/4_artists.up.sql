@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS artists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    country TEXT NOT NULL DEFAULT '',
+    formed_year INTEGER,
+    description TEXT NOT NULL DEFAULT '',
+    links TEXT NOT NULL DEFAULT '[]'
+);
+
+INSERT OR IGNORE INTO artists (name)
+SELECT DISTINCT "group" FROM songs;
+
+-- SQLite не позволяет добавить NOT NULL колонку со ссылкой без значения по умолчанию,
+-- поэтому artist_id всегда заполняется приложением.
+ALTER TABLE songs ADD COLUMN artist_id INTEGER REFERENCES artists (id);
+
+UPDATE songs
+SET artist_id = (SELECT id FROM artists WHERE artists.name = songs."group");
+
+CREATE INDEX IF NOT EXISTS songs_artist_id_idx ON songs (artist_id, id);
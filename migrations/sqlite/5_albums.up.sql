@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    artist_id INTEGER NOT NULL REFERENCES artists (id),
+    release_date TEXT NOT NULL DEFAULT '',
+    cover_link TEXT NOT NULL DEFAULT '',
+    UNIQUE (artist_id, title)
+);
+
+CREATE TABLE IF NOT EXISTS album_tracks (
+    album_id INTEGER NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (album_id, song_id),
+    UNIQUE (album_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS album_tracks_song_id_idx ON album_tracks (song_id);
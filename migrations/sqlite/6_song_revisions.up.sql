@@ -0,0 +1,12 @@
+-- Правки переживают удаление песни, поэтому внешнего ключа на songs нет
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    song_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    before TEXT,
+    after TEXT,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    UNIQUE (song_id, revision)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE songs ADD COLUMN enrichment_status TEXT NOT NULL DEFAULT 'enriched';
+
+-- Очередь обогащения (outbox): задача создается в одной транзакции с песней
+CREATE TABLE IF NOT EXISTS enrichment_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    song_id INTEGER NOT NULL UNIQUE REFERENCES songs(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TEXT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_outbox_next_attempt_idx ON enrichment_outbox (next_attempt_at);
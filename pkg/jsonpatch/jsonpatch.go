@@ -0,0 +1,337 @@
+// Package jsonpatch применяет к JSON-документам изменения в форматах JSON Merge Patch (RFC 7396)
+// и JSON Patch (RFC 6902). Документы представлены так, как их декодирует encoding/json в interface{}:
+// объекты — map[string]interface{}, массивы — []interface{}.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch означает, что изменение составлено неверно: неизвестная операция,
+	// отсутствующее значение или ошибка в указателе.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound означает, что в документе нет значения, на которое ссылается операция.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed означает, что значение в документе не совпало со значением операции test.
+	ErrTestFailed = errors.New("test failed")
+)
+
+// MergePatch применяет к документу изменение JSON Merge Patch и возвращает результат.
+// Документ не меняется: измененные объекты копируются.
+func MergePatch(doc, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	docObject, _ := doc.(map[string]interface{})
+	result := make(map[string]interface{}, len(docObject)+len(patchObject))
+	for key, value := range docObject {
+		result[key] = value
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = MergePatch(result[key], value)
+	}
+
+	return result
+}
+
+// Operation — операция JSON Patch. Value равно nil, если значение не передано; переданный null
+// хранится как json.RawMessage("null").
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// DecodePatch разбирает изменение JSON Patch — массив операций.
+func DecodePatch(data []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return ops, nil
+}
+
+// Apply применяет к документу операции JSON Patch по порядку и возвращает результат. Изменение
+// применяется целиком или не применяется вовсе: при ошибке документ остается прежним.
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	result := deepCopy(doc)
+
+	for i, op := range ops {
+		var err error
+		result, err = apply(result, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return result, nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			return set(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		result, _, err := remove(doc, path)
+		return result, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+
+		result, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(result, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901). Пустой указатель ссылается на весь документ.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = container[index]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return current, nil
+}
+
+// set заменяет существующее значение по пути и возвращает документ.
+func set(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+	default:
+		return nil, ErrPathNotFound
+	}
+	return doc, nil
+}
+
+// add добавляет значение по пути: заменяет член объекта или вставляет элемент массива
+// ("-" означает конец массива). Возвращает документ.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+		return doc, nil
+	case []interface{}:
+		index := len(container)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(container)); err != nil {
+				return nil, err
+			}
+		}
+
+		updated := make([]interface{}, 0, len(container)+1)
+		updated = append(updated, container[:index]...)
+		updated = append(updated, value)
+		updated = append(updated, container[index:]...)
+		return set(doc, path[:len(path)-1], updated)
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+// remove удаляет значение по пути и возвращает документ и удаленное значение.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, ok := container[last]
+		if !ok {
+			return nil, nil, ErrPathNotFound
+		}
+		delete(container, last)
+		return doc, value, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := container[index]
+		updated := make([]interface{}, 0, len(container)-1)
+		updated = append(updated, container[:index]...)
+		updated = append(updated, container[index+1:]...)
+		doc, err = set(doc, path[:len(path)-1], updated)
+		return doc, value, err
+	default:
+		return nil, nil, ErrPathNotFound
+	}
+}
+
+// arrayIndex разбирает индекс массива не больше max. Ведущие нули не допускаются.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if index > max {
+		return 0, ErrPathNotFound
+	}
+	return index, nil
+}
+
+// deepCopy копирует объекты и массивы документа, чтобы операции не меняли исходный документ.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = deepCopy(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = deepCopy(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,244 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) interface{} {
+	t.Helper()
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	return value
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "null deletes member", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "null for missing member", doc: `{"a":"b"}`, patch: `{"c":null}`, want: `{"a":"b"}`},
+		{name: "nested null deletes member", doc: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"b":null}}`, want: `{"a":{"d":"e"}}`},
+		{name: "array replaced whole", doc: `{"a":["b","c"]}`, patch: `{"a":["d"]}`, want: `{"a":["d"]}`},
+		{name: "non-object patch replaces document", doc: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "object patch over scalar", doc: `{"a":"b"}`, patch: `{"a":{"c":null,"d":"e"}}`, want: `{"a":{"d":"e"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got := MergePatch(doc, decode(t, tt.patch))
+
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("MergePatch() = %v, want %v", got, want)
+			}
+			if original := decode(t, tt.doc); !reflect.DeepEqual(doc, original) {
+				t.Errorf("MergePatch() changed the document to %v", doc)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "pointer escapes",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:  "escape decoded once",
+			doc:   `{"~1":1}`,
+			patch: `[{"op":"remove","path":"/~01"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "append with dash",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/-","value":3}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "insert before index",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/0","value":0}]`,
+			want:  `{"a":[0,1,2]}`,
+		},
+		{
+			name:  "insert at end index",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/2","value":3}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:    "insert past end",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"add","path":"/a/3","value":3}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "leading zero index",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"remove","path":"/a/01"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"a":{"b":[1,"c"]}}`,
+			patch: `[{"op":"test","path":"/a","value":{"b":[1,"c"]}},{"op":"remove","path":"/a/b/0"}]`,
+			want:  `{"a":{"b":["c"]}}`,
+		},
+		{
+			name:    "test fails",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"test","path":"/a","value":"c"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "failed test discards earlier operations",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"replace","path":"/a","value":"c"},{"op":"test","path":"/a","value":"b"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "test null value",
+			doc:   `{"a":null}`,
+			patch: `[{"op":"test","path":"/a","value":null}]`,
+			want:  `{"a":null}`,
+		},
+		{
+			name:    "value required",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"replace","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"replace","path":"/c","value":"d"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "move member",
+			doc:   `{"a":{"b":"c"},"d":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/d/e"}]`,
+			want:  `{"a":{},"d":{"e":"c"}}`,
+		},
+		{
+			name:  "move array element",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"move","from":"/a/0","path":"/a/-"}]`,
+			want:  `{"a":[2,3,1]}`,
+		},
+		{
+			name:  "move onto itself",
+			doc:   `{"a":{"b":"c"}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a"}]`,
+			want:  `{"a":{"b":"c"}}`,
+		},
+		{
+			name:    "move into own child",
+			doc:     `{"a":{"b":"c"}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/d"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "copy into own child",
+			doc:   `{"a":{"b":"c"}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/a/d"}]`,
+			want:  `{"a":{"b":"c","d":{"b":"c"}}}`,
+		},
+		{
+			name:  "copy is independent",
+			doc:   `{"a":{"b":"c"}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/d"},{"op":"replace","path":"/d/b","value":"e"}]`,
+			want:  `{"a":{"b":"c"},"d":{"b":"e"}}`,
+		},
+		{
+			name:    "copy from missing path",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"copy","from":"/c","path":"/d"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "remove whole document",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"remove","path":""}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "pointer without slash",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"remove","path":"a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown operation",
+			doc:     `{"a":"b"}`,
+			patch:   `[{"op":"rename","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("DecodePatch() error = %v", err)
+			}
+
+			doc := decode(t, tt.doc)
+			got, err := Apply(doc, ops)
+
+			if original := decode(t, tt.doc); !reflect.DeepEqual(doc, original) {
+				t.Errorf("Apply() changed the document to %v", doc)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodePatch(t *testing.T) {
+	if _, err := DecodePatch([]byte(`{"op":"add"}`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("DecodePatch(object) error = %v, want %v", err, ErrInvalidPatch)
+	}
+
+	ops, err := DecodePatch([]byte(`[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"}]`))
+	if err != nil {
+		t.Fatalf("DecodePatch() error = %v", err)
+	}
+	if string(ops[0].Value) != "null" {
+		t.Errorf("explicit null value = %q, want %q", ops[0].Value, "null")
+	}
+	if ops[1].Value != nil {
+		t.Errorf("omitted value = %q, want nil", ops[1].Value)
+	}
+}
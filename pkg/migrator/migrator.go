@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"log/slog"
 )
@@ -15,6 +16,20 @@ func Migrate(user, password, host, port, dbname string, log *slog.Logger) error
 		return err
 	}
 
+	return up(m, log)
+}
+
+// MigrateSQLite применяет миграции из migrations/sqlite к файлу базы данных SQLite.
+func MigrateSQLite(path string, log *slog.Logger) error {
+	m, err := migrate.New("file://migrations/sqlite", "sqlite://"+path)
+	if err != nil {
+		return err
+	}
+
+	return up(m, log)
+}
+
+func up(m *migrate.Migrate, log *slog.Logger) error {
 	if err := m.Up(); err != nil {
 		if errors.Is(err, migrate.ErrNoChange) {
 			log.Info("no changes to apply")